@@ -0,0 +1,89 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"google.golang.org/grpc/peer"
+)
+
+// auditCaller returns the network address of the client of the RPC of ctx,
+// or an empty string if the RPC was not received over the network.
+func auditCaller(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+// auditFileSize returns the size of filename, zero for a directory, and
+// whether it exists, so that an event can report the size of a file which an
+// operation is about to delete or replace. It returns false without looking
+// at the file if auditing is disabled.
+func (s *Service) auditFileSize(filename string) (size int64, exists bool) {
+	if !s.auditLog {
+		return 0, false
+	}
+	fi, err := s.storage.FileInfo(filename)
+	if err != nil {
+		return 0, false
+	}
+	if fi.IsDir() {
+		return 0, true
+	}
+	return fi.Size(), true
+}
+
+// auditBlobDetails returns the CommonBlobEventDetails of an operation on path.
+func auditBlobDetails(ctx context.Context, path string, size int64) eventpb.CommonBlobEventDetails {
+	return eventpb.CommonBlobEventDetails{
+		Caller: auditCaller(ctx),
+		Path:   path,
+		Bytes:  size,
+	}
+}
+
+// auditDelete reports the deletion of path, of which size bytes of files were
+// deleted.
+func (s *Service) auditDelete(ctx context.Context, path string, size int64, recursive bool) {
+	if !s.auditLog {
+		return
+	}
+	log.StructuredEvent(ctx, &eventpb.BlobDelete{
+		CommonBlobEventDetails: auditBlobDetails(ctx, path, size),
+		Recursive:              recursive,
+	})
+}
+
+// auditMove reports the move of the file at source, of size bytes, to
+// destination.
+func (s *Service) auditMove(ctx context.Context, source, destination string, size int64) {
+	if !s.auditLog {
+		return
+	}
+	log.StructuredEvent(ctx, &eventpb.BlobMove{
+		CommonBlobEventDetails: auditBlobDetails(ctx, source, size),
+		Destination:            destination,
+	})
+}
+
+// auditOverwrite reports that the file at path, of size bytes, was replaced.
+func (s *Service) auditOverwrite(ctx context.Context, path string, size int64) {
+	if !s.auditLog {
+		return
+	}
+	log.StructuredEvent(ctx, &eventpb.BlobOverwrite{
+		CommonBlobEventDetails: auditBlobDetails(ctx, path, size),
+	})
+}
@@ -13,6 +13,8 @@ package blobs
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -20,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -158,3 +161,92 @@ func benchmarkStreamingWriteFile(b *testing.B, tc *benchmarkTestCase) {
 		}
 	}
 }
+
+func BenchmarkListPaginated(b *testing.B) {
+	storage := newMemStorage()
+	const dirs, filesPerDir = 100, 100
+	for i := 0; i < dirs; i++ {
+		for j := 0; j < filesPerDir; j++ {
+			writeStorageFile(b, storage, fmt.Sprintf("dir/%03d/%03d.csv", i, j), nil)
+		}
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+	// Resume the listing close to its end, which should only read the
+	// directories holding the requested page.
+	token := base64.RawURLEncoding.EncodeToString([]byte("/dir/098/050.csv"))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := service.List(ctx, &blobspb.GlobRequest{
+			Pattern:   "dir",
+			PageSize:  100,
+			PageToken: token,
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(resp.Files) != 100 {
+			b.Fatalf("expected 100 files, got %d", len(resp.Files))
+		}
+	}
+}
+
+// discardGetStreamServer is a blobspb.Blob_GetStreamServer which discards
+// the chunks sent on it.
+type discardGetStreamServer struct {
+	blobspb.Blob_GetStreamServer
+	ctx context.Context
+}
+
+func (s discardGetStreamServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s discardGetStreamServer) Send(*blobspb.StreamChunk) error {
+	return nil
+}
+
+// BenchmarkSmallTransfers drives many small transfers through a blob
+// service, like a restore does, to measure the allocations of each transfer.
+// The buffers they are copied through are recycled across transfers.
+func BenchmarkSmallTransfers(b *testing.B) {
+	content := bytes.Repeat([]byte("a"), 1<<10)
+	service := NewBlobServiceWithStorage(newMemStorage(), ServiceOptions{})
+	ctx := context.Background()
+	const filename = "test/small.csv"
+
+	b.Run("put", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(content)))
+		for i := 0; i < b.N; i++ {
+			if err := service.PutStream(
+				newTestPutStreamServer(ctx, filename, [][]byte{content}, nil),
+			); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("get", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(content)))
+		for i := 0; i < b.N; i++ {
+			if err := service.GetStream(
+				&blobspb.GetRequest{Filename: filename}, discardGetStreamServer{ctx: ctx},
+			); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(content)))
+		for i := 0; i < b.N; i++ {
+			if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+				Source: filename, Destination: "test/copy.csv",
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
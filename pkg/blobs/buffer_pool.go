@@ -0,0 +1,64 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// The buffers of a bufferPool have a power of two capacity between
+// 1<<minPooledBufferShift and 1<<maxPooledBufferShift, which is maxChunkSize.
+const (
+	minPooledBufferShift = 12
+	maxPooledBufferShift = 22
+)
+
+// bufferPool recycles the buffers which file contents are copied through, so
+// that the many small transfers of e.g. a restore do not each allocate their
+// own chunk-sized buffers. Buffers are pooled by size class, so that streams
+// asking for different chunk sizes do not get oversized buffers.
+type bufferPool struct {
+	pools [maxPooledBufferShift - minPooledBufferShift + 1]sync.Pool
+}
+
+// buffers is the bufferPool shared by the blob services and clients of the
+// process.
+var buffers bufferPool
+
+// get returns a buffer of length size, which should be returned with put once
+// it is no longer used.
+func (p *bufferPool) get(size int) *[]byte {
+	shift := minPooledBufferShift
+	if size > 1<<minPooledBufferShift {
+		shift = bits.Len(uint(size - 1))
+	}
+	if shift > maxPooledBufferShift {
+		buf := make([]byte, size)
+		return &buf
+	}
+	if buf, ok := p.pools[shift-minPooledBufferShift].Get().(*[]byte); ok {
+		*buf = (*buf)[:size]
+		return buf
+	}
+	buf := make([]byte, size, 1<<shift)
+	return &buf
+}
+
+// put returns a buffer obtained from get to the pool. Its content must no
+// longer be referenced.
+func (p *bufferPool) put(buf *[]byte) {
+	c := cap(*buf)
+	if c&(c-1) != 0 || c < 1<<minPooledBufferShift || c > 1<<maxPooledBufferShift {
+		return
+	}
+	p.pools[bits.Len(uint(c))-1-minPooledBufferShift].Put(buf)
+}
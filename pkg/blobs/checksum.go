@@ -0,0 +1,101 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
+	"github.com/cockroachdb/errors"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// newChecksumHash returns a hash.Hash implementing the given algorithm.
+func newChecksumHash(algorithm blobspb.ChecksumAlgorithm) (hash.Hash, error) {
+	switch algorithm {
+	case blobspb.ChecksumAlgorithm_CRC32C:
+		return crc32.New(crc32cTable), nil
+	case blobspb.ChecksumAlgorithm_SHA256:
+		return sha256.New(), nil
+	default:
+		return nil, invalidArgumentf("unsupported checksum algorithm %s", algorithm)
+	}
+}
+
+// checksum returns the hex encoded digest of content using the given
+// algorithm.
+func checksum(content io.Reader, algorithm blobspb.ChecksumAlgorithm) (string, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrChecksumMismatch marks the errors returned when the content of a file
+// does not have the checksum it was expected to have.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumVerifier is an io.Reader which hashes the content read through it,
+// so that the digest of the content can be checked once it was read.
+type checksumVerifier struct {
+	r         io.Reader
+	h         hash.Hash
+	filename  string
+	algorithm blobspb.ChecksumAlgorithm
+	expected  string
+}
+
+// newChecksumVerifier returns a checksumVerifier which expects the content of
+// filename read from r to have the hex encoded digest expected.
+func newChecksumVerifier(
+	r io.Reader, filename string, algorithm blobspb.ChecksumAlgorithm, expected string,
+) (*checksumVerifier, error) {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &checksumVerifier{
+		r:         r,
+		h:         h,
+		filename:  filename,
+		algorithm: algorithm,
+		expected:  expected,
+	}, nil
+}
+
+func (v *checksumVerifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	_, _ = v.h.Write(p[:n])
+	return n, err
+}
+
+// verify returns an error marked with ErrChecksumMismatch if the digest of
+// the content read so far is not the expected one.
+func (v *checksumVerifier) verify() error {
+	if digest := hex.EncodeToString(v.h.Sum(nil)); !strings.EqualFold(digest, v.expected) {
+		return errors.Mark(
+			errors.Errorf("%s checksum of %q is %s but %s was expected",
+				v.algorithm, v.filename, digest, v.expected),
+			ErrChecksumMismatch,
+		)
+	}
+	return nil
+}
@@ -13,12 +13,17 @@ package blobs
 import (
 	"context"
 	"io"
+	"strconv"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -31,9 +36,25 @@ type BlobClient interface {
 	// read the contents.
 	ReadFile(ctx context.Context, file string, offset int64) (io.ReadCloser, int64, error)
 
+	// ReaderAt returns an io.ReaderAt over the named file on the requested
+	// node, along with the size of the file. Each ReadAt fetches the
+	// requested range of the file. If readAhead is positive, reads of fewer
+	// bytes fetch readAhead bytes instead and keep the bytes which were not
+	// requested for the next reads. The returned io.ReaderAt uses ctx for
+	// all of its reads.
+	ReaderAt(ctx context.Context, file string, readAhead int) (io.ReaderAt, int64, error)
+
 	// Writer opens the named payload on the requested node for writing.
 	Writer(ctx context.Context, file string) (io.WriteCloser, error)
 
+	// WriterAt returns an io.WriterAt over the named file on the requested
+	// node, e.g. to assemble the file from chunks received out of order. The
+	// file is created by the first write if needed, and a write past its end
+	// fills the gap with zeroes. Unlike with Writer, each write is applied to
+	// the file in place, so readers can observe a partially written file. The
+	// returned io.WriterAt uses ctx for all of its writes.
+	WriterAt(ctx context.Context, file string) io.WriterAt
+
 	// List lists the corresponding filenames from the requested node.
 	// The requested node can be the current node.
 	List(ctx context.Context, pattern string) ([]string, error)
@@ -45,17 +66,84 @@ type BlobClient interface {
 	Stat(ctx context.Context, file string) (*blobspb.BlobStat, error)
 }
 
+// ErrNotFound marks the errors returned by a BlobClient for a file which does
+// not exist.
+var ErrNotFound = errors.New("file not found")
+
+// IsNotFound returns true if err reports that a file does not exist. Besides
+// errors marked with ErrNotFound, it recognizes the errors returned for a
+// missing file by a Storage and by the blob service, so that callers do not
+// have to match their messages. See ErrorCode for other kinds of errors.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || ErrorCode(err) == codes.NotFound
+}
+
+// markNotFound marks err with ErrNotFound if it reports that a file does not
+// exist. Its message is left untouched.
+func markNotFound(err error) error {
+	if IsNotFound(err) {
+		return errors.Mark(err, ErrNotFound)
+	}
+	return err
+}
+
+// RetryPolicy controls how a remote BlobClient retries the operations which
+// fail because the node holding the files is unavailable, e.g. during a
+// transient network failure. Only the operations which can safely be repeated
+// are retried: reads, stats and listings, as well as the opening of the stream
+// of a write, before any data is sent on it.
+type RetryPolicy struct {
+	// Options holds the exponential backoff between attempts. Its MaxRetries
+	// bounds the number of retries of an operation.
+	Options retry.Options
+	// MaxDuration bounds the time spent on an operation: no attempt starts
+	// once it has elapsed since the first one. Zero means no bound.
+	MaxDuration time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy of the remote blob clients returned
+// by NewBlobClientFactory.
+var DefaultRetryPolicy = RetryPolicy{
+	Options: retry.Options{
+		InitialBackoff:      100 * time.Millisecond,
+		MaxBackoff:          5 * time.Second,
+		Multiplier:          2,
+		MaxRetries:          5,
+		RandomizationFactor: 0.15,
+	},
+	MaxDuration: time.Minute,
+}
+
+// do runs fn until it succeeds, fails with an error which is not worth
+// retrying, or the policy gives up, and returns the last error of fn.
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	start := timeutil.Now()
+	var err error
+	for r := retry.StartWithCtx(ctx, p.Options); r.Next(); {
+		err = fn()
+		if ErrorCode(err) != codes.Unavailable {
+			return err
+		}
+		if p.MaxDuration > 0 && timeutil.Since(start) >= p.MaxDuration {
+			break
+		}
+	}
+	return err
+}
+
 var _ BlobClient = &remoteClient{}
 
 // remoteClient uses the node dialer and blob service clients
 // to Read or Write bulk files from/to other nodes.
 type remoteClient struct {
-	blobClient blobspb.BlobClient
+	blobClient  blobspb.BlobClient
+	retryPolicy RetryPolicy
 }
 
-// newRemoteClient instantiates a remote blob service client.
-func newRemoteClient(blobClient blobspb.BlobClient) BlobClient {
-	return &remoteClient{blobClient: blobClient}
+// newRemoteClient instantiates a remote blob service client which retries
+// operations according to retryPolicy.
+func newRemoteClient(blobClient blobspb.BlobClient, retryPolicy RetryPolicy) BlobClient {
+	return &remoteClient{blobClient: blobClient, retryPolicy: retryPolicy}
 }
 
 func (c *remoteClient) ReadFile(
@@ -66,52 +154,248 @@ func (c *remoteClient) ReadFile(
 	if err != nil {
 		return nil, 0, err
 	}
-	stream, err := c.blobClient.GetStream(ctx, &blobspb.GetRequest{
-		Filename: file,
-		Offset:   offset,
+	var stream blobspb.Blob_GetStreamClient
+	err = c.retryPolicy.do(ctx, func() (err error) {
+		stream, err = c.blobClient.GetStream(ctx, &blobspb.GetRequest{
+			Filename: file,
+			Offset:   offset,
+		})
+		return err
 	})
 	return newGetStreamReader(stream), st.Filesize, errors.Wrap(err, "fetching file")
 }
 
+func (c *remoteClient) ReaderAt(
+	ctx context.Context, file string, readAhead int,
+) (io.ReaderAt, int64, error) {
+	st, err := c.Stat(ctx, file)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &blobReaderAt{
+		ctx:       ctx,
+		readRange: c.readRange(file),
+		size:      st.Filesize,
+		readAhead: readAhead,
+	}, st.Filesize, nil
+}
+
+// readRange returns a readRangeFunc which reads the ranges of file with
+// ranged GetStream requests.
+func (c *remoteClient) readRange(file string) readRangeFunc {
+	return func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		// The stream is cancelled once the range has been read, as it is not
+		// read up to its end.
+		ctx, cancel := context.WithCancel(ctx)
+		var stream blobspb.Blob_GetStreamClient
+		err := c.retryPolicy.do(ctx, func() (err error) {
+			stream, err = c.blobClient.GetStream(ctx, &blobspb.GetRequest{
+				Filename: file,
+				Offset:   offset,
+				Length:   length,
+			})
+			return err
+		})
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "fetching file")
+		}
+		return readCloser{
+			Reader: newGetStreamReader(stream),
+			close:  func() error { cancel(); return nil },
+		}, nil
+	}
+}
+
+// PutStreamOptions configures the writer returned by NewPutStreamWriter.
+type PutStreamOptions struct {
+	// ChunkSize is the size of the chunks sent on the stream. The default is
+	// used if it is not positive.
+	ChunkSize int
+	// ExpectedSize, if positive, is the number of bytes which will be written.
+	// It is declared to the blob service, which fails the write rather than
+	// leave a truncated file behind if it receives another number of bytes.
+	ExpectedSize int64
+	// Sync, if set, makes the blob service sync the file to disk, along with
+	// its directory, before Close returns. See WriteOptions.
+	Sync bool
+}
+
+// NewPutStreamWriter opens a PutStream with client to write file, and returns
+// an io.WriteCloser over it. What is written is sent in chunks, and the last
+// chunk is sent on Close.
+//
+// The file is only written once Close returns without error: Close waits for
+// the blob service to move the file into place and returns the error of the
+// service if it could not. A caller which gives up on the file should cancel
+// ctx, so that the service discards what it received.
+func NewPutStreamWriter(
+	ctx context.Context, client blobspb.BlobClient, file string, opts PutStreamOptions,
+) (io.WriteCloser, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "filename", file)
+	if opts.ExpectedSize > 0 {
+		ctx = metadata.AppendToOutgoingContext(
+			ctx, "expected-size", strconv.FormatInt(opts.ExpectedSize, 10),
+		)
+	}
+	if opts.Sync {
+		ctx = metadata.AppendToOutgoingContext(ctx, "sync", "true")
+	}
+	stream, err := client.PutStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = chunkSize
+	}
+	buf := make([]byte, 0, size)
+	return &streamWriter{s: stream, buf: blobspb.StreamChunk{Payload: buf}}, nil
+}
+
+// CopyWithProgress copies source to destination on the node of client, like
+// CopyBlob, and calls progress, if set, with the number of bytes copied so far
+// and the size of source each time the blob service reports the progress of
+// the copy. It returns once the copy completed.
+func CopyWithProgress(
+	ctx context.Context,
+	client blobspb.BlobClient,
+	source, destination string,
+	progress func(copied, total int64),
+) error {
+	// The stream is cancelled once the copy completed, as the final message
+	// is not followed by anything worth waiting for.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.CopyBlobProgress(ctx, &blobspb.CopyRequest{
+		Source:      source,
+		Destination: destination,
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return errors.Errorf(
+				"copying %q to %q: stream ended before the copy completed", source, destination,
+			)
+		}
+		if err != nil {
+			return markNotFound(err)
+		}
+		if progress != nil {
+			progress(msg.BytesCopied, msg.TotalBytes)
+		}
+		if msg.Done {
+			return nil
+		}
+	}
+}
+
+// streamWriter is the io.WriteCloser returned by NewPutStreamWriter. It
+// buffers what is written to it until it has a full chunk to send.
 type streamWriter struct {
 	s   blobspb.Blob_PutStreamClient
 	buf blobspb.StreamChunk
+	// err is the error which made the stream fail, if any. It is returned by
+	// all the calls which follow.
+	err error
 }
 
 func (w *streamWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
 	n := 0
 	for len(p) > 0 {
-		l := copy(w.buf.Payload[:cap(w.buf.Payload)], p)
-		w.buf.Payload = w.buf.Payload[:l]
+		l := copy(w.buf.Payload[len(w.buf.Payload):cap(w.buf.Payload)], p)
+		w.buf.Payload = w.buf.Payload[:len(w.buf.Payload)+l]
 		p = p[l:]
-		if l > 0 {
-			if err := w.s.Send(&w.buf); err != nil {
+		n += l
+		if len(w.buf.Payload) == cap(w.buf.Payload) {
+			if err := w.flush(); err != nil {
 				return n, err
 			}
 		}
-		n += l
 	}
 	return n, nil
 }
 
+// flush sends the buffered chunk.
+func (w *streamWriter) flush() error {
+	if err := w.s.Send(&w.buf); err != nil {
+		if err == io.EOF {
+			// The service aborted the stream, and its error is only returned by
+			// CloseAndRecv.
+			if _, recvErr := w.s.CloseAndRecv(); recvErr != nil {
+				err = recvErr
+			}
+		}
+		w.err = errors.Wrap(err, "sending chunk")
+		return w.err
+	}
+	w.buf.Payload = w.buf.Payload[:0]
+	return nil
+}
+
 func (w *streamWriter) Close() error {
-	_, err := w.s.CloseAndRecv()
-	return err
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf.Payload) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if _, err := w.s.CloseAndRecv(); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = errors.New("writer is closed")
+	return nil
 }
 
 func (c *remoteClient) Writer(ctx context.Context, file string) (io.WriteCloser, error) {
-	ctx = metadata.AppendToOutgoingContext(ctx, "filename", file)
-	stream, err := c.blobClient.PutStream(ctx)
-	if err != nil {
-		return nil, err
-	}
-	buf := make([]byte, 0, chunkSize)
-	return &streamWriter{s: stream, buf: blobspb.StreamChunk{Payload: buf}}, nil
+	var w io.WriteCloser
+	err := c.retryPolicy.do(ctx, func() (err error) {
+		w, err = NewPutStreamWriter(ctx, c.blobClient, file, PutStreamOptions{})
+		return err
+	})
+	return w, err
+}
+
+// writerAtFunc is an io.WriterAt which writes with a function.
+type writerAtFunc func(p []byte, off int64) (int, error)
+
+func (f writerAtFunc) WriteAt(p []byte, off int64) (int, error) { return f(p, off) }
+
+func (c *remoteClient) WriterAt(ctx context.Context, file string) io.WriterAt {
+	return writerAtFunc(func(p []byte, off int64) (int, error) {
+		// Writing the same bytes at the same offset again is harmless, so the
+		// write can be retried.
+		err := c.retryPolicy.do(ctx, func() error {
+			_, err := c.blobClient.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+				Filename: file,
+				Offset:   off,
+				Payload:  p,
+			})
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
 }
 
 func (c *remoteClient) List(ctx context.Context, pattern string) ([]string, error) {
-	resp, err := c.blobClient.List(ctx, &blobspb.GlobRequest{
-		Pattern: pattern,
+	var resp *blobspb.GlobResponse
+	err := c.retryPolicy.do(ctx, func() (err error) {
+		resp, err = c.blobClient.List(ctx, &blobspb.GlobRequest{
+			Pattern: pattern,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "fetching list")
@@ -123,15 +407,19 @@ func (c *remoteClient) Delete(ctx context.Context, file string) error {
 	_, err := c.blobClient.Delete(ctx, &blobspb.DeleteRequest{
 		Filename: file,
 	})
-	return err
+	return markNotFound(err)
 }
 
 func (c *remoteClient) Stat(ctx context.Context, file string) (*blobspb.BlobStat, error) {
-	resp, err := c.blobClient.Stat(ctx, &blobspb.StatRequest{
-		Filename: file,
+	var resp *blobspb.BlobStat
+	err := c.retryPolicy.do(ctx, func() (err error) {
+		resp, err = c.blobClient.Stat(ctx, &blobspb.StatRequest{
+			Filename: file,
+		})
+		return err
 	})
 	if err != nil {
-		return nil, err
+		return nil, markNotFound(err)
 	}
 	return resp, nil
 }
@@ -156,23 +444,56 @@ func NewLocalClient(externalIODir string) (BlobClient, error) {
 func (c *localClient) ReadFile(
 	ctx context.Context, file string, offset int64,
 ) (io.ReadCloser, int64, error) {
-	return c.localStorage.ReadFile(file, offset)
+	r, size, err := c.localStorage.ReadFile(file, offset)
+	return r, size, markNotFound(err)
+}
+
+func (c *localClient) ReaderAt(
+	ctx context.Context, file string, readAhead int,
+) (io.ReaderAt, int64, error) {
+	st, err := c.localStorage.Stat(file)
+	if err != nil {
+		return nil, 0, markNotFound(err)
+	}
+	readRange := func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		content, _, err := c.localStorage.ReadFile(file, offset)
+		if err != nil {
+			return nil, markNotFound(err)
+		}
+		return readCloser{Reader: io.LimitReader(content, length), close: content.Close}, nil
+	}
+	return &blobReaderAt{
+		ctx:       ctx,
+		readRange: readRange,
+		size:      st.Filesize,
+		readAhead: readAhead,
+	}, st.Filesize, nil
 }
 
 func (c *localClient) Writer(ctx context.Context, file string) (io.WriteCloser, error) {
 	return c.localStorage.Writer(ctx, file)
 }
 
+func (c *localClient) WriterAt(ctx context.Context, file string) io.WriterAt {
+	return writerAtFunc(func(p []byte, off int64) (int, error) {
+		if _, err := c.localStorage.WriteAt(file, off, p); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	})
+}
+
 func (c *localClient) List(ctx context.Context, pattern string) ([]string, error) {
 	return c.localStorage.List(pattern)
 }
 
 func (c *localClient) Delete(ctx context.Context, file string) error {
-	return c.localStorage.Delete(file)
+	return markNotFound(c.localStorage.Delete(file))
 }
 
 func (c *localClient) Stat(ctx context.Context, file string) (*blobspb.BlobStat, error) {
-	return c.localStorage.Stat(file)
+	resp, err := c.localStorage.Stat(file)
+	return resp, markNotFound(err)
 }
 
 // BlobClientFactory creates a blob client based on the nodeID we are dialing.
@@ -190,7 +511,7 @@ func NewBlobClientFactory(
 		if err != nil {
 			return nil, errors.Wrapf(err, "connecting to node %d", dialing)
 		}
-		return newRemoteClient(blobspb.NewBlobClient(conn)), nil
+		return newRemoteClient(blobspb.NewBlobClient(conn), DefaultRetryPolicy), nil
 	}
 }
 
@@ -31,8 +31,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/netutil"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func createTestResources(t testing.TB) (string, string, *stop.Stopper, func()) {
@@ -56,7 +61,7 @@ func setUpService(
 	remoteExternalDir string,
 ) BlobClientFactory {
 	s := rpc.NewServer(rpcContext)
-	remoteBlobServer, err := NewBlobService(remoteExternalDir)
+	remoteBlobServer, err := NewBlobService(remoteExternalDir, ServiceOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -67,7 +72,7 @@ func setUpService(
 	}
 
 	s2 := rpc.NewServer(rpcContext)
-	localBlobServer, err := NewBlobService(localExternalDir)
+	localBlobServer, err := NewBlobService(localExternalDir, ServiceOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -547,3 +552,486 @@ func TestBlobClientStat(t *testing.T) {
 		})
 	}
 }
+
+func TestBlobClientNotFound(t *testing.T) {
+	localNodeID := roachpb.NodeID(1)
+	remoteNodeID := roachpb.NodeID(2)
+	localExternalDir, remoteExternalDir, stopper, cleanUpFn := createTestResources(t)
+	defer cleanUpFn()
+
+	ctx := context.Background()
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	rpcContext := rpc.NewInsecureTestingContext(ctx, clock, stopper)
+	rpcContext.TestingAllowNamedRPCToAnonymousServer = true
+
+	blobClientFactory := setUpService(t, rpcContext, localNodeID, remoteNodeID, localExternalDir, remoteExternalDir)
+
+	writeTestFile(t, filepath.Join(localExternalDir, "test/local.csv"), []byte("local_file"))
+	writeTestFile(t, filepath.Join(remoteExternalDir, "test/remote.csv"), []byte("remote_file"))
+
+	for _, nodeID := range []roachpb.NodeID{localNodeID, remoteNodeID} {
+		t.Run(fmt.Sprintf("node-%d", nodeID), func(t *testing.T) {
+			blobClient, err := blobClientFactory(ctx, nodeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tc := range []struct {
+				name string
+				fn   func() error
+			}{
+				{"read", func() error {
+					_, _, err := blobClient.ReadFile(ctx, "test/missing.csv", 0)
+					return err
+				}},
+				{"delete", func() error {
+					return blobClient.Delete(ctx, "test/missing.csv")
+				}},
+				{"stat", func() error {
+					_, err := blobClient.Stat(ctx, "test/missing.csv")
+					return err
+				}},
+			} {
+				t.Run(tc.name, func(t *testing.T) {
+					err := tc.fn()
+					if !IsNotFound(err) || !errors.Is(err, ErrNotFound) {
+						t.Fatalf("expected a not found error, got %v", err)
+					}
+				})
+			}
+
+			// Other errors are not mistaken for missing files.
+			err = blobClient.Delete(ctx, "test")
+			if err == nil || IsNotFound(err) {
+				t.Fatalf("expected a directory not empty error, got %v", err)
+			}
+		})
+	}
+}
+
+// flakyBlobClient is a blobspb.BlobClient whose Stat, List and Delete fail
+// with err until they have been called failures times.
+type flakyBlobClient struct {
+	blobspb.BlobClient
+	err      error
+	failures int
+	calls    int
+}
+
+func (c *flakyBlobClient) call() error {
+	c.calls++
+	if c.calls <= c.failures {
+		return c.err
+	}
+	return nil
+}
+
+func (c *flakyBlobClient) Stat(
+	ctx context.Context, in *blobspb.StatRequest, opts ...grpc.CallOption,
+) (*blobspb.BlobStat, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	return &blobspb.BlobStat{Filesize: 1}, nil
+}
+
+func (c *flakyBlobClient) List(
+	ctx context.Context, in *blobspb.GlobRequest, opts ...grpc.CallOption,
+) (*blobspb.GlobResponse, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	return &blobspb.GlobResponse{Files: []string{"/a.txt"}}, nil
+}
+
+func (c *flakyBlobClient) Delete(
+	ctx context.Context, in *blobspb.DeleteRequest, opts ...grpc.CallOption,
+) (*blobspb.DeleteResponse, error) {
+	if err := c.call(); err != nil {
+		return nil, err
+	}
+	return &blobspb.DeleteResponse{}, nil
+}
+
+func TestBlobClientRetry(t *testing.T) {
+	ctx := context.Background()
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	policy := RetryPolicy{
+		Options: retry.Options{
+			InitialBackoff: time.Microsecond,
+			MaxBackoff:     time.Microsecond,
+			MaxRetries:     3,
+		},
+	}
+
+	t.Run("retries-transient-errors", func(t *testing.T) {
+		c := &flakyBlobClient{err: unavailable, failures: 2}
+		client := newRemoteClient(c, policy)
+		if _, err := client.Stat(ctx, "a.txt"); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 3, c.calls)
+
+		c.calls = 0
+		files, err := client.List(ctx, "*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"/a.txt"}, files)
+		assert.Equal(t, 3, c.calls)
+	})
+	t.Run("max-retries", func(t *testing.T) {
+		c := &flakyBlobClient{err: unavailable, failures: 10}
+		_, err := newRemoteClient(c, policy).Stat(ctx, "a.txt")
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected an unavailable error, got %v", err)
+		}
+		assert.Equal(t, 4, c.calls)
+	})
+	t.Run("max-duration", func(t *testing.T) {
+		c := &flakyBlobClient{err: unavailable, failures: 10}
+		policy := policy
+		policy.MaxDuration = time.Nanosecond
+		_, err := newRemoteClient(c, policy).Stat(ctx, "a.txt")
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected an unavailable error, got %v", err)
+		}
+		assert.Equal(t, 1, c.calls)
+	})
+	t.Run("permanent-errors", func(t *testing.T) {
+		c := &flakyBlobClient{err: status.Error(codes.NotFound, "no such file"), failures: 1}
+		_, err := newRemoteClient(c, policy).Stat(ctx, "a.txt")
+		if !IsNotFound(err) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+		assert.Equal(t, 1, c.calls)
+	})
+	t.Run("delete-not-retried", func(t *testing.T) {
+		// A deletion which failed may still have been performed, in which case
+		// another attempt would fail anyway.
+		c := &flakyBlobClient{err: unavailable, failures: 1}
+		err := newRemoteClient(c, policy).Delete(ctx, "a.txt")
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("expected an unavailable error, got %v", err)
+		}
+		assert.Equal(t, 1, c.calls)
+	})
+}
+
+func TestBlobClientReaderAt(t *testing.T) {
+	localNodeID := roachpb.NodeID(1)
+	remoteNodeID := roachpb.NodeID(2)
+	localExternalDir, remoteExternalDir, stopper, cleanUpFn := createTestResources(t)
+	defer cleanUpFn()
+
+	ctx := context.Background()
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	rpcContext := rpc.NewInsecureTestingContext(ctx, clock, stopper)
+	rpcContext.TestingAllowNamedRPCToAnonymousServer = true
+
+	blobClientFactory := setUpService(t, rpcContext, localNodeID, remoteNodeID, localExternalDir, remoteExternalDir)
+
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	writeTestFile(t, filepath.Join(localExternalDir, "test/file.sst"), content)
+	writeTestFile(t, filepath.Join(remoteExternalDir, "test/file.sst"), content)
+
+	for _, nodeID := range []roachpb.NodeID{localNodeID, remoteNodeID} {
+		for _, readAhead := range []int{0, 64} {
+			t.Run(fmt.Sprintf("node-%d/read-ahead-%d", nodeID, readAhead), func(t *testing.T) {
+				blobClient, err := blobClientFactory(ctx, nodeID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, size, err := blobClient.ReaderAt(ctx, "test/file.sst", readAhead)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, int64(len(content)), size)
+
+				for _, tc := range []struct {
+					offset, length int
+				}{
+					{0, 10}, {10, 10}, {500, 100}, {20, 10}, {0, 1000}, {990, 10},
+				} {
+					p := make([]byte, tc.length)
+					n, err := r.ReadAt(p, int64(tc.offset))
+					if err != nil {
+						t.Fatal(err)
+					}
+					assert.Equal(t, tc.length, n)
+					assert.Equal(t, content[tc.offset:tc.offset+tc.length], p)
+				}
+
+				// A read past the end of the file returns what is left, along
+				// with io.EOF.
+				p := make([]byte, 20)
+				n, err := r.ReadAt(p, 990)
+				if err != io.EOF {
+					t.Fatalf("expected io.EOF, got %v", err)
+				}
+				assert.Equal(t, content[990:], p[:n])
+				if _, err := r.ReadAt(p, 1000); err != io.EOF {
+					t.Fatalf("expected io.EOF, got %v", err)
+				}
+
+				if _, _, err := blobClient.ReaderAt(ctx, "test/missing.sst", readAhead); !IsNotFound(err) {
+					t.Fatalf("expected a not found error, got %v", err)
+				}
+			})
+		}
+	}
+
+	t.Run("read-ahead-coalesces-reads", func(t *testing.T) {
+		var fetches int
+		r := &blobReaderAt{
+			ctx: ctx,
+			readRange: func(_ context.Context, offset, length int64) (io.ReadCloser, error) {
+				fetches++
+				return ioutil.NopCloser(bytes.NewReader(content[offset : offset+length])), nil
+			},
+			size:      int64(len(content)),
+			readAhead: 64,
+		}
+		p := make([]byte, 16)
+		for _, offset := range []int64{0, 16, 32, 48} {
+			if _, err := r.ReadAt(p, offset); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, content[offset:offset+16], p)
+		}
+		assert.Equal(t, 1, fetches)
+		if _, err := r.ReadAt(p, 60); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, content[60:76], p)
+		assert.Equal(t, 2, fetches)
+	})
+}
+
+func TestBlobClientWriterAt(t *testing.T) {
+	localNodeID := roachpb.NodeID(1)
+	remoteNodeID := roachpb.NodeID(2)
+	localExternalDir, remoteExternalDir, stopper, cleanUpFn := createTestResources(t)
+	defer cleanUpFn()
+
+	ctx := context.Background()
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	rpcContext := rpc.NewInsecureTestingContext(ctx, clock, stopper)
+	rpcContext.TestingAllowNamedRPCToAnonymousServer = true
+
+	blobClientFactory := setUpService(t, rpcContext, localNodeID, remoteNodeID, localExternalDir, remoteExternalDir)
+
+	for _, tc := range []struct {
+		nodeID roachpb.NodeID
+		dir    string
+	}{
+		{localNodeID, localExternalDir},
+		{remoteNodeID, remoteExternalDir},
+	} {
+		t.Run(fmt.Sprintf("node-%d", tc.nodeID), func(t *testing.T) {
+			blobClient, err := blobClientFactory(ctx, tc.nodeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := blobClient.WriterAt(ctx, "test/assembled.sst")
+			// The chunks are written out of order, and the first one leaves a
+			// gap, which is zero-filled until it is written.
+			for _, chunk := range []struct {
+				offset  int64
+				payload string
+			}{
+				{6, "world"}, {0, "hello,"}, {11, "!"},
+			} {
+				n, err := w.WriteAt([]byte(chunk.payload), chunk.offset)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, len(chunk.payload), n)
+				if chunk.offset == 6 {
+					content, err := ioutil.ReadFile(filepath.Join(tc.dir, "test/assembled.sst"))
+					if err != nil {
+						t.Fatal(err)
+					}
+					assert.Equal(t, "\x00\x00\x00\x00\x00\x00world", string(content))
+				}
+			}
+			content, err := ioutil.ReadFile(filepath.Join(tc.dir, "test/assembled.sst"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "hello,world!", string(content))
+
+			if _, err := w.WriteAt([]byte("x"), -1); ErrorCode(err) != codes.InvalidArgument {
+				t.Fatalf("expected an invalid argument error, got %v", err)
+			}
+		})
+	}
+}
+
+// testPutStreamClient is a blobspb.Blob_PutStreamClient which records the
+// chunks sent on it. Once it has received failAfter chunks, if set, Send fails
+// like with a stream aborted by the service. CloseAndRecv returns err.
+type testPutStreamClient struct {
+	blobspb.Blob_PutStreamClient
+	chunks    []string
+	failAfter int
+	err       error
+}
+
+func (s *testPutStreamClient) Send(chunk *blobspb.StreamChunk) error {
+	if s.failAfter > 0 && len(s.chunks) >= s.failAfter {
+		return io.EOF
+	}
+	s.chunks = append(s.chunks, string(chunk.Payload))
+	return nil
+}
+
+func (s *testPutStreamClient) CloseAndRecv() (*blobspb.StreamResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &blobspb.StreamResponse{}, nil
+}
+
+// testPutStreamBlobClient is a blobspb.BlobClient whose PutStream returns
+// stream.
+type testPutStreamBlobClient struct {
+	blobspb.BlobClient
+	stream *testPutStreamClient
+}
+
+func (c *testPutStreamBlobClient) PutStream(
+	ctx context.Context, opts ...grpc.CallOption,
+) (blobspb.Blob_PutStreamClient, error) {
+	return c.stream, nil
+}
+
+// testCopyProgressClient is a blobspb.Blob_CopyBlobProgressClient which
+// receives msgs, followed by err or io.EOF.
+type testCopyProgressClient struct {
+	blobspb.Blob_CopyBlobProgressClient
+	msgs []*blobspb.CopyProgress
+	err  error
+}
+
+func (s *testCopyProgressClient) Recv() (*blobspb.CopyProgress, error) {
+	if len(s.msgs) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+type testCopyProgressBlobClient struct {
+	blobspb.BlobClient
+	stream *testCopyProgressClient
+}
+
+func (c *testCopyProgressBlobClient) CopyBlobProgress(
+	context.Context, *blobspb.CopyRequest, ...grpc.CallOption,
+) (blobspb.Blob_CopyBlobProgressClient, error) {
+	return c.stream, nil
+}
+
+func TestCopyWithProgress(t *testing.T) {
+	ctx := context.Background()
+	run := func(stream *testCopyProgressClient) ([]int64, error) {
+		var copied []int64
+		err := CopyWithProgress(
+			ctx, &testCopyProgressBlobClient{stream: stream}, "source", "destination",
+			func(n, total int64) {
+				assert.Equal(t, int64(30), total)
+				copied = append(copied, n)
+			},
+		)
+		return copied, err
+	}
+
+	t.Run("completed", func(t *testing.T) {
+		copied, err := run(&testCopyProgressClient{msgs: []*blobspb.CopyProgress{
+			{BytesCopied: 10, TotalBytes: 30},
+			{BytesCopied: 20, TotalBytes: 30},
+			{BytesCopied: 30, TotalBytes: 30, Done: true},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []int64{10, 20, 30}, copied)
+	})
+	t.Run("failed", func(t *testing.T) {
+		_, err := run(&testCopyProgressClient{
+			msgs: []*blobspb.CopyProgress{{BytesCopied: 10, TotalBytes: 30}},
+			err:  status.Error(codes.NotFound, "no such file"),
+		})
+		if !IsNotFound(err) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	})
+	t.Run("incomplete", func(t *testing.T) {
+		_, err := run(&testCopyProgressClient{
+			msgs: []*blobspb.CopyProgress{{BytesCopied: 10, TotalBytes: 30}},
+		})
+		if !testutils.IsError(err, "stream ended before the copy completed") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestPutStreamWriter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("chunks", func(t *testing.T) {
+		stream := &testPutStreamClient{}
+		w, err := NewPutStreamWriter(ctx, &testPutStreamBlobClient{stream: stream}, "file.csv", PutStreamOptions{ChunkSize: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"ab", "cdefg", "h", "ij"} {
+			if _, err := io.WriteString(w, s); err != nil {
+				t.Fatal(err)
+			}
+		}
+		assert.Equal(t, []string{"abcd", "efgh"}, stream.chunks)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"abcd", "efgh", "ij"}, stream.chunks)
+		if _, err := io.WriteString(w, "k"); err == nil {
+			t.Fatal("expected an error writing to a closed writer")
+		}
+	})
+	t.Run("close-returns-service-error", func(t *testing.T) {
+		stream := &testPutStreamClient{err: errors.New("moving temporary file to final location")}
+		w, err := NewPutStreamWriter(ctx, &testPutStreamBlobClient{stream: stream}, "file.csv", PutStreamOptions{ChunkSize: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, "ab"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); !testutils.IsError(err, "moving temporary file") {
+			t.Fatalf("expected the service error, got %v", err)
+		}
+	})
+	t.Run("aborted-stream", func(t *testing.T) {
+		stream := &testPutStreamClient{failAfter: 1, err: errors.New("insufficient disk space")}
+		w, err := NewPutStreamWriter(ctx, &testPutStreamBlobClient{stream: stream}, "file.csv", PutStreamOptions{ChunkSize: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The error of the service is returned rather than the io.EOF of the
+		// stream, and again on Close.
+		if _, err := io.WriteString(w, "abcdefgh"); !testutils.IsError(err, "insufficient disk space") {
+			t.Fatalf("expected the service error, got %v", err)
+		}
+		if err := w.Close(); !testutils.IsError(err, "insufficient disk space") {
+			t.Fatalf("expected the service error, got %v", err)
+		}
+	})
+}
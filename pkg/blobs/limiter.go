@@ -0,0 +1,102 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+)
+
+// rateLimit is a token bucket rate limit on the bytes transferred by a
+// Service. Its limit can be changed at any time, including from and to
+// unlimited.
+type rateLimit struct {
+	limiter *quotapool.RateLimiter
+	// bytesPerSecond is the current limit, or zero if unlimited. It is
+	// accessed atomically.
+	bytesPerSecond int64
+}
+
+// newRateLimit returns a rateLimit admitting bytesPerSecond bytes per second,
+// or unlimited if bytesPerSecond is not positive.
+func newRateLimit(name string, bytesPerSecond int64) *rateLimit {
+	l := &rateLimit{limiter: quotapool.NewRateLimiter(name, 0, 0)}
+	l.setLimit(bytesPerSecond)
+	return l
+}
+
+// setLimit changes the limit to bytesPerSecond bytes per second, or to
+// unlimited if bytesPerSecond is not positive. Transfers already waiting for
+// bytes to be admitted are admitted according to the new limit, unless it is
+// unlimited, in which case they finish waiting according to the previous one.
+func (l *rateLimit) setLimit(bytesPerSecond int64) {
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	if bytesPerSecond > 0 {
+		l.limiter.UpdateLimit(quotapool.Limit(bytesPerSecond), bytesPerSecond)
+	}
+	atomic.StoreInt64(&l.bytesPerSecond, bytesPerSecond)
+}
+
+// waitN waits for n bytes to be admitted.
+func (l *rateLimit) waitN(ctx context.Context, n int64) error {
+	if atomic.LoadInt64(&l.bytesPerSecond) == 0 {
+		return nil
+	}
+	return l.limiter.WaitN(ctx, n)
+}
+
+// limitedReader is an io.Reader which waits on a rateLimit for the bytes it
+// reads.
+type limitedReader struct {
+	ctx   context.Context
+	r     io.Reader
+	limit *rateLimit
+}
+
+// newLimitedReader wraps r so that reads are rate limited by limit.
+func newLimitedReader(ctx context.Context, r io.Reader, limit *rateLimit) io.Reader {
+	return &limitedReader{ctx: ctx, r: r, limit: limit}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if waitErr := r.limit.waitN(r.ctx, int64(n)); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// limitedWriter is an io.Writer which waits on a rateLimit for the bytes it
+// writes.
+type limitedWriter struct {
+	ctx   context.Context
+	w     io.Writer
+	limit *rateLimit
+}
+
+// newLimitedWriter wraps w so that writes are rate limited by limit.
+func newLimitedWriter(ctx context.Context, w io.Writer, limit *rateLimit) io.Writer {
+	return &limitedWriter{ctx: ctx, w: w, limit: limit}
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.limit.waitN(w.ctx, int64(len(p))); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
@@ -0,0 +1,545 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/iterutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+)
+
+// hasMeta returns whether path contains any of the wildcards of a glob
+// pattern.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// skipListingError returns whether err, returned when looking up a path found
+// by a listing, means that the path should be left out of the listing rather
+// than fail it. This is the case for dangling symlinks and symlinks pointing
+// outside of the storage.
+func skipListingError(err error) bool {
+	return oserror.IsNotExist(err) || errors.Is(err, errOutsideExternalIODir)
+}
+
+// listOptions restricts a listing to a window of its sorted results.
+type listOptions struct {
+	// after, if set, restricts the listing to the paths which sort after it.
+	after string
+	// limit, if positive, bounds the number of paths returned.
+	limit int
+	// modifiedAfterNanos, if set, restricts the listing to the paths modified
+	// strictly after it, in nanoseconds since the Unix epoch.
+	modifiedAfterNanos int64
+	// exclude holds the elements of the glob patterns matching the paths to
+	// leave out of the listing, as returned by excludePatterns.
+	exclude [][]string
+}
+
+// excludePatterns returns the elements of glob patterns, which are relative
+// to the root of the storage, for listOptions.exclude. They are matched like
+// the patterns passed to glob.
+func excludePatterns(patterns []string) ([][]string, error) {
+	var elems [][]string
+	for _, pattern := range patterns {
+		p := rootPath(pattern)
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, errors.Mark(
+				errors.Wrapf(err, "invalid exclude pattern %q", pattern), errInvalidArgument,
+			)
+		}
+		elems = append(elems, splitPath(p))
+	}
+	return elems, nil
+}
+
+// excluded returns whether path matches one of the exclusion patterns of opts.
+func (opts listOptions) excluded(path string) bool {
+	if len(opts.exclude) == 0 {
+		return false
+	}
+	elems := splitPath(path)
+	for _, pattern := range opts.exclude {
+		if matchElems(pattern, elems) {
+			return true
+		}
+	}
+	return false
+}
+
+// keep returns whether path, described by fi, passes the filters of opts.
+func (opts listOptions) keep(path string, fi os.FileInfo) bool {
+	if opts.modifiedAfterNanos != 0 && fi.ModTime().UnixNano() <= opts.modifiedAfterNanos {
+		return false
+	}
+	return !opts.excluded(path)
+}
+
+// filter returns the paths which pass the filters of opts. Paths which cannot
+// be looked up are left out, like in a listing.
+func (opts listOptions) filter(storage Storage, paths []string) ([]string, error) {
+	if opts.modifiedAfterNanos == 0 && len(opts.exclude) == 0 {
+		return paths, nil
+	}
+	kept := paths[:0]
+	for _, path := range paths {
+		if opts.excluded(path) {
+			continue
+		}
+		if opts.modifiedAfterNanos == 0 {
+			kept = append(kept, path)
+			continue
+		}
+		fi, err := storage.FileInfo(path)
+		if err != nil {
+			if skipListingError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if opts.keep(path, fi) {
+			kept = append(kept, path)
+		}
+	}
+	return kept, nil
+}
+
+// collector returns a walkFiles callback which appends the paths which pass
+// the filters of opts to *paths, and stops the walk once the limit of opts is
+// reached.
+func (opts listOptions) collector(paths *[]string) func(path string, fi os.FileInfo) error {
+	return func(path string, fi os.FileInfo) error {
+		if !opts.keep(path, fi) {
+			return nil
+		}
+		*paths = append(*paths, path)
+		if opts.limit > 0 && len(*paths) >= opts.limit {
+			return iterutil.StopIteration()
+		}
+		return nil
+	}
+}
+
+// window returns the part of the sorted paths selected by opts.
+func (opts listOptions) window(paths []string) []string {
+	i := sort.SearchStrings(paths, opts.after)
+	if i < len(paths) && paths[i] == opts.after {
+		i++
+	}
+	paths = paths[i:]
+	if opts.limit > 0 && len(paths) > opts.limit {
+		paths = paths[:opts.limit]
+	}
+	return paths
+}
+
+// listFiles returns, in sorted order, the files and directories of storage
+// matching a glob pattern. If pattern has no wildcard, it instead returns
+// every file below it if it is a directory, and every file it is a prefix of
+// otherwise, just like a cloud storage listing API would.
+//
+// Only the window of results selected by opts is returned. When listing a
+// prefix, the files before the window are skipped without reading the
+// directories holding them and the walk stops at the end of the window, so
+// that paging through a large directory does not read all of it for each
+// page. Glob patterns only match the entries of the directories they name,
+// which are read in full.
+// TODO(dt): make the prefix listing the only case -- never pass a pattern and
+// always just walk the prefix like a cloud storage listing API.
+func listFiles(
+	ctx context.Context, storage Storage, pattern string, opts listOptions,
+) ([]string, error) {
+	if pattern == "" {
+		return nil, invalidArgumentf("pattern cannot be empty")
+	}
+	p := rootPath(pattern)
+	if hasMeta(pattern) {
+		matches, err := glob(ctx, storage, p)
+		if err != nil {
+			return nil, err
+		}
+		if matches, err = opts.filter(storage, matches); err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		return opts.window(matches), nil
+	}
+
+	var matches []string
+	fi, err := storage.FileInfo(p)
+	switch {
+	case err == nil && fi.IsDir():
+		err = walkFiles(storage, p, "" /* prefix */, opts.after, opts.collector(&matches))
+	case err == nil || oserror.IsNotExist(err):
+		err = walkFiles(storage, filepath.Dir(p), p, opts.after, opts.collector(&matches))
+	}
+	if err != nil && !iterutil.Done(err) {
+		if oserror.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return matches, nil
+}
+
+// listRecursive returns, in sorted order, the files of storage matching a glob
+// pattern along with all the files below the matching directories. Like
+// listFiles, it only walks the directories holding the window of results
+// selected by opts.
+func listRecursive(
+	ctx context.Context, storage Storage, pattern string, opts listOptions,
+) ([]string, error) {
+	if pattern == "" {
+		return nil, invalidArgumentf("pattern cannot be empty")
+	}
+	matches, err := glob(ctx, storage, rootPath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	// Sorting the matches like walkFiles sorts directory entries puts the
+	// files below them in lexical order, as long as no match is below another.
+	infos := make([]os.FileInfo, 0, len(matches))
+	keys := make([]string, 0, len(matches))
+	for _, match := range matches {
+		fi, err := storage.FileInfo(match)
+		if err != nil {
+			if skipListingError(err) {
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, fi)
+		keys = append(keys, walkKey(match, fi))
+	}
+	sort.Sort(entriesByKey{entries: infos, keys: keys})
+
+	var files []string
+	collect := opts.collector(&files)
+	var lastDir string
+	for i, fi := range infos {
+		key := keys[i]
+		// A ** pattern can match both a directory and paths below it, which
+		// are already listed along with the directory.
+		if lastDir != "" && strings.HasPrefix(key, lastDir) {
+			continue
+		}
+		if fi.IsDir() {
+			lastDir = key
+		}
+		match := strings.TrimSuffix(key, string(filepath.Separator))
+		switch {
+		case fi.IsDir() && (key > opts.after || strings.HasPrefix(opts.after, key)):
+			err = walkFiles(storage, match, "" /* prefix */, opts.after, collect)
+		case !fi.IsDir() && match > opts.after:
+			err = collect(match, fi)
+		}
+		if err != nil {
+			if iterutil.Done(err) {
+				break
+			}
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// walkKey returns the key by which walkFiles sorts the entry at path: the
+// path itself, followed by a separator for directories, so that e.g. "a.csv"
+// comes before the files below "a", like "a/b.csv".
+func walkKey(path string, fi os.FileInfo) string {
+	if fi.IsDir() {
+		return path + string(filepath.Separator)
+	}
+	return path
+}
+
+// walkFiles calls fn with the path and os.FileInfo of every file below dir
+// which starts with prefix and sorts after after, in lexical order. Subdirectories which cannot
+// hold such a file are not read. The walk stops without error if fn returns
+// iterutil.StopIteration().
+//
+// Symlinks are not followed: they are reported like files, with the
+// os.FileInfo of their target, unless they are dangling or point outside of
+// the storage, in which case they are skipped.
+func walkFiles(
+	storage Storage, dir, prefix, after string, fn func(path string, fi os.FileInfo) error,
+) error {
+	entries, err := storage.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	// Sort the entries by the paths below them, rather than by name, so that
+	// files are reported in lexical order.
+	keys := make([]string, len(entries))
+	for i, fi := range entries {
+		keys[i] = walkKey(fi.Name(), fi)
+	}
+	sort.Sort(entriesByKey{entries: entries, keys: keys})
+
+	for _, fi := range entries {
+		path := filepath.Join(dir, fi.Name())
+		if fi.IsDir() {
+			sub := path + string(filepath.Separator)
+			if !strings.HasPrefix(sub, prefix) && !strings.HasPrefix(prefix, sub) {
+				continue
+			}
+			// Skip the directory if all the paths below it sort before after.
+			if after > sub && !strings.HasPrefix(after, sub) {
+				continue
+			}
+			if err := walkFiles(storage, path, prefix, after, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasPrefix(path, prefix) || path <= after {
+			continue
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := storage.FileInfo(path)
+			if err != nil {
+				if skipListingError(err) {
+					continue
+				}
+				return err
+			}
+			fi = target
+		}
+		if err := fn(path, fi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkEntries calls fn with the path and os.FileInfo of every entry of dir,
+// and of every entry below it if recursive is set, in the order of
+// filepath.Walk. Only the entries of the directories being walked are held
+// in memory. The walk stops with the error of ctx once it is done, which is
+// checked before each directory is read and before each of its entries.
+//
+// Like walkFiles, it does not follow symlinks: they are reported with the
+// os.FileInfo of their target, unless they are dangling or point outside of
+// the storage, in which case they are skipped.
+func walkEntries(
+	ctx context.Context,
+	storage Storage,
+	dir string,
+	recursive bool,
+	fn func(path string, fi os.FileInfo) error,
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entries, err := storage.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, fi := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, fi.Name())
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := storage.FileInfo(path)
+			if err != nil {
+				if skipListingError(err) {
+					continue
+				}
+				return err
+			}
+			if err := fn(path, target); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(path, fi); err != nil {
+			return err
+		}
+		if recursive && fi.IsDir() {
+			if err := walkEntries(ctx, storage, path, recursive, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entriesByKey sorts directory entries by the matching keys.
+type entriesByKey struct {
+	entries []os.FileInfo
+	keys    []string
+}
+
+func (e entriesByKey) Len() int           { return len(e.entries) }
+func (e entriesByKey) Less(i, j int) bool { return e.keys[i] < e.keys[j] }
+func (e entriesByKey) Swap(i, j int) {
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+}
+
+// doublestar is the path element of a glob pattern which matches any number
+// of directories, including none.
+const doublestar = "**"
+
+// hasDoublestar returns whether the glob pattern path has a ** element.
+func hasDoublestar(path string) bool {
+	for _, elem := range splitPath(path) {
+		if elem == doublestar {
+			return true
+		}
+	}
+	return false
+}
+
+// glob returns the paths of storage matching pattern, like filepath.Glob does
+// for the local filesystem. Paths which cannot be looked up, e.g. because they
+// are in a directory which does not exist, are not matched.
+//
+// Unlike filepath.Glob, a ** element of pattern matches any number of
+// directories, including none, so that e.g. "/backups/**/*.sst" matches the
+// .sst files at any depth below /backups. Any other use of * keeps matching
+// within a single path element.
+//
+// Matching stops with the error of ctx once it is done, which is checked
+// before each directory is read.
+func glob(ctx context.Context, storage Storage, pattern string) ([]string, error) {
+	// Validate the pattern even if there is nothing to match it against.
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	if hasDoublestar(pattern) {
+		return globDoublestar(ctx, storage, pattern)
+	}
+	if !hasMeta(pattern) {
+		if _, err := storage.FileInfo(pattern); err != nil {
+			if skipListingError(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := filepath.Split(pattern)
+	dir = filepath.Clean(dir)
+	dirs := []string{dir}
+	if hasMeta(dir) {
+		var err error
+		if dirs, err = glob(ctx, storage, dir); err != nil {
+			return nil, err
+		}
+	}
+	var matches []string
+	for _, d := range dirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fi, err := storage.FileInfo(d)
+		if err != nil {
+			if skipListingError(err) {
+				continue
+			}
+			return nil, err
+		}
+		if !fi.IsDir() {
+			continue
+		}
+		entries, err := storage.ReadDir(d)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if ok, _ := filepath.Match(file, e.Name()); ok {
+				matches = append(matches, filepath.Join(d, e.Name()))
+			}
+		}
+	}
+	return matches, nil
+}
+
+// splitPath returns the elements of path.
+func splitPath(path string) []string {
+	return strings.Split(path, string(filepath.Separator))
+}
+
+// globDoublestar is glob for patterns with a ** element. It walks the whole
+// tree below the directory named by the elements of pattern before the first
+// wildcard, and matches every path in it against the rest of pattern. Since
+// pattern is a clean path from the root of the storage, that directory cannot
+// be above the root, and as the walk does not follow symlinks, it stays below
+// it. The walk stops with the error of ctx once it is done.
+func globDoublestar(ctx context.Context, storage Storage, pattern string) ([]string, error) {
+	elems := splitPath(pattern)
+	i := 0
+	for i < len(elems) && !hasMeta(elems[i]) {
+		i++
+	}
+	base := rootPath(filepath.Join(elems[:i]...))
+	fi, err := storage.FileInfo(base)
+	if err != nil {
+		if skipListingError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, nil
+	}
+
+	var matches []string
+	if matchElems(elems[i:], nil) {
+		matches = append(matches, base)
+	}
+	match := func(path string, _ os.FileInfo) error {
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		if matchElems(elems[i:], splitPath(rel)) {
+			matches = append(matches, path)
+		}
+		return nil
+	}
+	if err := walkEntries(ctx, storage, base, true /* recursive */, match); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// matchElems returns whether the elements of a path match the elements of a
+// glob pattern, a ** pattern element matching any number of path elements.
+func matchElems(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == doublestar {
+			for i := 0; i <= len(path); i++ {
+				if matchElems(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
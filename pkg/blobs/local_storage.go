@@ -20,7 +20,11 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
 	"github.com/cockroachdb/cockroach/pkg/util/fileutil"
+	"github.com/cockroachdb/cockroach/pkg/util/sysutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble/vfs"
 )
 
 // LocalStorage wraps all operations with the local file system
@@ -29,6 +33,12 @@ type LocalStorage struct {
 	externalIODir string
 }
 
+var _ Storage = &LocalStorage{}
+
+// ErrExternalIODisabled is returned by the operations of a LocalStorage, and by
+// the RPCs of a Service, when the node was started without an external IO dir.
+var ErrExternalIODisabled = errors.New("external I/O is disabled on this node")
+
 // NewLocalStorage creates a new LocalStorage object and returns
 // an error when we cannot take the absolute path of `externalIODir`.
 func NewLocalStorage(externalIODir string) (*LocalStorage, error) {
@@ -49,33 +59,131 @@ func NewLocalStorage(externalIODir string) (*LocalStorage, error) {
 
 // prependExternalIODir makes `path` relative to the configured external I/O directory.
 //
-// Note that we purposefully only rely on the simplified cleanup
-// performed by filepath.Join() - which is limited to stripping out
-// occurrences of "../" - because we intendedly want to allow
-// operators to "open up" their I/O directory via symlinks. Therefore,
-// a full check via filepath.Abs() would be inadequate.
+// The joined path is first checked lexically, and then with all symlinks
+// along it resolved, so that a symlink inside the external I/O directory
+// cannot be used to reach files outside of it. Operators who want to "open
+// up" their I/O directory via symlinks should point external-io-dir itself
+// at the desired location instead.
 func (l *LocalStorage) prependExternalIODir(path string) (string, error) {
 	if l == nil {
-		return "", errors.Errorf("local file access is disabled")
+		return "", ErrExternalIODisabled
 	}
 	localBase := filepath.Join(l.externalIODir, path)
-	return localBase, l.ensureContained(localBase, path)
+	if err := l.ensureContained(localBase, path); err != nil {
+		return "", err
+	}
+	resolved, err := evalSymlinksPrefix(localBase)
+	if err != nil {
+		return "", err
+	}
+	if !withinDir(resolved, l.resolvedExternalIODir()) {
+		return "", outsideExternalIODirError(path)
+	}
+	return localBase, nil
 }
 
 func (l *LocalStorage) ensureContained(realPath, inputPath string) error {
-	if !strings.HasPrefix(realPath, l.externalIODir) {
-		return errors.Errorf("local file access to paths outside of external-io-dir is not allowed: %s", inputPath)
+	if !withinDir(realPath, l.externalIODir) {
+		return outsideExternalIODirError(inputPath)
 	}
 	return nil
 }
 
+// withinDir returns whether path is dir or is located below it.
+func withinDir(path, dir string) bool {
+	// Check for a separator after the prefix so that a sibling directory which
+	// happens to share dir's name as a prefix is rejected.
+	return path == dir ||
+		strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// resolvedExternalIODir returns the external I/O directory with any symlinks
+// in it resolved.
+func (l *LocalStorage) resolvedExternalIODir() string {
+	root, err := filepath.EvalSymlinks(l.externalIODir)
+	if err != nil {
+		return l.externalIODir
+	}
+	return root
+}
+
+// maxSymlinkHops bounds the number of dangling symlinks evalSymlinksPrefix
+// follows before giving up.
+const maxSymlinkHops = 255
+
+// evalSymlinksPrefix is like filepath.EvalSymlinks, except that path does not
+// need to exist: the longest existing prefix of path is resolved and the
+// remaining components are appended to it. Dangling symlinks are followed to
+// their targets so that the result reflects where a file created at path
+// would end up.
+func evalSymlinksPrefix(path string) (string, error) {
+	existing := path
+	var rest []string
+	for hops := 0; ; {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+		if !oserror.IsNotExist(err) {
+			return "", err
+		}
+		if fi, err := os.Lstat(existing); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if hops++; hops > maxSymlinkHops {
+				return "", errors.Errorf("too many levels of symbolic links: %s", path)
+			}
+			target, err := os.Readlink(existing)
+			if err != nil {
+				return "", err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(existing), target)
+			}
+			existing = target
+			continue
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// WriteOptions controls how LocalStorage writes a file.
+type WriteOptions struct {
+	// Mode, if non-zero, holds the permission bits of the written file. The
+	// default mode of a written file is 0600.
+	Mode os.FileMode
+	// IfNotExists, if set, makes the write fail with ErrFileExists if the
+	// target file already exists, instead of replacing it. The check and the
+	// creation of the file are atomic.
+	IfNotExists bool
+	// Sync, if set, makes the write also sync the directory of the file once
+	// the file has been put in place, so that the file survives a crash. The
+	// content of the file is always synced before that.
+	Sync bool
+}
+
+// ErrDirNotEmpty is returned when deleting a directory which is not empty
+// without deleting its content.
+var ErrDirNotEmpty = errors.New("directory not empty")
+
+// ErrFileExists is returned when a conditional write finds that its target
+// already exists.
+var ErrFileExists = errors.New("file already exists")
+
 type localWriter struct {
 	f         *os.File
 	ctx       context.Context
 	tmp, dest string
+	opts      WriteOptions
 }
 
 func (l localWriter) Write(p []byte) (int, error) {
+	if err := l.ctx.Err(); err != nil {
+		return 0, err
+	}
 	return l.f.Write(p)
 }
 
@@ -86,21 +194,121 @@ func (l localWriter) Close() error {
 		return errors.CombineErrors(err, errors.Wrap(errors.CombineErrors(rmErr, closeErr), "cleaning up"))
 	}
 
+	// The mode is applied to the temporary file so that the file never shows
+	// up at its final location with the wrong permissions.
+	var chmodErr error
+	if l.opts.Mode != 0 {
+		chmodErr = errors.Wrap(l.f.Chmod(l.opts.Mode), "setting file mode")
+	}
 	syncErr := l.f.Sync()
 	closeErr := l.f.Close()
-	if err := errors.CombineErrors(closeErr, syncErr); err != nil {
-		return err
+	if err := errors.CombineErrors(chmodErr, errors.CombineErrors(closeErr, syncErr)); err != nil {
+		rmErr := os.Remove(l.tmp)
+		return errors.CombineErrors(err, errors.Wrap(rmErr, "cleaning up"))
 	}
 	// Finally put the file to its final location.
-	return errors.Wrapf(
-		fileutil.Move(l.tmp, l.dest),
-		"moving temporary file to final location %q",
-		l.dest,
-	)
+	if l.opts.IfNotExists {
+		if err := l.linkNoReplace(); err != nil {
+			return err
+		}
+	} else if err := fileutil.Move(l.tmp, l.dest); err != nil {
+		rmErr := os.Remove(l.tmp)
+		return errors.CombineErrors(
+			errors.Wrapf(err, "moving temporary file to final location %q", l.dest),
+			errors.Wrap(rmErr, "cleaning up"),
+		)
+	}
+	if l.opts.Sync {
+		return syncDir(filepath.Dir(l.dest))
+	}
+	return nil
+}
+
+// syncDir syncs a directory, so that the files which were added to it or
+// removed from it survive a crash.
+func syncDir(dir string) error {
+	d, err := vfs.Default.OpenDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "opening directory %q", dir)
+	}
+	syncErr := errors.Wrapf(d.Sync(), "syncing directory %q", dir)
+	return errors.CombineErrors(syncErr, d.Close())
+}
+
+// linkFile is used by linkNoReplace to put files in place. It is a variable
+// so that tests can simulate filesystems which do not support hard links.
+var linkFile = os.Link
+
+// linkNoReplace puts the temporary file at its final location, failing with
+// ErrFileExists if there already is a file there. Unlike a rename, creating a
+// hard link never replaces its target.
+func (l localWriter) linkNoReplace() error {
+	linkErr := linkFile(l.tmp, l.dest)
+	if isLinkUnsupportedError(linkErr) {
+		return l.createNoReplace()
+	}
+	rmErr := errors.Wrap(os.Remove(l.tmp), "cleaning up")
+	if linkErr != nil {
+		if oserror.IsExist(linkErr) {
+			linkErr = errors.Wrapf(ErrFileExists, "%q", l.dest)
+		} else {
+			linkErr = errors.Wrapf(linkErr, "linking temporary file to final location %q", l.dest)
+		}
+		return errors.CombineErrors(linkErr, rmErr)
+	}
+	return rmErr
+}
+
+// createNoReplace is the fallback of linkNoReplace for filesystems which do
+// not support hard links. The final location is claimed by exclusively
+// creating an empty file there, which the temporary file then replaces. The
+// check and the creation are still atomic, but unlike with a hard link,
+// readers can briefly observe the empty file.
+func (l localWriter) createNoReplace() error {
+	f, err := os.OpenFile(l.dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if oserror.IsExist(err) {
+			err = errors.Wrapf(ErrFileExists, "%q", l.dest)
+		} else {
+			err = errors.Wrapf(err, "creating final location %q", l.dest)
+		}
+		return errors.CombineErrors(err, errors.Wrap(os.Remove(l.tmp), "cleaning up"))
+	}
+	err = f.Close()
+	if err == nil {
+		err = fileutil.Move(l.tmp, l.dest)
+	}
+	if err != nil {
+		rmErr := errors.CombineErrors(os.Remove(l.tmp), os.Remove(l.dest))
+		return errors.CombineErrors(
+			errors.Wrapf(err, "moving temporary file to final location %q", l.dest),
+			errors.Wrap(rmErr, "cleaning up"),
+		)
+	}
+	return nil
+}
+
+func isLinkUnsupportedError(err error) bool {
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		return sysutil.IsLinkUnsupportedErrno(le.Err)
+	}
+	return false
 }
 
 // Writer prepends IO dir to filename and writes the content to that local file.
 func (l *LocalStorage) Writer(ctx context.Context, filename string) (io.WriteCloser, error) {
+	return l.WriterWithOptions(ctx, filename, WriteOptions{})
+}
+
+// WriterWithOptions is like Writer, but the file is written according to the
+// passed options.
+func (l *LocalStorage) WriterWithOptions(
+	ctx context.Context, filename string, opts WriteOptions,
+) (io.WriteCloser, error) {
+	if opts.Mode&^os.ModePerm != 0 {
+		return nil, invalidArgumentf("invalid file mode %#o", uint32(opts.Mode))
+	}
 	fullPath, err := l.prependExternalIODir(filename)
 	if err != nil {
 		return nil, err
@@ -109,6 +317,19 @@ func (l *LocalStorage) Writer(ctx context.Context, filename string) (io.WriteClo
 		return nil, err
 	}
 
+	// Fail early rather than after the content has been received. The
+	// existence of the target is checked again atomically on Close.
+	if opts.IfNotExists {
+		if _, err := os.Lstat(fullPath); err == nil {
+			return nil, errors.Wrapf(ErrFileExists, "%q", fullPath)
+		} else if !oserror.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if err := checkRegularFile(fullPath); err != nil {
+		return nil, err
+	}
+
 	targetDir := filepath.Dir(fullPath)
 	if err = os.MkdirAll(targetDir, 0755); err != nil {
 		return nil, errors.Wrapf(err, "creating target local directory %q", targetDir)
@@ -118,16 +339,49 @@ func (l *LocalStorage) Writer(ctx context.Context, filename string) (io.WriteClo
 	// This has two purposes:
 	// - it avoids relying on the system-wide temporary directory, which
 	//   may not be large enough to receive the file.
-	// - it avoids a cross-filesystem rename in the common case.
+	// - it avoids a cross-filesystem rename in the common case, so that
+	//   the file atomically appears at its final location once complete.
 	//   (There can still be cross-filesystem renames in very
 	//   exotic edge cases, hence the use fileutil.Move below.)
-	// See the explanatory comment for ioutil.TempFile to understand
-	// what the "*" in the suffix means.
-	tmpFile, err := ioutil.TempFile(targetDir, filepath.Base(fullPath)+"*.tmp")
+	// The temporary file is named .<name>.tmp-<rand>. See the explanatory
+	// comment for ioutil.TempFile to understand what the "*" in the pattern
+	// means.
+	tmpFile, err := ioutil.TempFile(targetDir, tempFilePattern(fullPath))
 	if err != nil {
 		return nil, errors.Wrap(err, "creating temporary file")
 	}
-	return localWriter{tmp: tmpFile.Name(), dest: fullPath, f: tmpFile, ctx: ctx}, nil
+	if err := l.ensureContained(tmpFile.Name(), filename); err != nil {
+		return nil, errors.CombineErrors(err, errors.CombineErrors(tmpFile.Close(), os.Remove(tmpFile.Name())))
+	}
+	return localWriter{tmp: tmpFile.Name(), dest: fullPath, f: tmpFile, ctx: ctx, opts: opts}, nil
+}
+
+// checkRegularFile returns an error if the local file at fullPath exists but
+// is not a regular file. Writing to a FIFO, a device or a socket could block
+// forever or have surprising effects, so only regular files are written to.
+// Symlinks are followed, like the writes do; prependExternalIODir already
+// checked that they do not point outside of the external I/O directory.
+func checkRegularFile(fullPath string) error {
+	fi, err := os.Stat(fullPath)
+	if err != nil {
+		if oserror.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if fi.IsDir() {
+		return notAFileError(fi.Name())
+	}
+	if !fi.Mode().IsRegular() {
+		return notARegularFileError(fi.Name(), fi.Mode())
+	}
+	return nil
+}
+
+// tempFilePattern returns the ioutil.TempFile pattern of the temporary file
+// used to write the file at path.
+func tempFilePattern(path string) string {
+	return "." + filepath.Base(path) + ".tmp-*"
 }
 
 // ReadFile prepends IO dir to filename and reads the content of that local file.
@@ -152,7 +406,11 @@ func (l *LocalStorage) ReadFile(
 		return nil, 0, err
 	}
 	if fi.IsDir() {
-		return nil, 0, errors.Errorf("expected a file but %q is a directory", fi.Name())
+		return nil, 0, notAFileError(fi.Name())
+	}
+	if offset > fi.Size() {
+		return nil, 0, errors.Errorf(
+			"offset %d is past the end of %q (size %d)", offset, fi.Name(), fi.Size())
 	}
 	if offset != 0 {
 		if ret, err := f.Seek(offset, 0); err != nil {
@@ -164,64 +422,180 @@ func (l *LocalStorage) ReadFile(
 	return f, fi.Size(), nil
 }
 
-// List prepends IO dir to pattern and glob matches all local files against that pattern.
-func (l *LocalStorage) List(pattern string) ([]string, error) {
-	if pattern == "" {
-		return nil, errors.New("pattern cannot be empty")
+// Append prepends IO dir to filename and appends payload to the end of that
+// local file, creating it if it does not exist. It returns the size of the
+// file after the append.
+func (l *LocalStorage) Append(filename string, payload []byte) (int64, error) {
+	fullPath, err := l.prependExternalIODir(filename)
+	if err != nil {
+		return 0, errors.Wrap(err, "appending to file")
 	}
-	fullPath, err := l.prependExternalIODir(pattern)
+	if err := checkRegularFile(fullPath); err != nil {
+		return 0, err
+	}
+	targetDir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return 0, errors.Wrapf(err, "creating target local directory %q", targetDir)
+	}
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if _, err := f.Write(payload); err != nil {
+		return 0, errors.CombineErrors(err, f.Close())
 	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, errors.CombineErrors(err, f.Close())
+	}
+	return fi.Size(), f.Close()
+}
 
-	// If we are not given a glob pattern, we should recursively list this prefix
-	// just like a cloud storage provider, using filepath.Walk, because absent a
-	// wildcard in a pattern filepath.Glob matches at most one path.
-	// TODO(dt): make this the only case -- never pass a pattern and always just
-	// walk the prefix like a cloud storage listing API.
-	if !strings.ContainsAny(pattern, "*?[") {
-		var matches []string
-		walkRoot := fullPath
-		listingParent := false
-		if f, err := os.Stat(fullPath); err != nil || !f.IsDir() {
-			listingParent = true
-			walkRoot = filepath.Dir(fullPath)
-			if err := l.ensureContained(walkRoot, pattern); err != nil {
-				return nil, err
-			}
-		}
+// WriteAt prepends IO dir to filename and writes payload at offset in that
+// local file, creating it if needed, and returns the size of the file after
+// the write. Like pwrite, a write past the end of the file fills the gap with
+// zeroes.
+func (l *LocalStorage) WriteAt(filename string, offset int64, payload []byte) (int64, error) {
+	if offset < 0 {
+		return 0, invalidArgumentf("cannot write to %q at negative offset %d", filename, offset)
+	}
+	fullPath, err := l.prependExternalIODir(filename)
+	if err != nil {
+		return 0, errors.Wrap(err, "writing to file")
+	}
+	if err := checkRegularFile(fullPath); err != nil {
+		return 0, err
+	}
+	targetDir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return 0, errors.Wrapf(err, "creating target local directory %q", targetDir)
+	}
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.WriteAt(payload, offset); err != nil {
+		return 0, errors.CombineErrors(err, f.Close())
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, errors.CombineErrors(err, f.Close())
+	}
+	return fi.Size(), f.Close()
+}
 
-		if err := filepath.Walk(walkRoot, func(p string, f os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if f.IsDir() {
-				return nil
-			}
-			if listingParent && !strings.HasPrefix(p, fullPath) {
-				return nil
-			}
-			matches = append(matches, strings.TrimPrefix(p, l.externalIODir))
+// Mkdir prepends IO dir to path and creates that local directory, along with
+// any missing parents. It is not an error for the directory to already exist.
+func (l *LocalStorage) Mkdir(path string) error {
+	fullPath, err := l.prependExternalIODir(path)
+	if err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+	if fi, err := os.Stat(fullPath); err == nil && !fi.IsDir() {
+		return errors.Errorf("cannot create directory %q: a file with that name already exists", path)
+	}
+	return os.MkdirAll(fullPath, 0755)
+}
+
+// Touch prepends IO dir to filename and creates that local file, along with
+// any missing parents, if it does not exist. If it does and updateModTime is
+// set, its modification time is set to the current time.
+func (l *LocalStorage) Touch(filename string, updateModTime bool) error {
+	fullPath, err := l.prependExternalIODir(filename)
+	if err != nil {
+		return errors.Wrap(err, "touching file")
+	}
+	fi, err := os.Stat(fullPath)
+	if err == nil {
+		if fi.IsDir() {
+			return notAFileError(fi.Name())
+		}
+		if !updateModTime {
 			return nil
-		}); err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				return nil, nil
-			}
-			return nil, err
 		}
-		return matches, nil
+		now := timeutil.Now()
+		return os.Chtimes(fullPath, now, now)
 	}
+	if !oserror.IsNotExist(err) {
+		return err
+	}
+	targetDir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return errors.Wrapf(err, "creating target local directory %q", targetDir)
+	}
+	// The file may have been created concurrently: opening it without O_TRUNC
+	// leaves it as it is.
+	f, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Truncate prepends IO dir to filename and changes the size of that local
+// file. A file which is smaller than size is extended with zeroes.
+func (l *LocalStorage) Truncate(filename string, size int64) error {
+	if size < 0 {
+		return invalidArgumentf("cannot truncate %q to negative size %d", filename, size)
+	}
+	fullPath, err := l.prependExternalIODir(filename)
+	if err != nil {
+		return errors.Wrap(err, "truncating file")
+	}
+	if err := checkRegularFile(fullPath); err != nil {
+		return err
+	}
+	return os.Truncate(fullPath, size)
+}
+
+// renameFile is used by Rename to rename files. It is a variable so that
+// tests can simulate a rename across filesystems.
+var renameFile = os.Rename
 
-	matches, err := filepath.Glob(fullPath)
+// Rename prepends IO dir to source and destination and renames the former
+// local file or directory to the latter. Renaming across filesystems fails
+// with an error marked as ErrCrossDevice.
+func (l *LocalStorage) Rename(source, destination string) error {
+	srcPath, err := l.prependExternalIODir(source)
 	if err != nil {
+		return errors.Wrap(err, "renaming file")
+	}
+	destPath, err := l.prependExternalIODir(destination)
+	if err != nil {
+		return errors.Wrap(err, "renaming file")
+	}
+	err = renameFile(srcPath, destPath)
+	if isCrossDeviceLinkError(err) {
+		return errors.Mark(err, ErrCrossDevice)
+	}
+	return err
+}
+
+func isCrossDeviceLinkError(err error) bool {
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		return sysutil.IsCrossDeviceLinkErrno(le.Err)
+	}
+	return false
+}
+
+// List prepends IO dir to pattern and glob matches all local files against
+// that pattern. See listFiles.
+func (l *LocalStorage) List(pattern string) ([]string, error) {
+	if _, err := l.prependExternalIODir(pattern); err != nil {
 		return nil, err
 	}
+	return listFiles(context.Background(), l, pattern, listOptions{})
+}
 
-	var fileList []string
-	for _, file := range matches {
-		fileList = append(fileList, strings.TrimPrefix(file, l.externalIODir))
+// ReadDir prepends IO dir to dir and returns the entries of that local
+// directory, sorted by name.
+func (l *LocalStorage) ReadDir(dir string) ([]os.FileInfo, error) {
+	fullPath, err := l.prependExternalIODir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading directory")
 	}
-	return fileList, nil
+	return ioutil.ReadDir(fullPath)
 }
 
 // Delete prepends IO dir to filename and deletes that local file.
@@ -230,21 +604,45 @@ func (l *LocalStorage) Delete(filename string) error {
 	if err != nil {
 		return errors.Wrap(err, "deleting file")
 	}
-	return os.Remove(fullPath)
+	err = os.Remove(fullPath)
+	if isDirNotEmptyError(err) {
+		return errors.Wrapf(ErrDirNotEmpty, "deleting %q", filename)
+	}
+	return err
 }
 
-// Stat prepends IO dir to filename and gets the Stat() of that local file.
-func (l *LocalStorage) Stat(filename string) (*blobspb.BlobStat, error) {
+func isDirNotEmptyError(err error) bool {
+	var pe *os.PathError
+	if errors.As(err, &pe) {
+		return sysutil.IsDirNotEmptyErrno(pe.Err)
+	}
+	return false
+}
+
+// FileInfo prepends IO dir to filename and gets the os.FileInfo of that
+// local file or directory.
+func (l *LocalStorage) FileInfo(filename string) (os.FileInfo, error) {
 	fullPath, err := l.prependExternalIODir(filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting stat of file")
 	}
-	fi, err := os.Stat(fullPath)
-	if err != nil {
-		return nil, err
+	return os.Stat(fullPath)
+}
+
+// DiskUsage implements the Storage interface. All the files are assumed to be
+// on the filesystem holding the external IO dir, so path is only validated.
+func (l *LocalStorage) DiskUsage(path string) (vfs.DiskUsage, error) {
+	if _, err := l.prependExternalIODir(path); err != nil {
+		return vfs.DiskUsage{}, err
 	}
-	if fi.IsDir() {
-		return nil, errors.Errorf("expected a file but %q is a directory", fi.Name())
+	root, err := l.prependExternalIODir("")
+	if err != nil {
+		return vfs.DiskUsage{}, err
 	}
-	return &blobspb.BlobStat{Filesize: fi.Size()}, nil
+	return vfs.Default.GetDiskUsage(root)
+}
+
+// Stat prepends IO dir to filename and gets the Stat() of that local file.
+func (l *LocalStorage) Stat(filename string) (*blobspb.BlobStat, error) {
+	return statBlob(l, filename, false /* allowDir */)
 }
@@ -11,9 +11,17 @@
 package blobs
 
 import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
 	"path/filepath"
+	"regexp"
+	"syscall"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,3 +37,188 @@ func TestDirectoryNormalization(t *testing.T) {
 
 	assert.Equal(t, expected, l.externalIODir)
 }
+
+func TestWriterTempFile(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	l, err := NewLocalStorage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := l.Writer(context.Background(), "dir/content.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Until the writer is closed, the content only lives in a hidden temporary
+	// file next to the target.
+	entries, err := ioutil.ReadDir(filepath.Join(tmpDir, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected a single temporary file, found %d files", len(entries))
+	}
+	assert.Regexp(t, regexp.MustCompile(`^\.content\.txt\.tmp-[0-9]+$`), entries[0].Name())
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = ioutil.ReadDir(filepath.Join(tmpDir, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "content.txt" {
+		t.Fatalf("expected only the written file to be left, found %v", entries)
+	}
+}
+
+func TestWriterIfNotExistsRace(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	l, err := NewLocalStorage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	opts := WriteOptions{IfNotExists: true}
+
+	testRace := func(t *testing.T, filename string) {
+		// Both writers pass the early existence check, but only the first one
+		// to be closed may create the file.
+		w1, err := l.WriterWithOptions(ctx, filename, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w2, err := l.WriterWithOptions(ctx, filename, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w1.Write([]byte("first")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w2.Write([]byte("second")); err != nil {
+			t.Fatal(err)
+		}
+		if err := w1.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w2.Close(); !errors.Is(err, ErrFileExists) {
+			t.Fatalf("expected ErrFileExists, got %v", err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "first", string(content))
+		// No temporary file may be left behind.
+		entries, err := ioutil.ReadDir(filepath.Join(tmpDir, filepath.Dir(filename)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("expected only the written file to be left, found %d files", len(entries))
+		}
+	}
+
+	t.Run("hard-links", func(t *testing.T) {
+		testRace(t, "link/manifest")
+	})
+	t.Run("no-hard-links", func(t *testing.T) {
+		defer func(f func(string, string) error) { linkFile = f }(linkFile)
+		linkFile = func(oldname, newname string) error {
+			return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+		}
+		testRace(t, "nolink/manifest")
+	})
+}
+
+func TestLocalStorageSpecialFiles(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	l, err := NewLocalStorage(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A socket stands for any special file: writing to a FIFO, for instance,
+	// would block until it has a reader.
+	ln, err := net.Listen("unix", filepath.Join(tmpDir, "socket"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if err := os.Symlink("socket", filepath.Join(tmpDir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(filename string) error {
+		w, err := l.WriterWithOptions(ctx, filename, WriteOptions{})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte("content")); err != nil {
+			return errors.CombineErrors(err, w.Close())
+		}
+		return w.Close()
+	}
+	for _, filename := range []string{"socket", "link"} {
+		t.Run(filename, func(t *testing.T) {
+			for _, tc := range []struct {
+				name string
+				op   func() error
+			}{
+				{"write", func() error { return write(filename) }},
+				{"append", func() error {
+					_, err := l.Append(filename, []byte("content"))
+					return err
+				}},
+				{"write-at", func() error {
+					_, err := l.WriteAt(filename, 0, []byte("content"))
+					return err
+				}},
+				{"truncate", func() error { return l.Truncate(filename, 0) }},
+			} {
+				err := tc.op()
+				if !errors.Is(err, errNotAFile) || !testutils.IsError(err, "not a regular file") {
+					t.Fatalf("%s: expected a not a regular file error, got %v", tc.name, err)
+				}
+			}
+			fi, err := os.Stat(filepath.Join(tmpDir, filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode()&os.ModeSocket == 0 {
+				t.Fatalf("expected %s to still be a socket, got mode %s", filename, fi.Mode())
+			}
+		})
+	}
+
+	t.Run("regular-file", func(t *testing.T) {
+		// Creating a file and then overwriting it keeps working.
+		if err := write("dir/content.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.Append("dir/content.txt", []byte("!")); err != nil {
+			t.Fatal(err)
+		}
+		if err := write("dir/content.txt"); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Truncate("dir/content.txt", 4); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, "dir/content.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "cont", string(content))
+	})
+}
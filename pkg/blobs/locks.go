@@ -0,0 +1,93 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// lockDir is the directory, relative to the root of a Storage, which holds
+// the files of the locks acquired with AcquireLock. It is reserved for them.
+const lockDir = ".blob-locks"
+
+// ErrLockHeld marks the errors returned when acquiring a lock which is held,
+// or when releasing a lock with a token which does not hold it.
+var ErrLockHeld = errors.New("lock is held")
+
+// lockFile returns the path of the file of the lock called name.
+func lockFile(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", invalidArgumentf("invalid lock name %q", name)
+	}
+	return filepath.Join(lockDir, name), nil
+}
+
+// lockHolder describes the holder of a lock. It is stored in the file of the
+// lock.
+type lockHolder struct {
+	token      string
+	expiration time.Time
+}
+
+func (h lockHolder) encode() []byte {
+	return []byte(fmt.Sprintf("%s %d", h.token, h.expiration.UnixNano()))
+}
+
+func decodeLockHolder(b []byte) (lockHolder, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) != 2 {
+		return lockHolder{}, errors.Errorf("malformed lock file %q", b)
+	}
+	nanos, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return lockHolder{}, errors.Wrapf(err, "malformed lock file %q", b)
+	}
+	return lockHolder{token: fields[0], expiration: timeutil.Unix(0, nanos)}, nil
+}
+
+// readLockHolder returns the holder of the lock stored in path.
+func readLockHolder(storage Storage, path string) (lockHolder, error) {
+	content, _, err := storage.ReadFile(path, 0)
+	if err != nil {
+		return lockHolder{}, err
+	}
+	defer content.Close()
+	b, err := ioutil.ReadAll(content)
+	if err != nil {
+		return lockHolder{}, err
+	}
+	return decodeLockHolder(b)
+}
+
+// writeLockHolder stores h in path, replacing the holder it may already hold.
+// The file is synced, so that a lock is not lost in a crash.
+func writeLockHolder(ctx context.Context, storage Storage, path string, h lockHolder) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := storage.WriterWithOptions(ctx, path, WriteOptions{Sync: true})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(h.encode()); err != nil {
+		cancel()
+		return errors.CombineErrors(err, w.Close())
+	}
+	return w.Close()
+}
@@ -0,0 +1,500 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// memStorage is a Storage which keeps its files in memory. It lets tests
+// exercise a Service without touching the disk.
+type memStorage struct {
+	mu struct {
+		syncutil.Mutex
+		files map[string]*memFile
+		// dirs holds the modification time of every directory but the root.
+		dirs map[string]time.Time
+		// capacity is the size of the disk reported by DiskUsage.
+		capacity uint64
+	}
+}
+
+type memFile struct {
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+var _ Storage = &memStorage{}
+
+// newMemStorage returns an empty memStorage.
+func newMemStorage() *memStorage {
+	s := &memStorage{}
+	s.mu.files = make(map[string]*memFile)
+	s.mu.dirs = make(map[string]time.Time)
+	s.mu.capacity = math.MaxInt64
+	return s
+}
+
+// setCapacity sets the size of the disk reported by DiskUsage.
+func (s *memStorage) setCapacity(capacity uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.capacity = capacity
+}
+
+// writeStorageFile writes content to filename in storage.
+func writeStorageFile(t testing.TB, storage Storage, filename string, content []byte) {
+	w, err := storage.WriterWithOptions(context.Background(), filename, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(errors.CombineErrors(err, w.Close()))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readStorageFile returns the content of filename in storage.
+func readStorageFile(t testing.TB, storage Storage, filename string) []byte {
+	r, _, err := storage.ReadFile(filename, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// memRoot is the path of the root of a memStorage.
+const memRoot = "."
+
+// memPath returns the key under which the file at path is stored.
+func memPath(path string) string {
+	return filepath.Join(memRoot, path)
+}
+
+// memChild returns whether path is located below dir.
+func memChild(path, dir string) bool {
+	return dir == memRoot || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func memNotExist(op, path string) error {
+	return &os.PathError{Op: op, Path: path, Err: syscall.ENOENT}
+}
+
+func (s *memStorage) isDirLocked(p string) bool {
+	if p == memRoot {
+		return true
+	}
+	_, ok := s.mu.dirs[p]
+	return ok
+}
+
+func (s *memStorage) mkdirAllLocked(p string) error {
+	now := timeutil.Now()
+	for d := p; d != memRoot; d = filepath.Dir(d) {
+		if _, ok := s.mu.files[d]; ok {
+			return &os.PathError{Op: "mkdir", Path: d, Err: syscall.ENOTDIR}
+		}
+		if _, ok := s.mu.dirs[d]; !ok {
+			s.mu.dirs[d] = now
+		}
+	}
+	return nil
+}
+
+// ReadFile implements the Storage interface.
+func (s *memStorage) ReadFile(filename string, offset int64) (io.ReadCloser, int64, error) {
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDirLocked(p) {
+		return nil, 0, notAFileError(filepath.Base(p))
+	}
+	f, ok := s.mu.files[p]
+	if !ok {
+		return nil, 0, memNotExist("open", filename)
+	}
+	size := int64(len(f.data))
+	if offset > size {
+		return nil, 0, errors.Errorf(
+			"offset %d is past the end of %q (size %d)", offset, filepath.Base(p), size)
+	}
+	// File contents are never modified in place, so the reader can share them.
+	return ioutil.NopCloser(bytes.NewReader(f.data[offset:])), size, nil
+}
+
+// WriterWithOptions implements the Storage interface.
+func (s *memStorage) WriterWithOptions(
+	ctx context.Context, filename string, opts WriteOptions,
+) (io.WriteCloser, error) {
+	if opts.Mode&^os.ModePerm != 0 {
+		return nil, errors.Errorf("invalid file mode %#o", uint32(opts.Mode))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p := memPath(filename)
+	if opts.IfNotExists {
+		s.mu.Lock()
+		_, exists := s.mu.files[p]
+		s.mu.Unlock()
+		if exists {
+			return nil, errors.Wrapf(ErrFileExists, "%q", filename)
+		}
+	}
+	return &memWriter{s: s, ctx: ctx, path: p, opts: opts}, nil
+}
+
+type memWriter struct {
+	s    *memStorage
+	ctx  context.Context
+	path string
+	opts WriteOptions
+	buf  bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.buf.Write(p)
+}
+
+func (w *memWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	mode := w.opts.Mode
+	if mode == 0 {
+		mode = 0600
+	}
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	if w.s.isDirLocked(w.path) {
+		return notAFileError(filepath.Base(w.path))
+	}
+	if _, ok := w.s.mu.files[w.path]; ok && w.opts.IfNotExists {
+		return errors.Wrapf(ErrFileExists, "%q", w.path)
+	}
+	if err := w.s.mkdirAllLocked(filepath.Dir(w.path)); err != nil {
+		return err
+	}
+	w.s.mu.files[w.path] = &memFile{data: w.buf.Bytes(), mode: mode, modTime: timeutil.Now()}
+	return nil
+}
+
+// Append implements the Storage interface.
+func (s *memStorage) Append(filename string, payload []byte) (int64, error) {
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDirLocked(p) {
+		return 0, notAFileError(filepath.Base(p))
+	}
+	if err := s.mkdirAllLocked(filepath.Dir(p)); err != nil {
+		return 0, err
+	}
+	f, ok := s.mu.files[p]
+	if !ok {
+		f = &memFile{mode: 0644}
+		s.mu.files[p] = f
+	}
+	// Appending never overwrites the bytes visible to existing readers.
+	f.data = append(f.data, payload...)
+	f.modTime = timeutil.Now()
+	return int64(len(f.data)), nil
+}
+
+// WriteAt implements the Storage interface.
+func (s *memStorage) WriteAt(filename string, offset int64, payload []byte) (int64, error) {
+	if offset < 0 {
+		return 0, errors.Errorf("cannot write to %q at negative offset %d", filename, offset)
+	}
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDirLocked(p) {
+		return 0, notAFileError(filepath.Base(p))
+	}
+	if err := s.mkdirAllLocked(filepath.Dir(p)); err != nil {
+		return 0, err
+	}
+	f, ok := s.mu.files[p]
+	if !ok {
+		f = &memFile{mode: 0644}
+		s.mu.files[p] = f
+	}
+	// The bytes visible to existing readers are copied rather than
+	// overwritten.
+	size := int64(len(f.data))
+	if end := offset + int64(len(payload)); end > size {
+		size = end
+	}
+	data := make([]byte, size)
+	copy(data, f.data)
+	copy(data[offset:], payload)
+	f.data = data
+	f.modTime = timeutil.Now()
+	return size, nil
+}
+
+// Rename implements the Storage interface.
+func (s *memStorage) Rename(source, destination string) error {
+	src, dst := memPath(source), memPath(destination)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isDirLocked(filepath.Dir(dst)) {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOENT}
+	}
+	if src == dst {
+		return nil
+	}
+	if f, ok := s.mu.files[src]; ok {
+		if s.isDirLocked(dst) {
+			return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EISDIR}
+		}
+		delete(s.mu.files, src)
+		s.mu.files[dst] = f
+		return nil
+	}
+	if !s.isDirLocked(src) {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOENT}
+	}
+	if src == memRoot || memChild(dst, src) {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EINVAL}
+	}
+	if _, ok := s.mu.files[dst]; ok {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOTDIR}
+	}
+	if s.isDirLocked(dst) {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EEXIST}
+	}
+	rename := func(p string) string { return dst + strings.TrimPrefix(p, src) }
+	for p, f := range s.mu.files {
+		if memChild(p, src) {
+			delete(s.mu.files, p)
+			s.mu.files[rename(p)] = f
+		}
+	}
+	for p, modTime := range s.mu.dirs {
+		if p == src || memChild(p, src) {
+			delete(s.mu.dirs, p)
+			s.mu.dirs[rename(p)] = modTime
+		}
+	}
+	return nil
+}
+
+// Truncate implements the Storage interface.
+func (s *memStorage) Truncate(filename string, size int64) error {
+	if size < 0 {
+		return errors.Errorf("cannot truncate %q to negative size %d", filename, size)
+	}
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDirLocked(p) {
+		return &os.PathError{Op: "truncate", Path: filename, Err: syscall.EISDIR}
+	}
+	f, ok := s.mu.files[p]
+	if !ok {
+		return memNotExist("truncate", filename)
+	}
+	data := make([]byte, size)
+	copy(data, f.data)
+	f.data = data
+	f.modTime = timeutil.Now()
+	return nil
+}
+
+// Mkdir implements the Storage interface.
+func (s *memStorage) Mkdir(path string) error {
+	p := memPath(path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mu.files[p]; ok {
+		return errors.Errorf("cannot create directory %q: a file with that name already exists", path)
+	}
+	return s.mkdirAllLocked(p)
+}
+
+// Touch implements the Storage interface.
+func (s *memStorage) Touch(filename string, updateModTime bool) error {
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isDirLocked(p) {
+		return notAFileError(filepath.Base(p))
+	}
+	if f, ok := s.mu.files[p]; ok {
+		if updateModTime {
+			f.modTime = timeutil.Now()
+		}
+		return nil
+	}
+	if err := s.mkdirAllLocked(filepath.Dir(p)); err != nil {
+		return err
+	}
+	s.mu.files[p] = &memFile{mode: 0644, modTime: timeutil.Now()}
+	return nil
+}
+
+// fileInfoLocked returns the memFileInfo of the file or directory at p, or
+// false if there is none.
+func (s *memStorage) fileInfoLocked(p string) (memFileInfo, bool) {
+	if f, ok := s.mu.files[p]; ok {
+		return memFileInfo{
+			name: filepath.Base(p), size: int64(len(f.data)), mode: f.mode, modTime: f.modTime,
+		}, true
+	}
+	if p == memRoot {
+		return memFileInfo{name: memRoot, mode: os.ModeDir | 0755}, true
+	}
+	if modTime, ok := s.mu.dirs[p]; ok {
+		return memFileInfo{name: filepath.Base(p), mode: os.ModeDir | 0755, modTime: modTime}, true
+	}
+	return memFileInfo{}, false
+}
+
+// ReadDir implements the Storage interface.
+func (s *memStorage) ReadDir(dir string) ([]os.FileInfo, error) {
+	p := memPath(dir)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mu.files[p]; ok {
+		return nil, &os.PathError{Op: "readdirent", Path: dir, Err: syscall.ENOTDIR}
+	}
+	if !s.isDirLocked(p) {
+		return nil, memNotExist("open", dir)
+	}
+	var entries []os.FileInfo
+	add := func(child string) {
+		if filepath.Dir(child) == p && child != memRoot {
+			fi, _ := s.fileInfoLocked(child)
+			entries = append(entries, fi)
+		}
+	}
+	for child := range s.mu.files {
+		add(child)
+	}
+	for child := range s.mu.dirs {
+		add(child)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Delete implements the Storage interface.
+func (s *memStorage) Delete(filename string) error {
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mu.files[p]; ok {
+		delete(s.mu.files, p)
+		return nil
+	}
+	if !s.isDirLocked(p) {
+		return memNotExist("remove", filename)
+	}
+	for f := range s.mu.files {
+		if memChild(f, p) {
+			return errors.Wrapf(ErrDirNotEmpty, "deleting %q", filename)
+		}
+	}
+	for d := range s.mu.dirs {
+		if memChild(d, p) {
+			return errors.Wrapf(ErrDirNotEmpty, "deleting %q", filename)
+		}
+	}
+	if p == memRoot {
+		return &os.PathError{Op: "remove", Path: filename, Err: syscall.EBUSY}
+	}
+	delete(s.mu.dirs, p)
+	return nil
+}
+
+// FileInfo implements the Storage interface.
+func (s *memStorage) FileInfo(filename string) (os.FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fi, ok := s.fileInfoLocked(memPath(filename)); ok {
+		return fi, nil
+	}
+	return nil, memNotExist("stat", filename)
+}
+
+// setModTime sets the modification time of a file or directory.
+func (s *memStorage) setModTime(filename string, modTime time.Time) {
+	p := memPath(filename)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.mu.files[p]; ok {
+		f.modTime = modTime
+	} else if _, ok := s.mu.dirs[p]; ok {
+		s.mu.dirs[p] = modTime
+	}
+}
+
+// DiskUsage implements the Storage interface. The disk holds nothing but the
+// files of the storage.
+func (s *memStorage) DiskUsage(string) (vfs.DiskUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var used uint64
+	for _, f := range s.mu.files {
+		used += uint64(len(f.data))
+	}
+	du := vfs.DiskUsage{TotalBytes: s.mu.capacity, UsedBytes: used}
+	if used < s.mu.capacity {
+		du.AvailBytes = s.mu.capacity - used
+	}
+	return du, nil
+}
+
+// memFileInfo implements os.FileInfo for the files of a memStorage.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+var _ os.FileInfo = memFileInfo{}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi memFileInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,422 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+)
+
+// Metrics contains pointers to the metrics for monitoring the blob service.
+type Metrics struct {
+	BytesRead    *metric.Counter
+	BytesWritten *metric.Counter
+
+	GetCount         *metric.Counter
+	PutCount         *metric.Counter
+	ListCount        *metric.Counter
+	DeleteCount      *metric.Counter
+	StatCount        *metric.Counter
+	AppendCount      *metric.Counter
+	CopyCount        *metric.Counter
+	MoveCount        *metric.Counter
+	MkdirCount       *metric.Counter
+	TruncateCount    *metric.Counter
+	ChecksumCount    *metric.Counter
+	ExistsCount      *metric.Counter
+	BatchGetCount    *metric.Counter
+	BatchDeleteCount *metric.Counter
+	DiskUsageCount   *metric.Counter
+	WalkCount        *metric.Counter
+	LockCount        *metric.Counter
+	UnlockCount      *metric.Counter
+	WriteAtCount     *metric.Counter
+	TouchCount       *metric.Counter
+	ReadCount        *metric.Counter
+	WriteCount       *metric.Counter
+
+	GetLatency         *metric.Histogram
+	PutLatency         *metric.Histogram
+	ListLatency        *metric.Histogram
+	DeleteLatency      *metric.Histogram
+	StatLatency        *metric.Histogram
+	AppendLatency      *metric.Histogram
+	CopyLatency        *metric.Histogram
+	MoveLatency        *metric.Histogram
+	MkdirLatency       *metric.Histogram
+	TruncateLatency    *metric.Histogram
+	ChecksumLatency    *metric.Histogram
+	ExistsLatency      *metric.Histogram
+	BatchGetLatency    *metric.Histogram
+	BatchDeleteLatency *metric.Histogram
+	DiskUsageLatency   *metric.Histogram
+	WalkLatency        *metric.Histogram
+	LockLatency        *metric.Histogram
+	UnlockLatency      *metric.Histogram
+	WriteAtLatency     *metric.Histogram
+	TouchLatency       *metric.Histogram
+	ReadLatency        *metric.Histogram
+	WriteLatency       *metric.Histogram
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (Metrics) MetricStruct() {}
+
+var _ metric.Struct = Metrics{}
+
+var (
+	metaBytesRead = metric.Metadata{
+		Name:        "blobs.bytes.read",
+		Help:        "Number of bytes read from files by the blob service",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaBytesWritten = metric.Metadata{
+		Name:        "blobs.bytes.written",
+		Help:        "Number of bytes written to files by the blob service",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaGetCount = metric.Metadata{
+		Name:        "blobs.get.count",
+		Help:        "Number of blob service file reads",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaPutCount = metric.Metadata{
+		Name:        "blobs.put.count",
+		Help:        "Number of blob service file writes",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaListCount = metric.Metadata{
+		Name:        "blobs.list.count",
+		Help:        "Number of blob service file listings",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDeleteCount = metric.Metadata{
+		Name:        "blobs.delete.count",
+		Help:        "Number of blob service file deletions",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStatCount = metric.Metadata{
+		Name:        "blobs.stat.count",
+		Help:        "Number of blob service file stats",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaAppendCount = metric.Metadata{
+		Name:        "blobs.append.count",
+		Help:        "Number of blob service file appends",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaCopyCount = metric.Metadata{
+		Name:        "blobs.copy.count",
+		Help:        "Number of blob service file copies",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaMoveCount = metric.Metadata{
+		Name:        "blobs.move.count",
+		Help:        "Number of blob service file moves",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaMkdirCount = metric.Metadata{
+		Name:        "blobs.mkdir.count",
+		Help:        "Number of blob service directory creations",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaTruncateCount = metric.Metadata{
+		Name:        "blobs.truncate.count",
+		Help:        "Number of blob service file truncations",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaChecksumCount = metric.Metadata{
+		Name:        "blobs.checksum.count",
+		Help:        "Number of blob service file checksums",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaExistsCount = metric.Metadata{
+		Name:        "blobs.exists.count",
+		Help:        "Number of blob service file existence checks",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaBatchGetCount = metric.Metadata{
+		Name:        "blobs.batch_get.count",
+		Help:        "Number of blob service batched file reads",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaBatchDeleteCount = metric.Metadata{
+		Name:        "blobs.batch_delete.count",
+		Help:        "Number of blob service batched file deletions",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaDiskUsageCount = metric.Metadata{
+		Name:        "blobs.disk_usage.count",
+		Help:        "Number of blob service disk usage queries",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaWalkCount = metric.Metadata{
+		Name:        "blobs.walk.count",
+		Help:        "Number of blob service directory walks",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLockCount = metric.Metadata{
+		Name:        "blobs.lock.count",
+		Help:        "Number of blob service lock acquisitions",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaUnlockCount = metric.Metadata{
+		Name:        "blobs.unlock.count",
+		Help:        "Number of blob service lock releases",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaWriteAtCount = metric.Metadata{
+		Name:        "blobs.writeat.count",
+		Help:        "Number of blob service file writes at an offset",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaTouchCount = metric.Metadata{
+		Name:        "blobs.touch.count",
+		Help:        "Number of blob service file touches",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReadCount = metric.Metadata{
+		Name:        "blobs.read.count",
+		Help:        "Number of blob service file reads streamed by ReadBlob",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaWriteCount = metric.Metadata{
+		Name:        "blobs.write.count",
+		Help:        "Number of blob service file writes streamed by WriteBlob",
+		Measurement: "Operations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaGetLatency = metric.Metadata{
+		Name:        "blobs.get.latency",
+		Help:        "Latency of blob service file reads",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaPutLatency = metric.Metadata{
+		Name:        "blobs.put.latency",
+		Help:        "Latency of blob service file writes",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaListLatency = metric.Metadata{
+		Name:        "blobs.list.latency",
+		Help:        "Latency of blob service file listings",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaDeleteLatency = metric.Metadata{
+		Name:        "blobs.delete.latency",
+		Help:        "Latency of blob service file deletions",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaStatLatency = metric.Metadata{
+		Name:        "blobs.stat.latency",
+		Help:        "Latency of blob service file stats",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaAppendLatency = metric.Metadata{
+		Name:        "blobs.append.latency",
+		Help:        "Latency of blob service file appends",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaCopyLatency = metric.Metadata{
+		Name:        "blobs.copy.latency",
+		Help:        "Latency of blob service file copies",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaMoveLatency = metric.Metadata{
+		Name:        "blobs.move.latency",
+		Help:        "Latency of blob service file moves",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaMkdirLatency = metric.Metadata{
+		Name:        "blobs.mkdir.latency",
+		Help:        "Latency of blob service directory creations",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaTruncateLatency = metric.Metadata{
+		Name:        "blobs.truncate.latency",
+		Help:        "Latency of blob service file truncations",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaChecksumLatency = metric.Metadata{
+		Name:        "blobs.checksum.latency",
+		Help:        "Latency of blob service file checksums",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaExistsLatency = metric.Metadata{
+		Name:        "blobs.exists.latency",
+		Help:        "Latency of blob service file existence checks",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaBatchGetLatency = metric.Metadata{
+		Name:        "blobs.batch_get.latency",
+		Help:        "Latency of blob service batched file reads",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaBatchDeleteLatency = metric.Metadata{
+		Name:        "blobs.batch_delete.latency",
+		Help:        "Latency of blob service batched file deletions",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaDiskUsageLatency = metric.Metadata{
+		Name:        "blobs.disk_usage.latency",
+		Help:        "Latency of blob service disk usage queries",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaWalkLatency = metric.Metadata{
+		Name:        "blobs.walk.latency",
+		Help:        "Latency of blob service directory walks",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaLockLatency = metric.Metadata{
+		Name:        "blobs.lock.latency",
+		Help:        "Latency of blob service lock acquisitions",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaUnlockLatency = metric.Metadata{
+		Name:        "blobs.unlock.latency",
+		Help:        "Latency of blob service lock releases",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaWriteAtLatency = metric.Metadata{
+		Name:        "blobs.writeat.latency",
+		Help:        "Latency of blob service file writes at an offset",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaTouchLatency = metric.Metadata{
+		Name:        "blobs.touch.latency",
+		Help:        "Latency of blob service file touches",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaReadLatency = metric.Metadata{
+		Name:        "blobs.read.latency",
+		Help:        "Latency of blob service file reads streamed by ReadBlob",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaWriteLatency = metric.Metadata{
+		Name:        "blobs.write.latency",
+		Help:        "Latency of blob service file writes streamed by WriteBlob",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+)
+
+// MakeMetrics instantiates the metrics holder for blob service monitoring.
+func MakeMetrics(histogramWindow time.Duration) Metrics {
+	return Metrics{
+		BytesRead:          metric.NewCounter(metaBytesRead),
+		BytesWritten:       metric.NewCounter(metaBytesWritten),
+		GetCount:           metric.NewCounter(metaGetCount),
+		PutCount:           metric.NewCounter(metaPutCount),
+		ListCount:          metric.NewCounter(metaListCount),
+		DeleteCount:        metric.NewCounter(metaDeleteCount),
+		StatCount:          metric.NewCounter(metaStatCount),
+		AppendCount:        metric.NewCounter(metaAppendCount),
+		CopyCount:          metric.NewCounter(metaCopyCount),
+		MoveCount:          metric.NewCounter(metaMoveCount),
+		MkdirCount:         metric.NewCounter(metaMkdirCount),
+		TruncateCount:      metric.NewCounter(metaTruncateCount),
+		ChecksumCount:      metric.NewCounter(metaChecksumCount),
+		ExistsCount:        metric.NewCounter(metaExistsCount),
+		BatchGetCount:      metric.NewCounter(metaBatchGetCount),
+		BatchDeleteCount:   metric.NewCounter(metaBatchDeleteCount),
+		DiskUsageCount:     metric.NewCounter(metaDiskUsageCount),
+		WalkCount:          metric.NewCounter(metaWalkCount),
+		LockCount:          metric.NewCounter(metaLockCount),
+		UnlockCount:        metric.NewCounter(metaUnlockCount),
+		WriteAtCount:       metric.NewCounter(metaWriteAtCount),
+		TouchCount:         metric.NewCounter(metaTouchCount),
+		ReadCount:          metric.NewCounter(metaReadCount),
+		WriteCount:         metric.NewCounter(metaWriteCount),
+		GetLatency:         metric.NewLatency(metaGetLatency, histogramWindow),
+		PutLatency:         metric.NewLatency(metaPutLatency, histogramWindow),
+		ListLatency:        metric.NewLatency(metaListLatency, histogramWindow),
+		DeleteLatency:      metric.NewLatency(metaDeleteLatency, histogramWindow),
+		StatLatency:        metric.NewLatency(metaStatLatency, histogramWindow),
+		AppendLatency:      metric.NewLatency(metaAppendLatency, histogramWindow),
+		CopyLatency:        metric.NewLatency(metaCopyLatency, histogramWindow),
+		MoveLatency:        metric.NewLatency(metaMoveLatency, histogramWindow),
+		MkdirLatency:       metric.NewLatency(metaMkdirLatency, histogramWindow),
+		TruncateLatency:    metric.NewLatency(metaTruncateLatency, histogramWindow),
+		ChecksumLatency:    metric.NewLatency(metaChecksumLatency, histogramWindow),
+		ExistsLatency:      metric.NewLatency(metaExistsLatency, histogramWindow),
+		BatchGetLatency:    metric.NewLatency(metaBatchGetLatency, histogramWindow),
+		BatchDeleteLatency: metric.NewLatency(metaBatchDeleteLatency, histogramWindow),
+		DiskUsageLatency:   metric.NewLatency(metaDiskUsageLatency, histogramWindow),
+		WalkLatency:        metric.NewLatency(metaWalkLatency, histogramWindow),
+		LockLatency:        metric.NewLatency(metaLockLatency, histogramWindow),
+		UnlockLatency:      metric.NewLatency(metaUnlockLatency, histogramWindow),
+		WriteAtLatency:     metric.NewLatency(metaWriteAtLatency, histogramWindow),
+		TouchLatency:       metric.NewLatency(metaTouchLatency, histogramWindow),
+		ReadLatency:        metric.NewLatency(metaReadLatency, histogramWindow),
+		WriteLatency:       metric.NewLatency(metaWriteLatency, histogramWindow),
+	}
+}
+
+// countingReader is an io.Reader which adds the number of bytes it reads to
+// a counter.
+type countingReader struct {
+	r       io.Reader
+	counter *metric.Counter
+	// read is the number of bytes read so far.
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.counter.Inc(int64(n))
+	r.read += int64(n)
+	return n, err
+}
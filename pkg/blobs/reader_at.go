@@ -0,0 +1,110 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"io"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// readRangeFunc returns a reader for the length bytes of a file which start
+// at offset.
+type readRangeFunc func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+
+// readCloser is an io.ReadCloser which reads from a reader and closes with a
+// function, e.g. to release the stream the reader reads from.
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r readCloser) Close() error { return r.close() }
+
+// blobReaderAt is an io.ReaderAt over a file of a BlobClient, which turns
+// every ReadAt into a read of the requested range of the file.
+//
+// If readAhead is set, reads of fewer bytes fetch readAhead bytes instead, and
+// the bytes which were not requested are kept to serve the next reads, so that
+// adjacent small reads, e.g. of the blocks of an SSTable, are coalesced.
+type blobReaderAt struct {
+	ctx       context.Context
+	readRange readRangeFunc
+	size      int64
+	readAhead int
+
+	mu struct {
+		syncutil.Mutex
+		// buf holds the bytes of the file which start at offset.
+		buf    []byte
+		offset int64
+	}
+}
+
+var _ io.ReaderAt = &blobReaderAt{}
+
+// ReadAt implements the io.ReaderAt interface.
+func (r *blobReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.Errorf("negative offset %d", off)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	want := p
+	if remaining := r.size - off; int64(len(want)) > remaining {
+		want = want[:remaining]
+	}
+	n, err := r.readAt(want, off)
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// readAt fills p, which does not extend past the end of the file, with the
+// bytes at off.
+func (r *blobReaderAt) readAt(p []byte, off int64) (int, error) {
+	if r.readAhead <= len(p) {
+		return r.fetch(p, off)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if off < r.mu.offset || off+int64(len(p)) > r.mu.offset+int64(len(r.mu.buf)) {
+		size := int64(r.readAhead)
+		if remaining := r.size - off; size > remaining {
+			size = remaining
+		}
+		buf := make([]byte, size)
+		n, err := r.fetch(buf, off)
+		r.mu.buf, r.mu.offset = buf[:n], off
+		if err != nil {
+			return copy(p, r.mu.buf), err
+		}
+	}
+	return copy(p, r.mu.buf[off-r.mu.offset:]), nil
+}
+
+// fetch fills p with the bytes at off, reading them from the client.
+func (r *blobReaderAt) fetch(p []byte, off int64) (int, error) {
+	content, err := r.readRange(r.ctx, off, int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	defer content.Close()
+	n, err := io.ReadFull(content, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		err = errors.Errorf("file truncated: read %d bytes at offset %d, expected %d", n, off, len(p))
+	}
+	return n, err
+}
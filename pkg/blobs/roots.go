@@ -0,0 +1,222 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// multiRootStorage is a Storage which serves the files of several external
+// IO dirs, e.g. so that backups and imports can use different disks.
+//
+// Paths are relative to the first root, the primary one, like with a single
+// LocalStorage, absolute paths included. The additional roots are selected
+// explicitly by the first element of a path: "@1" is the first additional
+// root, "@2" the second one, and so on. For instance, with the roots /data
+// and /fast, both "backups/1" and "/backups/1" address /data/backups/1, while
+// "@1/backups/1" addresses /fast/backups/1. A selector which does not match a
+// root is rejected rather than read from the primary root. Each root then
+// checks that the path does not escape it.
+type multiRootStorage struct {
+	roots []Storage
+}
+
+var _ Storage = &multiRootStorage{}
+
+// rootSelectorPrefix starts the first element of the paths which address an
+// additional root of a multiRootStorage.
+const rootSelectorPrefix = "@"
+
+// newMultiRootStorage returns a Storage over the external IO dirs in roots,
+// of which the first one is the primary root. A single root is served by a
+// LocalStorage.
+func newMultiRootStorage(roots []string) (Storage, error) {
+	if len(roots) == 1 {
+		return NewLocalStorage(roots[0])
+	}
+	s := &multiRootStorage{}
+	var dirs []string
+	for _, root := range roots {
+		l, err := NewLocalStorage(root)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil {
+			return nil, errors.New("external IO dirs cannot be empty")
+		}
+		for _, other := range dirs {
+			if withinDir(l.externalIODir, other) || withinDir(other, l.externalIODir) {
+				return nil, errors.Errorf("external IO dirs %q and %q overlap", other, l.externalIODir)
+			}
+		}
+		dirs = append(dirs, l.externalIODir)
+		s.roots = append(s.roots, l)
+	}
+	return s, nil
+}
+
+// route returns the root which holds path, along with path relative to that
+// root. Like with a single root, the path is cleaned first, so that "/@1/a"
+// selects the first additional root while "@1/../a" stays in the primary one.
+func (s *multiRootStorage) route(path string) (Storage, string, error) {
+	clean := filepath.Join(".", path)
+	first, rest := clean, "."
+	if i := strings.IndexRune(clean, filepath.Separator); i >= 0 {
+		first, rest = clean[:i], clean[i+1:]
+	}
+	if !strings.HasPrefix(first, rootSelectorPrefix) {
+		return s.roots[0], path, nil
+	}
+	suffix := strings.TrimPrefix(first, rootSelectorPrefix)
+	n, err := strconv.Atoi(suffix)
+	if err != nil || strconv.Itoa(n) != suffix {
+		// Not a selector, e.g. "@latest" or "@+1".
+		return s.roots[0], path, nil
+	}
+	if n < 1 || n >= len(s.roots) {
+		return nil, "", invalidArgumentf("%q does not select an external IO dir", path)
+	}
+	return s.roots[n], rest, nil
+}
+
+// ReadFile implements the Storage interface.
+func (s *multiRootStorage) ReadFile(filename string, offset int64) (io.ReadCloser, int64, error) {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	return root.ReadFile(rel, offset)
+}
+
+// WriterWithOptions implements the Storage interface.
+func (s *multiRootStorage) WriterWithOptions(
+	ctx context.Context, filename string, opts WriteOptions,
+) (io.WriteCloser, error) {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return nil, err
+	}
+	return root.WriterWithOptions(ctx, rel, opts)
+}
+
+// Append implements the Storage interface.
+func (s *multiRootStorage) Append(filename string, payload []byte) (int64, error) {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return 0, err
+	}
+	return root.Append(rel, payload)
+}
+
+// WriteAt implements the Storage interface.
+func (s *multiRootStorage) WriteAt(filename string, offset int64, payload []byte) (int64, error) {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return 0, err
+	}
+	return root.WriteAt(rel, offset, payload)
+}
+
+// Truncate implements the Storage interface.
+func (s *multiRootStorage) Truncate(filename string, size int64) error {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return err
+	}
+	return root.Truncate(rel, size)
+}
+
+// Rename implements the Storage interface. A rename from one root to another
+// fails with ErrCrossDevice, as the roots may be on different filesystems.
+func (s *multiRootStorage) Rename(source, destination string) error {
+	srcRoot, srcRel, err := s.route(source)
+	if err != nil {
+		return err
+	}
+	destRoot, destRel, err := s.route(destination)
+	if err != nil {
+		return err
+	}
+	if srcRoot != destRoot {
+		return errors.Mark(
+			errors.Errorf("cannot rename %q to %q in another external IO dir", source, destination),
+			ErrCrossDevice,
+		)
+	}
+	return srcRoot.Rename(srcRel, destRel)
+}
+
+// Mkdir implements the Storage interface.
+func (s *multiRootStorage) Mkdir(path string) error {
+	root, rel, err := s.route(path)
+	if err != nil {
+		return err
+	}
+	return root.Mkdir(rel)
+}
+
+// Touch implements the Storage interface.
+func (s *multiRootStorage) Touch(filename string, updateModTime bool) error {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return err
+	}
+	return root.Touch(rel, updateModTime)
+}
+
+// ReadDir implements the Storage interface.
+func (s *multiRootStorage) ReadDir(dir string) ([]os.FileInfo, error) {
+	root, rel, err := s.route(dir)
+	if err != nil {
+		return nil, err
+	}
+	return root.ReadDir(rel)
+}
+
+// Delete implements the Storage interface. Like deleteRecursive does for the
+// primary root, it refuses to delete an additional root.
+func (s *multiRootStorage) Delete(filename string) error {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return err
+	}
+	if root != s.roots[0] && rel == "." {
+		return deleteRootError("deleting an external-io-dir is not allowed: %s", filename)
+	}
+	return root.Delete(rel)
+}
+
+// FileInfo implements the Storage interface.
+func (s *multiRootStorage) FileInfo(filename string) (os.FileInfo, error) {
+	root, rel, err := s.route(filename)
+	if err != nil {
+		return nil, err
+	}
+	return root.FileInfo(rel)
+}
+
+// DiskUsage implements the Storage interface. It returns the disk usage of
+// the filesystem holding the root which path is routed to.
+func (s *multiRootStorage) DiskUsage(path string) (vfs.DiskUsage, error) {
+	root, rel, err := s.route(path)
+	if err != nil {
+		return vfs.DiskUsage{}, err
+	}
+	return root.DiskUsage(rel)
+}
@@ -26,10 +26,23 @@ which includes the following functionalities:
 package blobs
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/errors/oserror"
 	"google.golang.org/grpc/codes"
@@ -39,78 +52,1273 @@ import (
 
 // Service implements the gRPC BlobService which exchanges bulk files between different nodes.
 type Service struct {
-	localStorage *LocalStorage
+	storage Storage
+	// readLimit and writeLimit rate limit the bytes read from and written to
+	// files on behalf of clients.
+	readLimit  *rateLimit
+	writeLimit *rateLimit
+	// opsPool bounds the number of in-flight RPCs to its capacity, which is
+	// maxConcurrentOps when that is positive. It is not used otherwise.
+	opsPool *quotapool.IntPool
+	// maxConcurrentOps and rejectWhenBusy are accessed atomically, as they can
+	// change at runtime.
+	maxConcurrentOps int64
+	rejectWhenBusy   int32
+	metrics          Metrics
+	// maxDecompressedBytes bounds the decompressed size of a compressed
+	// payload.
+	maxDecompressedBytes int64
+	skipDiskSpaceCheck   bool
+	// maxFileSize bounds the size of the files written by the service, zero
+	// meaning unlimited.
+	maxFileSize int64
+	// auditLog is set when the operations which delete or replace files are
+	// reported as structured events.
+	auditLog bool
+	// locksMu serializes the acquisitions and releases of locks, so that
+	// checking whether a lock is free and taking it is atomic.
+	locksMu syncutil.Mutex
+	// disabled is set when the node has no external IO dir, in which case
+	// every RPC fails with ErrExternalIODisabled.
+	disabled bool
+	// readOnly is set when the RPCs which modify files fail with ErrReadOnly.
+	readOnly bool
 }
 
 var _ blobspb.BlobServer = &Service{}
 
+// ServiceOptions configures a Service. The zero value imposes no limits.
+type ServiceOptions struct {
+	// ReadBytesPerSecond limits the rate at which file contents are read and
+	// sent to clients. Zero means unlimited.
+	ReadBytesPerSecond int64
+	// WriteBytesPerSecond limits the rate at which file contents received
+	// from clients are written. Zero means unlimited.
+	WriteBytesPerSecond int64
+	// MaxConcurrentOps limits the number of RPCs handled at the same time.
+	// Zero means unlimited.
+	MaxConcurrentOps int
+	// RejectWhenBusy makes RPCs exceeding MaxConcurrentOps fail immediately
+	// with codes.ResourceExhausted rather than wait for an in-flight RPC to
+	// finish.
+	RejectWhenBusy bool
+	// HistogramWindowInterval is the rotation window of the service's latency
+	// histograms. It defaults to base.DefaultHistogramWindowInterval().
+	HistogramWindowInterval time.Duration
+	// Settings, if set, makes the service's limits follow the
+	// bulkio.blob_service.* cluster settings, which can be changed at runtime,
+	// instead of ReadBytesPerSecond, WriteBytesPerSecond, MaxConcurrentOps and
+	// RejectWhenBusy.
+	Settings *cluster.Settings
+	// MaxDecompressedBytes bounds the size a gzip compressed payload received
+	// by PutStream can decompress to, so that a small payload cannot fill the
+	// disk. It defaults to defaultMaxDecompressedBytes.
+	MaxDecompressedBytes int64
+	// SkipDiskSpaceCheck disables the check that there is enough free disk
+	// space for a write before it starts, for filesystems which do not report
+	// their free space reliably.
+	SkipDiskSpaceCheck bool
+	// AdditionalExternalIODirs are external IO dirs served along with the one
+	// passed to NewBlobService, e.g. on other disks. Their files are addressed
+	// by paths starting with "@1/" for the first one, "@2/" for the second one
+	// and so on, while every other path, absolute or not, remains relative to
+	// the primary external IO dir. A selector without a matching dir is
+	// rejected.
+	AdditionalExternalIODirs []string
+	// MaxFileSize bounds the size of the files written by the service. A write
+	// which would make a file larger fails with codes.ResourceExhausted, and
+	// leaves the file as it was. Zero means unlimited.
+	MaxFileSize int64
+	// AuditLog makes the service report the operations which delete or
+	// replace files, i.e. deletions, moves, and writes and copies over an
+	// existing file, as structured events on the OPS logging channel. An
+	// event is reported once its operation succeeded.
+	AuditLog bool
+	// ReadOnly makes the service reject the RPCs which modify files, e.g. on
+	// a node which should only serve restores. They fail with
+	// codes.FailedPrecondition, while files can still be read and listed.
+	ReadOnly bool
+}
+
+// defaultMaxDecompressedBytes is the default of
+// ServiceOptions.MaxDecompressedBytes.
+const defaultMaxDecompressedBytes = 8 << 30
+
 // NewBlobService instantiates a blob service server.
-func NewBlobService(externalIODir string) (*Service, error) {
-	localStorage, err := NewLocalStorage(externalIODir)
-	return &Service{localStorage: localStorage}, err
+func NewBlobService(externalIODir string, opts ServiceOptions) (*Service, error) {
+	if externalIODir == "" {
+		localStorage, err := NewLocalStorage(externalIODir)
+		s := NewBlobServiceWithStorage(localStorage, opts)
+		s.disabled = true
+		return s, err
+	}
+	if len(opts.AdditionalExternalIODirs) == 0 {
+		localStorage, err := NewLocalStorage(externalIODir)
+		return NewBlobServiceWithStorage(localStorage, opts), err
+	}
+	storage, err := newMultiRootStorage(append([]string{externalIODir}, opts.AdditionalExternalIODirs...))
+	if err != nil {
+		return nil, err
+	}
+	return NewBlobServiceWithStorage(storage, opts), nil
+}
+
+// NewBlobServiceWithStorage instantiates a blob service server which serves
+// the files held by storage.
+func NewBlobServiceWithStorage(storage Storage, opts ServiceOptions) *Service {
+	histogramWindow := opts.HistogramWindowInterval
+	if histogramWindow <= 0 {
+		histogramWindow = base.DefaultHistogramWindowInterval()
+	}
+	s := &Service{
+		storage:    storage,
+		readLimit:  newRateLimit("blob-service-read", opts.ReadBytesPerSecond),
+		writeLimit: newRateLimit("blob-service-write", opts.WriteBytesPerSecond),
+		opsPool:    quotapool.NewIntPool("blob-service-ops", 1),
+		metrics:    MakeMetrics(histogramWindow),
+	}
+	s.setMaxConcurrentOps(int64(opts.MaxConcurrentOps))
+	s.setRejectWhenBusy(opts.RejectWhenBusy)
+	s.skipDiskSpaceCheck = opts.SkipDiskSpaceCheck
+	s.maxFileSize = opts.MaxFileSize
+	s.auditLog = opts.AuditLog
+	s.readOnly = opts.ReadOnly
+	s.maxDecompressedBytes = opts.MaxDecompressedBytes
+	if s.maxDecompressedBytes <= 0 {
+		s.maxDecompressedBytes = defaultMaxDecompressedBytes
+	}
+	if opts.Settings != nil {
+		s.watchSettings(&opts.Settings.SV)
+	}
+	return s
+}
+
+// Metrics returns the metrics of the service.
+func (s *Service) Metrics() Metrics {
+	return s.metrics
+}
+
+// setMaxConcurrentOps sets the number of RPCs handled at the same time, zero
+// meaning unlimited. Lowering it does not affect in-flight RPCs.
+func (s *Service) setMaxConcurrentOps(n int64) {
+	if n > 0 {
+		s.opsPool.UpdateCapacity(uint64(n))
+	}
+	atomic.StoreInt64(&s.maxConcurrentOps, n)
+}
+
+// setRejectWhenBusy sets whether RPCs exceeding the maximum number of
+// concurrent RPCs fail rather than wait.
+func (s *Service) setRejectWhenBusy(reject bool) {
+	var v int32
+	if reject {
+		v = 1
+	}
+	atomic.StoreInt32(&s.rejectWhenBusy, v)
+}
+
+// acquireOp reserves a slot for an RPC, waiting for one to free up unless
+// the service was configured to reject RPCs when busy. It fails if external
+// IO is disabled, as every RPC starts with it. The returned function
+// must be called to release the slot once the RPC is done.
+func (s *Service) acquireOp(ctx context.Context) (func(), error) {
+	if s.disabled {
+		return nil, ErrExternalIODisabled
+	}
+	if atomic.LoadInt64(&s.maxConcurrentOps) <= 0 {
+		return func() {}, nil
+	}
+	var alloc *quotapool.IntAlloc
+	var err error
+	if atomic.LoadInt32(&s.rejectWhenBusy) != 0 {
+		alloc, err = s.opsPool.TryAcquire(ctx, 1)
+		if errors.Is(err, quotapool.ErrNotEnoughQuota) {
+			return nil, status.Error(codes.ResourceExhausted, "too many concurrent blob operations")
+		}
+	} else {
+		alloc, err = s.opsPool.Acquire(ctx, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return alloc.Release, nil
+}
+
+// ErrReadOnly is returned by the RPCs which modify files when the service is
+// read-only.
+var ErrReadOnly = errors.New("service is read-only")
+
+// acquireWriteOp is like acquireOp, for the RPCs which modify files. It fails
+// with ErrReadOnly if the service is read-only.
+func (s *Service) acquireWriteOp(ctx context.Context) (func(), error) {
+	if s.readOnly && !s.disabled {
+		return nil, ErrReadOnly
+	}
+	return s.acquireOp(ctx)
+}
+
+// ReadBlob implements the gRPC service.
+//
+// It streams the file like GetStream, in chunks of a megabyte unless the
+// request asks for another size, but is traced and counted on its own.
+func (s *Service) ReadBlob(
+	req *blobspb.ReadRequest, stream blobspb.Blob_ReadBlobServer,
+) (retErr error) {
+	ctx, op := startOp(
+		stream.Context(), "blob.Read", req.Filename, s.metrics.ReadCount, s.metrics.ReadLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	return s.streamFile(ctx, &op, &blobspb.GetRequest{
+		Filename:  req.Filename,
+		Offset:    req.Offset,
+		ChunkSize: int32(clampChunkSize(req.ChunkSize, defaultReadChunkSize)),
+	}, readBlobServer{stream})
+}
+
+// readBlobServer adapts a ReadBlob stream so that streamFile can send to it.
+type readBlobServer struct {
+	blobspb.Blob_ReadBlobServer
+}
+
+func (s readBlobServer) Send(chunk *blobspb.StreamChunk) error {
+	return s.Blob_ReadBlobServer.Send(&blobspb.ReadChunk{Payload: chunk.Payload})
 }
 
 // GetStream implements the gRPC service.
-func (s *Service) GetStream(req *blobspb.GetRequest, stream blobspb.Blob_GetStreamServer) error {
-	content, _, err := s.localStorage.ReadFile(req.Filename, req.Offset)
+//
+// The file is opened before anything is sent on the stream, so an error
+// opening it (e.g. because it does not exist) is always returned before the
+// first chunk and can be told apart from a failure mid-stream. A checksum
+// mismatch, on the other hand, is only detected once the whole file has been
+// sent, and fails the stream instead of completing it.
+func (s *Service) GetStream(
+	req *blobspb.GetRequest, stream blobspb.Blob_GetStreamServer,
+) (retErr error) {
+	ctx, op := startOp(
+		stream.Context(), "blob.Get", req.Filename, s.metrics.GetCount, s.metrics.GetLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	return s.streamFile(ctx, &op, req, stream)
+}
+
+// streamFile sends the file requested by req on stream, on behalf of op.
+func (s *Service) streamFile(
+	ctx context.Context, op *blobOp, req *blobspb.GetRequest, stream blobspb.Blob_GetStreamServer,
+) error {
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return err
+	}
+	content, _, err := s.storage.ReadFile(req.Filename, req.Offset)
 	if err != nil {
 		return err
 	}
 	defer content.Close()
-	return streamContent(stream, content)
+	counter := &countingReader{r: content, counter: s.metrics.BytesRead}
+	defer func() { op.addBytes(counter.read) }()
+	var r io.Reader = &contextReader{ctx: ctx, r: counter}
+	if req.Length > 0 {
+		r = io.LimitReader(r, req.Length)
+	}
+	var verifier *checksumVerifier
+	if req.ExpectedChecksum != "" {
+		if req.Offset != 0 || req.Length > 0 {
+			return invalidArgumentf(
+				"the checksum of %q can only be verified when reading the whole file", req.Filename,
+			)
+		}
+		verifier, err = newChecksumVerifier(r, req.Filename, req.Algorithm, req.ExpectedChecksum)
+		if err != nil {
+			return err
+		}
+		r = verifier
+	}
+	r = newLimitedReader(ctx, r, s.readLimit)
+	size := clampChunkSize(req.ChunkSize, chunkSize)
+	switch req.Compression {
+	case blobspb.Compression_NONE:
+		err = streamContent(stream, r, size)
+	case blobspb.Compression_GZIP:
+		err = streamCompressedContent(stream, r, size)
+	default:
+		return invalidArgumentf("unsupported compression %s", req.Compression)
+	}
+	if err != nil || verifier == nil {
+		return err
+	}
+	// The whole file was read, but the stream only completes if it was not
+	// corrupt.
+	return verifier.verify()
+}
+
+// maxBatchGetSize bounds the total size of the files returned by GetBlobs.
+const maxBatchGetSize = 16 << 20
+
+// GetBlobs implements the gRPC service.
+//
+// Each file is validated and read on its own, so that a file which cannot be
+// read only fails its own result. A file which would take the response over
+// maxBatchGetSize is not read either, and has to be fetched with GetStream.
+func (s *Service) GetBlobs(
+	ctx context.Context, req *blobspb.BatchGetRequest,
+) (_ *blobspb.BatchGetResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.GetBlobs", "", s.metrics.BatchGetCount, s.metrics.BatchGetLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp := &blobspb.BatchGetResponse{
+		Results: make([]*blobspb.BatchGetResult, len(req.Filenames)),
+	}
+	remaining := int64(maxBatchGetSize)
+	for i, filename := range req.Filenames {
+		// Give up on the whole batch rather than fail each of the remaining
+		// files once the RPC is cancelled.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result := &blobspb.BatchGetResult{Filename: filename}
+		payload, err := s.readWholeFile(ctx, filename, remaining)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Payload = payload
+			remaining -= int64(len(payload))
+			op.addBytes(int64(len(payload)))
+		}
+		resp.Results[i] = result
+	}
+	return resp, nil
+}
+
+// readWholeFile returns the contents of filename, as long as it holds at most
+// maxSize bytes when it is opened.
+func (s *Service) readWholeFile(ctx context.Context, filename string, maxSize int64) ([]byte, error) {
+	if err := validatePath(filename); err != nil {
+		return nil, err
+	}
+	content, size, err := s.storage.ReadFile(filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	if size > maxSize {
+		return nil, errors.Errorf(
+			"file %s of %d bytes does not fit in the %d bytes left in the batch",
+			filename, size, maxSize,
+		)
+	}
+	r := newLimitedReader(ctx, &contextReader{
+		ctx: ctx,
+		r:   &countingReader{r: io.LimitReader(content, size), counter: s.metrics.BytesRead},
+	}, s.readLimit)
+	return ioutil.ReadAll(r)
 }
 
 // PutStream implements the gRPC service.
-func (s *Service) PutStream(stream blobspb.Blob_PutStreamServer) error {
-	md, ok := metadata.FromIncomingContext(stream.Context())
+//
+// The target filename is passed in the stream's metadata and is validated
+// against the external IO dir before any file is opened. The payload is
+// written to a temporary file next to the target which is only moved into
+// place once the whole stream has been received; it is removed if the stream
+// fails or the context is cancelled.
+func (s *Service) PutStream(stream blobspb.Blob_PutStreamServer) (retErr error) {
+	ctx, op := startOp(stream.Context(), "blob.Put", "", s.metrics.PutCount, s.metrics.PutLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return errors.New("could not fetch metadata")
 	}
 	filename := md.Get("filename")
 	if len(filename) < 1 || filename[0] == "" {
-		return errors.New("no filename in metadata")
+		return invalidArgumentf("no filename in metadata")
+	}
+	op.setTag("filename", filename[0])
+	if err := validatePath(filename[0]); err != nil {
+		return err
+	}
+	compression, err := compressionFromMetadata(md)
+	if err != nil {
+		return err
+	}
+	opts, err := writeOptionsFromMetadata(md)
+	if err != nil {
+		return err
+	}
+	expectedSize, err := expectedSizeFromMetadata(md)
+	if err != nil {
+		return err
+	}
+	if err := s.checkFileSize(filename[0], expectedSize); err != nil {
+		return err
+	}
+	if err := s.checkDiskSpace(filename[0], expectedSize); err != nil {
+		return err
 	}
 	reader := newPutStreamReader(stream)
 	defer reader.Close()
-	ctx, cancel := context.WithCancel(stream.Context())
+	_, err = s.receiveFile(ctx, &op, filename[0], reader, compression, opts, expectedSize)
+	return err
+}
+
+// WriteBlob implements the gRPC service.
+//
+// The first message of the stream carries the target filename, which is
+// validated against the external IO dir before any file is opened, and the
+// following ones the contents of the file. The contents are written like those
+// of PutStream: to a temporary file next to the target which is only moved
+// into place once the whole stream has been received, and is removed if the
+// stream fails or the context is cancelled.
+func (s *Service) WriteBlob(stream blobspb.Blob_WriteBlobServer) (retErr error) {
+	ctx, op := startOp(
+		stream.Context(), "blob.Write", "", s.metrics.WriteCount, s.metrics.WriteLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return invalidArgumentf("empty stream: the first message must carry the filename")
+	}
+	if err != nil {
+		return err
+	}
+	if first.Filename == "" {
+		return invalidArgumentf("no filename in the first message")
+	}
+	if len(first.Payload) > 0 {
+		return invalidArgumentf("the first message must not carry a payload")
+	}
+	op.setTag("filename", first.Filename)
+	if err := validatePath(first.Filename); err != nil {
+		return err
+	}
+	n, err := s.receiveFile(ctx, &op, first.Filename,
+		&blobStreamReader{stream: writeBlobReceiver{stream}},
+		blobspb.Compression_NONE, WriteOptions{}, -1 /* expectedSize */)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(&blobspb.WriteResponse{Filesize: n})
+}
+
+// receiveFile writes the content received by a PutStream or WriteBlob RPC to
+// filename, which must have been validated, and returns the number of bytes
+// written. The content is decompressed if need be, and must amount to
+// expectedSize bytes unless that is negative.
+func (s *Service) receiveFile(
+	ctx context.Context,
+	op *blobOp,
+	filename string,
+	reader io.Reader,
+	compression blobspb.Compression,
+	opts WriteOptions,
+	expectedSize int64,
+) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	w, err := s.localStorage.Writer(ctx, filename[0])
+	var content io.Reader = reader
+	if compression == blobspb.Compression_GZIP {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, errors.Wrap(err, "decompressing payload")
+		}
+		defer gz.Close()
+		content = &maxSizeReader{
+			r:   gz,
+			max: s.maxDecompressedBytes,
+			tooLarge: invalidArgumentf(
+				"decompressed payload exceeds the maximum of %d bytes", s.maxDecompressedBytes,
+			),
+		}
+	}
+	if s.maxFileSize > 0 {
+		// The size is checked as the content is received, so that the write
+		// fails before the file grows past the maximum.
+		content = &maxSizeReader{
+			r:        content,
+			max:      s.maxFileSize,
+			tooLarge: fileTooLargeError(filename, s.maxFileSize),
+		}
+	}
+
+	oldSize, overwrite := s.auditFileSize(filename)
+	w, err := s.storage.WriterWithOptions(ctx, filename, opts)
 	if err != nil {
 		cancel()
-		return err
+		return 0, err
 	}
-	if _, err := io.Copy(w, reader); err != nil {
+	buf := buffers.get(chunkSize)
+	defer buffers.put(buf)
+	n, err := io.CopyBuffer(newLimitedWriter(ctx, w, s.writeLimit), content, *buf)
+	s.metrics.BytesWritten.Inc(n)
+	op.addBytes(n)
+	if err != nil {
+		// Cancelling the context makes the writer discard the temporary file
+		// on Close. Report the copy error first, since the writer will only
+		// complain about the cancellation.
+		cancel()
+		return n, errors.CombineErrors(err, w.Close())
+	}
+	if expectedSize >= 0 && n != expectedSize {
+		// The stream may have been cut short: discard the file rather than
+		// leave a truncated one in place.
 		cancel()
-		return errors.CombineErrors(w.Close(), err)
+		return n, errors.CombineErrors(
+			status.Errorf(codes.DataLoss, "received %d bytes but %d bytes were expected", n, expectedSize),
+			w.Close(),
+		)
 	}
 	err = w.Close()
 	cancel()
-	return err
+	if err != nil {
+		return n, err
+	}
+	if overwrite {
+		s.auditOverwrite(ctx, filename, oldSize)
+	}
+	return n, nil
+}
+
+// compressionFromMetadata returns the Compression named by the "compression"
+// key of a PutStream's metadata, or NONE if there is no such key.
+func compressionFromMetadata(md metadata.MD) (blobspb.Compression, error) {
+	vals := md.Get("compression")
+	if len(vals) < 1 || vals[0] == "" {
+		return blobspb.Compression_NONE, nil
+	}
+	c, ok := blobspb.Compression_value[vals[0]]
+	if !ok {
+		return 0, invalidArgumentf("unsupported compression %q", vals[0])
+	}
+	return blobspb.Compression(c), nil
+}
+
+// writeOptionsFromMetadata returns the WriteOptions described by a
+// PutStream's metadata. The optional "mode" key holds the permission bits of
+// the written file, parsed like a Go integer literal (e.g. "0644"), and the
+// optional "if-not-exists" and "sync" keys hold booleans.
+func writeOptionsFromMetadata(md metadata.MD) (WriteOptions, error) {
+	var opts WriteOptions
+	if vals := md.Get("mode"); len(vals) > 0 && vals[0] != "" {
+		mode, err := strconv.ParseUint(vals[0], 0, 32)
+		if err != nil {
+			return WriteOptions{}, errors.Mark(
+				errors.Wrapf(err, "invalid file mode %q", vals[0]), errInvalidArgument,
+			)
+		}
+		opts.Mode = os.FileMode(mode)
+	}
+	if vals := md.Get("if-not-exists"); len(vals) > 0 && vals[0] != "" {
+		ifNotExists, err := strconv.ParseBool(vals[0])
+		if err != nil {
+			return WriteOptions{}, errors.Mark(
+				errors.Wrapf(err, "invalid if-not-exists value %q", vals[0]), errInvalidArgument,
+			)
+		}
+		opts.IfNotExists = ifNotExists
+	}
+	if vals := md.Get("sync"); len(vals) > 0 && vals[0] != "" {
+		sync, err := strconv.ParseBool(vals[0])
+		if err != nil {
+			return WriteOptions{}, errors.Mark(
+				errors.Wrapf(err, "invalid sync value %q", vals[0]), errInvalidArgument,
+			)
+		}
+		opts.Sync = sync
+	}
+	return opts, nil
+}
+
+// expectedSizeFromMetadata returns the size of the file written by a
+// PutStream, as declared by the optional "expected-size" key of its metadata,
+// or -1 if it is not declared.
+func expectedSizeFromMetadata(md metadata.MD) (int64, error) {
+	vals := md.Get("expected-size")
+	if len(vals) < 1 || vals[0] == "" {
+		return -1, nil
+	}
+	size, err := strconv.ParseInt(vals[0], 10, 64)
+	if err != nil || size < 0 {
+		return 0, invalidArgumentf("invalid expected-size %q", vals[0])
+	}
+	return size, nil
+}
+
+// checkDiskSpace returns a ResourceExhausted error if the filesystem which
+// holds filename does not have size bytes of free disk space, so that a write
+// which cannot fit fails before it starts rather than with ENOSPC halfway
+// through.
+func (s *Service) checkDiskSpace(filename string, size int64) error {
+	if s.skipDiskSpaceCheck || size <= 0 {
+		return nil
+	}
+	du, err := s.storage.DiskUsage(filename)
+	if err != nil {
+		return errors.Wrap(err, "checking free disk space")
+	}
+	if uint64(size) > du.AvailBytes {
+		return status.Errorf(codes.ResourceExhausted,
+			"insufficient disk space: writing %d bytes but only %d bytes are available",
+			size, du.AvailBytes)
+	}
+	return nil
+}
+
+// ErrFileTooLarge marks the errors returned for a write which would make a
+// file larger than ServiceOptions.MaxFileSize.
+var ErrFileTooLarge = errors.New("file too large")
+
+// fileTooLargeError returns the error reported for a write which would make
+// name larger than max bytes.
+func fileTooLargeError(name string, max int64) error {
+	return errors.Mark(
+		errors.Errorf("writing %q: file exceeds the maximum file size of %d bytes", name, max),
+		ErrFileTooLarge,
+	)
+}
+
+// checkFileSize returns an error if the file name cannot have size bytes
+// because of the maximum file size.
+func (s *Service) checkFileSize(name string, size int64) error {
+	if s.maxFileSize > 0 && size > s.maxFileSize {
+		return fileTooLargeError(name, s.maxFileSize)
+	}
+	return nil
+}
+
+// AppendBlob implements the gRPC service.
+func (s *Service) AppendBlob(
+	ctx context.Context, req *blobspb.AppendRequest,
+) (_ *blobspb.AppendResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.Append", req.Filename, s.metrics.AppendCount, s.metrics.AppendLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	if s.maxFileSize > 0 {
+		// A file which cannot be stat'ed is left for Append to report on.
+		var size int64
+		if fi, err := s.storage.FileInfo(req.Filename); err == nil {
+			size = fi.Size()
+		}
+		if err := s.checkFileSize(req.Filename, size+int64(len(req.Payload))); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkDiskSpace(req.Filename, int64(len(req.Payload))); err != nil {
+		return nil, err
+	}
+	if err := s.writeLimit.waitN(ctx, int64(len(req.Payload))); err != nil {
+		return nil, err
+	}
+	size, err := s.storage.Append(req.Filename, req.Payload)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.BytesWritten.Inc(int64(len(req.Payload)))
+	op.addBytes(int64(len(req.Payload)))
+	return &blobspb.AppendResponse{Filesize: size}, nil
+}
+
+// WriteBlobAt implements the gRPC service.
+//
+// The file is written in place, so the write is neither atomic nor hidden from
+// concurrent readers, unlike with PutStream.
+func (s *Service) WriteBlobAt(
+	ctx context.Context, req *blobspb.WriteAtRequest,
+) (_ *blobspb.WriteAtResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.WriteAt", req.Filename, s.metrics.WriteAtCount, s.metrics.WriteAtLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	if req.Offset < 0 {
+		return nil, invalidArgumentf("negative offset %d", req.Offset)
+	}
+	if err := s.checkFileSize(req.Filename, req.Offset+int64(len(req.Payload))); err != nil {
+		return nil, err
+	}
+	if err := s.checkDiskSpace(req.Filename, int64(len(req.Payload))); err != nil {
+		return nil, err
+	}
+	if err := s.writeLimit.waitN(ctx, int64(len(req.Payload))); err != nil {
+		return nil, err
+	}
+	size, err := s.storage.WriteAt(req.Filename, req.Offset, req.Payload)
+	if err != nil {
+		return nil, err
+	}
+	s.metrics.BytesWritten.Inc(int64(len(req.Payload)))
+	op.addBytes(int64(len(req.Payload)))
+	return &blobspb.WriteAtResponse{Filesize: size}, nil
+}
+
+// CopyBlob implements the gRPC service.
+func (s *Service) CopyBlob(
+	ctx context.Context, req *blobspb.CopyRequest,
+) (_ *blobspb.CopyResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.Copy", req.Source, s.metrics.CopyCount, s.metrics.CopyLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	op.setTag("destination", req.Destination)
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	for _, path := range []string{req.Source, req.Destination} {
+		if err := validatePath(path); err != nil {
+			return nil, err
+		}
+	}
+	oldSize, overwrite := s.auditFileSize(req.Destination)
+	n, err := copyFile(ctx, s.storage, req.Source, req.Destination, nil /* progress */)
+	s.metrics.BytesRead.Inc(n)
+	s.metrics.BytesWritten.Inc(n)
+	op.addBytes(n)
+	if err != nil {
+		return nil, err
+	}
+	if overwrite {
+		s.auditOverwrite(ctx, req.Destination, oldSize)
+	}
+	return &blobspb.CopyResponse{}, nil
+}
+
+// copyProgressInterval is the minimum interval between the progress messages
+// sent by CopyBlobProgress. It is a variable so that tests can lower it.
+var copyProgressInterval = time.Second
+
+// CopyBlobProgress implements the gRPC service.
+//
+// It copies the file like CopyBlob, and reports the progress of the copy on
+// stream, ending with a message with Done set once the copy completed.
+func (s *Service) CopyBlobProgress(
+	req *blobspb.CopyRequest, stream blobspb.Blob_CopyBlobProgressServer,
+) (retErr error) {
+	ctx, op := startOp(
+		stream.Context(), "blob.CopyProgress", req.Source,
+		s.metrics.CopyCount, s.metrics.CopyLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	op.setTag("destination", req.Destination)
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	for _, path := range []string{req.Source, req.Destination} {
+		if err := validatePath(path); err != nil {
+			return err
+		}
+	}
+	var lastSent time.Time
+	progress := func(copied, total int64) error {
+		if now := timeutil.Now(); now.Sub(lastSent) >= copyProgressInterval {
+			lastSent = now
+			return stream.Send(&blobspb.CopyProgress{BytesCopied: copied, TotalBytes: total})
+		}
+		return nil
+	}
+	oldSize, overwrite := s.auditFileSize(req.Destination)
+	n, err := copyFile(ctx, s.storage, req.Source, req.Destination, progress)
+	s.metrics.BytesRead.Inc(n)
+	s.metrics.BytesWritten.Inc(n)
+	op.addBytes(n)
+	if err != nil {
+		return err
+	}
+	if overwrite {
+		s.auditOverwrite(ctx, req.Destination, oldSize)
+	}
+	return stream.Send(&blobspb.CopyProgress{BytesCopied: n, TotalBytes: n, Done: true})
+}
+
+// MoveBlob implements the gRPC service.
+func (s *Service) MoveBlob(
+	ctx context.Context, req *blobspb.MoveRequest,
+) (_ *blobspb.MoveResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.Move", req.Source, s.metrics.MoveCount, s.metrics.MoveLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	op.setTag("destination", req.Destination)
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	for _, path := range []string{req.Source, req.Destination} {
+		if err := validatePath(path); err != nil {
+			return nil, err
+		}
+	}
+	size, _ := s.auditFileSize(req.Source)
+	if err := moveFile(ctx, s.storage, req.Source, req.Destination); err != nil {
+		return nil, err
+	}
+	s.auditMove(ctx, req.Source, req.Destination, size)
+	return &blobspb.MoveResponse{}, nil
+}
+
+// Mkdir implements the gRPC service.
+func (s *Service) Mkdir(
+	ctx context.Context, req *blobspb.MkdirRequest,
+) (_ *blobspb.MkdirResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.Mkdir", req.Path, s.metrics.MkdirCount, s.metrics.MkdirLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Path); err != nil {
+		return nil, err
+	}
+	return &blobspb.MkdirResponse{}, s.storage.Mkdir(req.Path)
+}
+
+// Touch implements the gRPC service.
+func (s *Service) Touch(
+	ctx context.Context, req *blobspb.TouchRequest,
+) (_ *blobspb.TouchResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.Touch", req.Filename, s.metrics.TouchCount, s.metrics.TouchLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Touch(req.Filename, req.UpdateModTime); err != nil {
+		return nil, err
+	}
+	return &blobspb.TouchResponse{}, nil
+}
+
+// TruncateBlob implements the gRPC service.
+func (s *Service) TruncateBlob(
+	ctx context.Context, req *blobspb.TruncateRequest,
+) (_ *blobspb.TruncateResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.Truncate", req.Filename, s.metrics.TruncateCount, s.metrics.TruncateLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	if err := s.checkFileSize(req.Filename, req.Size); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Truncate(req.Filename, req.Size); err != nil {
+		return nil, err
+	}
+	return &blobspb.TruncateResponse{}, nil
+}
+
+// Checksum implements the gRPC service.
+func (s *Service) Checksum(
+	ctx context.Context, req *blobspb.ChecksumRequest,
+) (_ *blobspb.ChecksumResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.Checksum", req.Filename, s.metrics.ChecksumCount, s.metrics.ChecksumLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	content, _, err := s.storage.ReadFile(req.Filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	counter := &countingReader{r: content, counter: s.metrics.BytesRead}
+	defer func() { op.addBytes(counter.read) }()
+	digest, err := checksum(
+		newLimitedReader(ctx, &contextReader{ctx: ctx, r: counter}, s.readLimit),
+		req.Algorithm,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &blobspb.ChecksumResponse{Digest: digest}, nil
 }
 
 // List implements the gRPC service.
 func (s *Service) List(
 	ctx context.Context, req *blobspb.GlobRequest,
-) (*blobspb.GlobResponse, error) {
-	matches, err := s.localStorage.List(req.Pattern)
-	return &blobspb.GlobResponse{Files: matches}, err
+) (_ *blobspb.GlobResponse, retErr error) {
+	ctx, op := startOp(ctx, "blob.List", req.Pattern, s.metrics.ListCount, s.metrics.ListLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Pattern); err != nil {
+		return nil, err
+	}
+	opts, err := listOptionsForPage(req.PageToken, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+	opts.modifiedAfterNanos = req.ModifiedAfterNanos
+	if opts.exclude, err = excludePatterns(req.Exclude); err != nil {
+		return nil, err
+	}
+	var matches []string
+	if req.Recursive {
+		matches, err = listRecursive(ctx, s.storage, req.Pattern, opts)
+	} else {
+		matches, err = listFiles(ctx, s.storage, req.Pattern, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var nextPageToken string
+	if req.PageSize > 0 && len(matches) > int(req.PageSize) {
+		matches = matches[:req.PageSize]
+		nextPageToken = base64.RawURLEncoding.EncodeToString([]byte(matches[len(matches)-1]))
+	}
+	resp := &blobspb.GlobResponse{Files: matches, NextPageToken: nextPageToken}
+	if req.Details {
+		// A match which cannot be stat'ed, e.g. a dangling symlink, or one
+		// which was deleted since it was listed, is left out of the response
+		// rather than failing it. The page token still accounts for it.
+		resp.Files = resp.Files[:0]
+		for _, match := range matches {
+			fi, err := s.storage.FileInfo(match)
+			if err != nil {
+				if skipListingError(err) {
+					continue
+				}
+				return nil, err
+			}
+			resp.Files = append(resp.Files, match)
+			resp.FileInfos = append(resp.FileInfos, fileInfoProto(match, fi))
+		}
+	}
+	return resp, nil
+}
+
+// fileInfoProto returns the FileInfo describing the file at path.
+func fileInfoProto(path string, fi os.FileInfo) *blobspb.FileInfo {
+	return &blobspb.FileInfo{
+		Path:         path,
+		Size:         fi.Size(),
+		ModTimeNanos: fi.ModTime().UnixNano(),
+		IsDir:        fi.IsDir(),
+	}
+}
+
+// WalkBlobs implements the gRPC service.
+//
+// Unlike List, it sends every file and directory as soon as it is found, so
+// that even a huge tree can be walked without holding all of it in memory on
+// either side. The walk stops as soon as the stream's context is done.
+func (s *Service) WalkBlobs(
+	req *blobspb.WalkRequest, stream blobspb.Blob_WalkBlobsServer,
+) (retErr error) {
+	ctx, op := startOp(
+		stream.Context(), "blob.Walk", req.Root, s.metrics.WalkCount, s.metrics.WalkLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := validatePath(req.Root); err != nil {
+		return err
+	}
+	return walkEntries(ctx, s.storage, rootPath(req.Root), req.Recursive,
+		func(path string, fi os.FileInfo) error {
+			return stream.Send(fileInfoProto(path, fi))
+		})
+}
+
+// listOptionsForPage returns the listOptions selecting the page of at most
+// pageSize matches which follows the page that pageToken was returned for.
+// One more match is selected, so that the caller can tell whether there is a
+// next page. A page token encodes the last file of the page it
+// was returned for, so that a listing can be resumed deterministically even if
+// files are added or removed in the meantime.
+func listOptionsForPage(pageToken string, pageSize int) (listOptions, error) {
+	var opts listOptions
+	if pageToken != "" {
+		last, err := base64.RawURLEncoding.DecodeString(pageToken)
+		if err != nil {
+			return listOptions{}, errors.Mark(errors.Wrap(err, "invalid page token"), errInvalidArgument)
+		}
+		opts.after = string(last)
+	}
+	if pageSize > 0 {
+		opts.limit = pageSize + 1
+	}
+	return opts, nil
 }
 
 // Delete implements the gRPC service.
 func (s *Service) Delete(
 	ctx context.Context, req *blobspb.DeleteRequest,
-) (*blobspb.DeleteResponse, error) {
-	return &blobspb.DeleteResponse{}, s.localStorage.Delete(req.Filename)
+) (_ *blobspb.DeleteResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.Delete", req.Filename, s.metrics.DeleteCount, s.metrics.DeleteLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	size, _ := s.auditFileSize(req.Filename)
+	if req.Recursive {
+		var contentSize int64
+		contentSize, err = deleteRecursive(s.storage, req.Filename)
+		size += contentSize
+	} else {
+		err = s.storage.Delete(req.Filename)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.auditDelete(ctx, req.Filename, size, req.Recursive)
+	if req.RemoveEmptyParents {
+		if err := removeEmptyParents(s.storage, req.Filename); err != nil {
+			return nil, errors.Wrapf(err, "removing the empty parents of %q", req.Filename)
+		}
+	}
+	return &blobspb.DeleteResponse{}, nil
+}
+
+// DeleteBlobs implements the gRPC service.
+//
+// Like GetBlobs, it validates and deletes each file on its own, so that a file
+// which cannot be deleted, e.g. because it was already deleted, only fails its
+// own result.
+func (s *Service) DeleteBlobs(
+	ctx context.Context, req *blobspb.BatchDeleteRequest,
+) (_ *blobspb.BatchDeleteResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.DeleteBlobs", "", s.metrics.BatchDeleteCount, s.metrics.BatchDeleteLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp := &blobspb.BatchDeleteResponse{
+		Results: make([]*blobspb.BatchDeleteResult, len(req.Filenames)),
+	}
+	for i, filename := range req.Filenames {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result := &blobspb.BatchDeleteResult{Filename: filename}
+		err := validatePath(filename)
+		if err == nil {
+			size, _ := s.auditFileSize(filename)
+			if err = s.storage.Delete(filename); err == nil {
+				s.auditDelete(ctx, filename, size, false /* recursive */)
+			}
+		}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		resp.Results[i] = result
+	}
+	return resp, nil
 }
 
 // Stat implements the gRPC service.
-func (s *Service) Stat(ctx context.Context, req *blobspb.StatRequest) (*blobspb.BlobStat, error) {
-	resp, err := s.localStorage.Stat(req.Filename)
-	if oserror.IsNotExist(err) {
-		// gRPC hides the underlying golang ErrNotExist error, so we send back an
-		// equivalent gRPC error which can be handled gracefully on the client side.
-		return nil, status.Error(codes.NotFound, err.Error())
-	}
-	return resp, err
+func (s *Service) Stat(
+	ctx context.Context, req *blobspb.StatRequest,
+) (_ *blobspb.BlobStat, retErr error) {
+	ctx, op := startOp(ctx, "blob.Stat", req.Filename, s.metrics.StatCount, s.metrics.StatLatency)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	return statBlob(s.storage, req.Filename, req.AllowDir)
+}
+
+// Exists implements the gRPC service.
+func (s *Service) Exists(
+	ctx context.Context, req *blobspb.ExistsRequest,
+) (_ *blobspb.ExistsResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.Exists", req.Filename, s.metrics.ExistsCount, s.metrics.ExistsLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Filename); err != nil {
+		return nil, err
+	}
+	exists, isDir, err := fileExists(s.storage, req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return &blobspb.ExistsResponse{Exists: exists, IsDir: isDir}, nil
+}
+
+// DiskUsage implements the gRPC service.
+func (s *Service) DiskUsage(
+	ctx context.Context, req *blobspb.DiskUsageRequest,
+) (_ *blobspb.DiskUsageResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.DiskUsage", req.Path, s.metrics.DiskUsageCount, s.metrics.DiskUsageLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := validatePath(req.Path); err != nil {
+		return nil, err
+	}
+	du, err := s.storage.DiskUsage(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &blobspb.DiskUsageResponse{
+		CapacityBytes:  du.TotalBytes,
+		AvailableBytes: du.AvailBytes,
+		UsedBytes:      du.UsedBytes,
+	}, nil
+}
+
+// AcquireLock implements the gRPC service.
+//
+// A lock is held by the file of the same name in lockDir, which records the
+// token of its holder and when the lock expires. An expired lock is free to
+// be acquired again. Acquiring a lock which is held fails with ErrLockHeld.
+func (s *Service) AcquireLock(
+	ctx context.Context, req *blobspb.LockRequest,
+) (_ *blobspb.LockResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.AcquireLock", req.Name, s.metrics.LockCount, s.metrics.LockLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	path, err := lockFile(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if req.TtlNanos <= 0 {
+		return nil, invalidArgumentf("invalid lock TTL %d", req.TtlNanos)
+	}
+
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	holder, err := readLockHolder(s.storage, path)
+	if err == nil && timeutil.Now().Before(holder.expiration) {
+		return nil, errors.Wrapf(ErrLockHeld, "lock %q expires at %s", req.Name, holder.expiration)
+	}
+	if err != nil && !oserror.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "reading lock %q", req.Name)
+	}
+	holder = lockHolder{
+		token:      uuid.MakeV4().String(),
+		expiration: timeutil.Now().Add(time.Duration(req.TtlNanos)),
+	}
+	if err := writeLockHolder(ctx, s.storage, path, holder); err != nil {
+		return nil, errors.Wrapf(err, "writing lock %q", req.Name)
+	}
+	return &blobspb.LockResponse{
+		Token:           holder.token,
+		ExpirationNanos: holder.expiration.UnixNano(),
+	}, nil
+}
+
+// ReleaseLock implements the gRPC service.
+//
+// Releasing a lock with a token which does not hold it, e.g. because the lock
+// expired and was acquired again, fails with ErrLockHeld.
+func (s *Service) ReleaseLock(
+	ctx context.Context, req *blobspb.ReleaseRequest,
+) (_ *blobspb.ReleaseResponse, retErr error) {
+	ctx, op := startOp(
+		ctx, "blob.ReleaseLock", req.Name, s.metrics.UnlockCount, s.metrics.UnlockLatency,
+	)
+	defer func() { retErr = toStatus(retErr); op.finish(retErr) }()
+	release, err := s.acquireWriteOp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	path, err := lockFile(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+	holder, err := readLockHolder(s.storage, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading lock %q", req.Name)
+	}
+	if holder.token != req.Token {
+		return nil, errors.Wrapf(ErrLockHeld, "lock %q is not held by token %q", req.Name, req.Token)
+	}
+	if err := s.storage.Delete(path); err != nil {
+		return nil, errors.Wrapf(err, "deleting lock %q", req.Name)
+	}
+	return &blobspb.ReleaseResponse{}, nil
 }
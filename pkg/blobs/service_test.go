@@ -11,14 +11,44 @@
 package blobs
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/logpb"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/redact"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 func TestBlobServiceList(t *testing.T) {
@@ -31,7 +61,7 @@ func TestBlobServiceList(t *testing.T) {
 		writeTestFile(t, filepath.Join(tmpDir, file), fileContent)
 	}
 
-	service, err := NewBlobService(tmpDir)
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -54,6 +84,151 @@ func TestBlobServiceList(t *testing.T) {
 			}
 		}
 	})
+	t.Run("list-recursive", func(t *testing.T) {
+		nested := []string{"/file/nested/a/d.csv", "/file/nested/a/b/e.csv", "/file/nested/f.csv"}
+		for _, file := range nested {
+			writeTestFile(t, filepath.Join(tmpDir, file), fileContent)
+		}
+		// A symlink pointing outside of the external IO dir must not show up in
+		// the results.
+		outsideDir, cleanupOutside := testutils.TempDir(t)
+		defer cleanupOutside()
+		writeTestFile(t, filepath.Join(outsideDir, "outside.csv"), fileContent)
+		if err := os.Symlink(
+			filepath.Join(outsideDir, "outside.csv"), filepath.Join(tmpDir, "file/nested/outside.csv"),
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tc := range []struct {
+			pattern  string
+			expected []string
+		}{
+			{"file/nested", []string{"/file/nested/a/b/e.csv", "/file/nested/a/d.csv", "/file/nested/f.csv"}},
+			{"file/nested/a*", []string{"/file/nested/a/b/e.csv", "/file/nested/a/d.csv"}},
+			{"file/*", append(append([]string{}, files...),
+				"/file/nested/a/b/e.csv", "/file/nested/a/d.csv", "/file/nested/f.csv")},
+		} {
+			resp, err := service.List(ctx, &blobspb.GlobRequest{
+				Pattern:   tc.pattern,
+				Recursive: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Files) != len(tc.expected) {
+				t.Fatalf("%s: expected %s, got %s", tc.pattern, tc.expected, resp.Files)
+			}
+			for i, f := range resp.Files {
+				if f != tc.expected[i] {
+					t.Fatalf("%s: expected %s, got %s", tc.pattern, tc.expected, resp.Files)
+				}
+			}
+		}
+	})
+	t.Run("list-paginated", func(t *testing.T) {
+		var listed []string
+		req := &blobspb.GlobRequest{Pattern: "file/dir/*.csv", PageSize: 2}
+		for {
+			resp, err := service.List(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Files) > 2 {
+				t.Fatalf("page exceeds requested size: %s", resp.Files)
+			}
+			listed = append(listed, resp.Files...)
+			if resp.NextPageToken == "" {
+				break
+			}
+			if len(listed) == 2 {
+				// Files added before the current position must not affect the
+				// remaining pages.
+				writeTestFile(t, filepath.Join(tmpDir, "file/dir/0.csv"), fileContent)
+			}
+			req.PageToken = resp.NextPageToken
+		}
+		if len(listed) != len(files) {
+			t.Fatalf("expected %s, got %s", files, listed)
+		}
+		for i, f := range listed {
+			if f != files[i] {
+				t.Fatalf("expected %s, got %s", files, listed)
+			}
+		}
+	})
+	t.Run("list-details", func(t *testing.T) {
+		resp, err := service.List(ctx, &blobspb.GlobRequest{
+			Pattern: "file/*",
+			Details: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.FileInfos) != len(resp.Files) {
+			t.Fatalf("expected %d file infos, got %d", len(resp.Files), len(resp.FileInfos))
+		}
+		for i, info := range resp.FileInfos {
+			if info.Path != resp.Files[i] {
+				t.Fatalf("expected path %s, got %s", resp.Files[i], info.Path)
+			}
+			fi, err := os.Stat(filepath.Join(tmpDir, info.Path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.IsDir != fi.IsDir() || info.Size != fi.Size() ||
+				info.ModTimeNanos != fi.ModTime().UnixNano() {
+				t.Fatalf("incorrect details for %s: %+v", info.Path, info)
+			}
+		}
+
+		resp, err = service.List(ctx, &blobspb.GlobRequest{
+			Pattern: "file/dir/a.csv",
+			Details: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.FileInfos) != 1 || resp.FileInfos[0].IsDir ||
+			resp.FileInfos[0].Size != int64(len(fileContent)) {
+			t.Fatalf("incorrect details: %+v", resp.FileInfos)
+		}
+	})
+	t.Run("list-details-dangling-symlink", func(t *testing.T) {
+		outsideDir, cleanupOutside := testutils.TempDir(t)
+		defer cleanupOutside()
+		writeTestFile(t, filepath.Join(tmpDir, "links/real.csv"), fileContent)
+		for link, target := range map[string]string{
+			"links/dangling.csv": filepath.Join(tmpDir, "links/missing.csv"),
+			"links/outside.csv":  filepath.Join(outsideDir, "outside.csv"),
+		} {
+			if err := os.Symlink(target, filepath.Join(tmpDir, link)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		writeTestFile(t, filepath.Join(outsideDir, "outside.csv"), fileContent)
+
+		resp, err := service.List(ctx, &blobspb.GlobRequest{
+			Pattern: "links/*.csv",
+			Details: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []string{"/links/real.csv"}, resp.Files)
+		if len(resp.FileInfos) != 1 || resp.FileInfos[0].Path != "/links/real.csv" {
+			t.Fatalf("incorrect details: %+v", resp.FileInfos)
+		}
+	})
+	t.Run("list-invalid-page-token", func(t *testing.T) {
+		_, err := service.List(ctx, &blobspb.GlobRequest{
+			Pattern:   "file/dir/*.csv",
+			PageToken: "!",
+		})
+		if !testutils.IsError(err, "invalid page token") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
 	t.Run("not-in-external-io-dir", func(t *testing.T) {
 		_, err := service.List(ctx, &blobspb.GlobRequest{
 			Pattern: "file/../../*.csv",
@@ -67,6 +242,320 @@ func TestBlobServiceList(t *testing.T) {
 	})
 }
 
+// readDirRecordingStorage is a memStorage which records the directories it
+// reads.
+type readDirRecordingStorage struct {
+	*memStorage
+	read map[string]bool
+}
+
+func (s readDirRecordingStorage) ReadDir(dir string) ([]os.FileInfo, error) {
+	s.read[dir] = true
+	return s.memStorage.ReadDir(dir)
+}
+
+func TestBlobServiceListPagination(t *testing.T) {
+	storage := readDirRecordingStorage{memStorage: newMemStorage(), read: make(map[string]bool)}
+	files := []string{"/dir/a.csv", "/dir/a/b.csv", "/dir/b/c.csv", "/dir/b/d.csv", "/dir/c.csv"}
+	for _, file := range files {
+		writeStorageFile(t, storage, file, []byte("a"))
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, recursive := range []bool{false, true} {
+		pattern := "dir"
+		if recursive {
+			pattern = "*"
+		}
+		t.Run(pattern, func(t *testing.T) {
+			var listed []string
+			req := &blobspb.GlobRequest{Pattern: pattern, PageSize: 2, Recursive: recursive}
+			for {
+				for dir := range storage.read {
+					delete(storage.read, dir)
+				}
+				resp, err := service.List(ctx, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Directories whose files were all listed by previous pages must
+				// not be read again.
+				if n := len(listed); n > 0 && listed[n-1] > "/dir/a/" &&
+					!strings.HasPrefix(listed[n-1], "/dir/a/") && storage.read["/dir/a"] {
+					t.Fatalf("expected /dir/a to be skipped when resuming after %s", listed[n-1])
+				}
+				listed = append(listed, resp.Files...)
+				if resp.NextPageToken == "" {
+					break
+				}
+				req.PageToken = resp.NextPageToken
+			}
+			assert.Equal(t, files, listed)
+		})
+	}
+}
+
+type testWalkBlobsServer struct {
+	blobspb.Blob_WalkBlobsServer
+	ctx   context.Context
+	paths []string
+	// cancel, if set, is called once an entry has been sent.
+	cancel func()
+}
+
+func (s *testWalkBlobsServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testWalkBlobsServer) Send(fi *blobspb.FileInfo) error {
+	s.paths = append(s.paths, fi.Path)
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+func TestBlobServiceWalkBlobs(t *testing.T) {
+	storage := newMemStorage()
+	for _, filename := range []string{"a/b.txt", "a/c/d.txt", "a.txt", "e.txt"} {
+		writeStorageFile(t, storage, filename, []byte("content"))
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name      string
+		root      string
+		recursive bool
+		expected  []string
+	}{
+		{"root", "", false, []string{"/a", "/a.txt", "/e.txt"}},
+		{"root-recursive", "", true, []string{"/a", "/a/b.txt", "/a/c", "/a/c/d.txt", "/a.txt", "/e.txt"}},
+		{"dir", "a", false, []string{"/a/b.txt", "/a/c"}},
+		{"dir-recursive", "/a", true, []string{"/a/b.txt", "/a/c", "/a/c/d.txt"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &testWalkBlobsServer{ctx: ctx}
+			if err := service.WalkBlobs(
+				&blobspb.WalkRequest{Root: tc.root, Recursive: tc.recursive}, stream,
+			); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expected, stream.paths)
+		})
+	}
+	t.Run("missing-root", func(t *testing.T) {
+		err := service.WalkBlobs(&blobspb.WalkRequest{Root: "missing"}, &testWalkBlobsServer{ctx: ctx})
+		if !oserror.IsNotExist(err) {
+			t.Fatalf("expected a not exist error, got %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		err := service.WalkBlobs(&blobspb.WalkRequest{Root: "../outside"}, &testWalkBlobsServer{ctx: ctx})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream := &testWalkBlobsServer{ctx: ctx, cancel: cancel}
+		err := service.WalkBlobs(&blobspb.WalkRequest{Recursive: true}, stream)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		if len(stream.paths) != 1 {
+			t.Fatalf("expected the walk to stop after the first entry, got %v", stream.paths)
+		}
+	})
+}
+
+func TestBlobServiceListDoublestar(t *testing.T) {
+	storage := newMemStorage()
+	for _, file := range []string{
+		"backups/a.sst", "backups/x/b.sst", "backups/x/y/c.sst", "backups/x/y/c.txt", "other/d.sst",
+	} {
+		writeStorageFile(t, storage, file, []byte("content"))
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		pattern   string
+		recursive bool
+		expected  []string
+	}{
+		{"backups/**/*.sst", false, []string{"/backups/a.sst", "/backups/x/b.sst", "/backups/x/y/c.sst"}},
+		{"**/*.sst", false, []string{
+			"/backups/a.sst", "/backups/x/b.sst", "/backups/x/y/c.sst", "/other/d.sst",
+		}},
+		{"backups/**/y/*", false, []string{"/backups/x/y/c.sst", "/backups/x/y/c.txt"}},
+		// A single * still only matches within a path element.
+		{"backups/*/*.sst", false, []string{"/backups/x/b.sst"}},
+		{"backups/x/**", false, []string{
+			"/backups/x", "/backups/x/b.sst", "/backups/x/y", "/backups/x/y/c.sst", "/backups/x/y/c.txt",
+		}},
+		// Files below a matched directory are only listed once.
+		{"backups/x/**", true, []string{"/backups/x/b.sst", "/backups/x/y/c.sst", "/backups/x/y/c.txt"}},
+		{"missing/**/*.sst", false, nil},
+		{"backups/a.sst/**", false, nil},
+	} {
+		t.Run(fmt.Sprintf("%s/recursive=%t", tc.pattern, tc.recursive), func(t *testing.T) {
+			resp, err := service.List(ctx, &blobspb.GlobRequest{
+				Pattern: tc.pattern, Recursive: tc.recursive,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expected, resp.Files)
+		})
+	}
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.List(ctx, &blobspb.GlobRequest{Pattern: "../**/*.sst"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		storage := &cancellingReadDirStorage{memStorage: storage, cancel: cancel}
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+		_, err := service.List(ctx, &blobspb.GlobRequest{Pattern: "**/*.sst"})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		if storage.reads != 1 {
+			t.Fatalf("expected the walk to stop after 1 directory, read %d", storage.reads)
+		}
+	})
+}
+
+// cancellingReadDirStorage is a memStorage which cancels a context once it
+// has read a directory.
+type cancellingReadDirStorage struct {
+	*memStorage
+	cancel func()
+	reads  int
+}
+
+func (s *cancellingReadDirStorage) ReadDir(dir string) ([]os.FileInfo, error) {
+	s.reads++
+	s.cancel()
+	return s.memStorage.ReadDir(dir)
+}
+
+func TestBlobServiceListExclude(t *testing.T) {
+	storage := newMemStorage()
+	for _, file := range []string{
+		"backups/MANIFEST", "backups/data/1.sst", "backups/data/2.sst", "backups/data/MANIFEST-CHECKSUM",
+	} {
+		writeStorageFile(t, storage, file, []byte("content"))
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name      string
+		pattern   string
+		recursive bool
+		exclude   []string
+		expected  []string
+	}{
+		{"prefix", "backups", false, []string{"backups/**/MANIFEST*"},
+			[]string{"/backups/data/1.sst", "/backups/data/2.sst"}},
+		{"glob", "backups/data/*", false, []string{"backups/data/2.sst", "/backups/*/MANIFEST*"},
+			[]string{"/backups/data/1.sst"}},
+		{"recursive", "backups/*", true, []string{"**/*.sst"},
+			[]string{"/backups/MANIFEST", "/backups/data/MANIFEST-CHECKSUM"}},
+		{"no-match", "backups/data", false, []string{"other/**"},
+			[]string{"/backups/data/1.sst", "/backups/data/2.sst", "/backups/data/MANIFEST-CHECKSUM"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Page through the results one file at a time, so that exclusions
+			// are checked to apply before the page is cut.
+			var listed []string
+			var pageToken string
+			for {
+				resp, err := service.List(ctx, &blobspb.GlobRequest{
+					Pattern:   tc.pattern,
+					Recursive: tc.recursive,
+					Exclude:   tc.exclude,
+					PageSize:  1,
+					PageToken: pageToken,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				listed = append(listed, resp.Files...)
+				if resp.NextPageToken == "" {
+					break
+				}
+				pageToken = resp.NextPageToken
+			}
+			assert.Equal(t, tc.expected, listed)
+		})
+	}
+	t.Run("invalid-pattern", func(t *testing.T) {
+		_, err := service.List(ctx, &blobspb.GlobRequest{Pattern: "backups", Exclude: []string{"["}})
+		if !testutils.IsError(err, "invalid exclude pattern") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceListModifiedAfter(t *testing.T) {
+	storage := newMemStorage()
+	// The files are modified in the order they are listed in.
+	files := []string{"/dir/a.csv", "/dir/b.csv", "/dir/nested/c.csv", "/dir/nested/d.csv"}
+	for i, file := range files {
+		writeStorageFile(t, storage, file, []byte("content"))
+		storage.setModTime(file, timeutil.Unix(0, int64(i+1)))
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name          string
+		pattern       string
+		recursive     bool
+		modifiedAfter int64
+		expected      []string
+	}{
+		{"no-filter", "dir", true, 0, files},
+		{"prefix", "dir", false, 1, files[1:]},
+		{"glob", "dir/*.csv", false, 1, files[1:2]},
+		{"recursive", "dir/*", true, 2, files[2:]},
+		{"none", "dir", false, 4, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Page through the results one file at a time, so that the filter
+			// is checked to apply before the page is cut.
+			var listed []string
+			var pageToken string
+			for {
+				resp, err := service.List(ctx, &blobspb.GlobRequest{
+					Pattern:            tc.pattern,
+					Recursive:          tc.recursive,
+					ModifiedAfterNanos: tc.modifiedAfter,
+					PageSize:           1,
+					PageToken:          pageToken,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				listed = append(listed, resp.Files...)
+				if resp.NextPageToken == "" {
+					break
+				}
+				pageToken = resp.NextPageToken
+			}
+			assert.Equal(t, tc.expected, listed)
+		})
+	}
+}
+
 func TestBlobServiceDelete(t *testing.T) {
 	tmpDir, cleanupFn := testutils.TempDir(t)
 	defer cleanupFn()
@@ -75,7 +564,7 @@ func TestBlobServiceDelete(t *testing.T) {
 	filename := "path/to/file/content.txt"
 	writeTestFile(t, filepath.Join(tmpDir, filename), fileContent)
 
-	service, err := NewBlobService(tmpDir)
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -114,6 +603,203 @@ func TestBlobServiceDelete(t *testing.T) {
 			t.Fatal("incorrect error message: " + err.Error())
 		}
 	})
+	t.Run("delete-directory-not-recursive", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(tmpDir, "tree/a.txt"), fileContent)
+		_, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename: "tree",
+		})
+		if !errors.Is(err, ErrDirNotEmpty) {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("delete-directory-recursive", func(t *testing.T) {
+		for _, file := range []string{"tree/a.txt", "tree/b/c.txt", "tree/b/d/e.txt"} {
+			writeTestFile(t, filepath.Join(tmpDir, file), fileContent)
+		}
+		writeTestFile(t, filepath.Join(tmpDir, "tree-sibling.txt"), fileContent)
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename:  "tree",
+			Recursive: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "tree")); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "tree-sibling.txt")); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("delete-recursive-not-exist", func(t *testing.T) {
+		_, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename:  "file/does/not/exist",
+			Recursive: true,
+		})
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("delete-recursive-root", func(t *testing.T) {
+		for _, filename := range []string{"", ".", "path/.."} {
+			_, err := service.Delete(ctx, &blobspb.DeleteRequest{
+				Filename:  filename,
+				Recursive: true,
+			})
+			if !testutils.IsError(err, "deleting the external-io-dir is not allowed") {
+				t.Fatalf("%q: incorrect error message: %v", filename, err)
+			}
+		}
+	})
+	t.Run("delete-recursive-not-in-external-io-dir", func(t *testing.T) {
+		// A sibling of the external IO dir whose name has the external IO dir's
+		// name as a prefix.
+		sibling := tmpDir + "-sibling"
+		writeTestFile(t, filepath.Join(sibling, "content.txt"), fileContent)
+		defer func() { _ = os.RemoveAll(sibling) }()
+		for _, filename := range []string{
+			"file/../..",
+			filepath.Join("..", filepath.Base(sibling)),
+		} {
+			_, err := service.Delete(ctx, &blobspb.DeleteRequest{
+				Filename:  filename,
+				Recursive: true,
+			})
+			if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+				t.Fatalf("%q: incorrect error message: %v", filename, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(sibling, "content.txt")); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestBlobServiceDeleteRemoveEmptyParents(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+	root := filepath.Join(tmpDir, "root")
+	other := filepath.Join(tmpDir, "other")
+
+	service, err := NewBlobService(root, ServiceOptions{
+		AdditionalExternalIODirs: []string{other},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	deleteFile := func(t *testing.T, filename string) {
+		t.Helper()
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename:           filename,
+			RemoveEmptyParents: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectExists := func(t *testing.T, path string, exists bool) {
+		t.Helper()
+		_, err := os.Stat(path)
+		if exists && err != nil {
+			t.Fatalf("expected %s to exist, got %v", path, err)
+		}
+		if !exists && !oserror.IsNotExist(err) {
+			t.Fatalf("expected %s not to exist, got %v", path, err)
+		}
+	}
+
+	t.Run("stops-at-non-empty-directory", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(root, "backup/keep.txt"), []byte("content"))
+		writeTestFile(t, filepath.Join(root, "backup/a/b/c/file.txt"), []byte("content"))
+		deleteFile(t, "backup/a/b/c/file.txt")
+		expectExists(t, filepath.Join(root, "backup/a"), false)
+		expectExists(t, filepath.Join(root, "backup/keep.txt"), true)
+	})
+	t.Run("stops-at-root", func(t *testing.T) {
+		deleteFile(t, "backup/keep.txt")
+		writeTestFile(t, filepath.Join(root, "a/b/c/d/e/f/file.txt"), []byte("content"))
+		deleteFile(t, "a/b/c/d/e/f/file.txt")
+		expectExists(t, filepath.Join(root, "a"), false)
+		expectExists(t, filepath.Join(root, "backup"), false)
+		expectExists(t, root, true)
+		if entries, err := ioutil.ReadDir(root); err != nil || len(entries) != 0 {
+			t.Fatalf("expected the root to be empty, got %v, %v", entries, err)
+		}
+	})
+	t.Run("stops-at-additional-root", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(other, "a/b/c/file.txt"), []byte("content"))
+		deleteFile(t, "@1/a/b/c/file.txt")
+		expectExists(t, filepath.Join(other, "a"), false)
+		expectExists(t, other, true)
+	})
+	t.Run("directory", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(root, "a/b/c/file.txt"), []byte("content"))
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename:           "a/b",
+			Recursive:          true,
+			RemoveEmptyParents: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectExists(t, filepath.Join(root, "a"), false)
+		expectExists(t, root, true)
+	})
+}
+
+func TestBlobServiceDeleteBlobs(t *testing.T) {
+	storage := newMemStorage()
+	for _, filename := range []string{"a.txt", "dir/b.txt", "dir/c.txt"} {
+		writeStorageFile(t, storage, filename, []byte("content"))
+	}
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	resp, err := service.DeleteBlobs(ctx, &blobspb.BatchDeleteRequest{
+		Filenames: []string{"a.txt", "a.txt", "../outside.txt", "dir", "dir/b.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(resp.Results))
+	}
+	for i, tc := range []struct {
+		filename    string
+		expectedErr string
+	}{
+		{"a.txt", ""},
+		// Deleting the same file again fails without aborting the batch.
+		{"a.txt", "no such file"},
+		{"../outside.txt", "outside of external-io-dir is not allowed"},
+		{"dir", "directory not empty"},
+		{"dir/b.txt", ""},
+	} {
+		result := resp.Results[i]
+		if result.Filename != tc.filename {
+			t.Fatalf("result %d: expected %s, got %s", i, tc.filename, result.Filename)
+		}
+		if (tc.expectedErr == "") != (result.Error == "") ||
+			!strings.Contains(result.Error, tc.expectedErr) {
+			t.Fatalf("%s: incorrect error message: %q", tc.filename, result.Error)
+		}
+	}
+	for _, tc := range []struct {
+		filename string
+		exists   bool
+	}{
+		{"a.txt", false},
+		{"dir/b.txt", false},
+		{"dir/c.txt", true},
+	} {
+		exists, _, err := fileExists(storage, tc.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists != tc.exists {
+			t.Fatalf("%s: expected exists=%t, got %t", tc.filename, tc.exists, exists)
+		}
+	}
 }
 
 func TestBlobServiceStat(t *testing.T) {
@@ -124,7 +810,7 @@ func TestBlobServiceStat(t *testing.T) {
 	filename := "path/to/file/content.txt"
 	writeTestFile(t, filepath.Join(tmpDir, filename), fileContent)
 
-	service, err := NewBlobService(tmpDir)
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -141,6 +827,21 @@ func TestBlobServiceStat(t *testing.T) {
 			t.Fatalf("expected filesize: %d, got %d", len(fileContent), resp.Filesize)
 		}
 	})
+	t.Run("get-correct-mod-time", func(t *testing.T) {
+		modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		if err := os.Chtimes(filepath.Join(tmpDir, filename), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := service.Stat(ctx, &blobspb.StatRequest{
+			Filename: filename,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := timeutil.Unix(0, resp.ModTimeNanos); !got.Equal(modTime) {
+			t.Fatalf("expected mod time: %s, got %s", modTime, got)
+		}
+	})
 	t.Run("file-not-exist", func(t *testing.T) {
 		_, err := service.Stat(ctx, &blobspb.StatRequest{
 			Filename: "file/does/not/exist",
@@ -174,4 +875,3023 @@ func TestBlobServiceStat(t *testing.T) {
 			t.Fatal("incorrect error message: " + err.Error())
 		}
 	})
+	t.Run("stat-directory-allowed", func(t *testing.T) {
+		resp, err := service.Stat(ctx, &blobspb.StatRequest{
+			Filename: filepath.Dir(filename),
+			AllowDir: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.IsDir || resp.Filesize != 0 {
+			t.Fatalf("expected a directory with no size, got %+v", resp)
+		}
+	})
+	t.Run("stat-file-allow-dir", func(t *testing.T) {
+		resp, err := service.Stat(ctx, &blobspb.StatRequest{
+			Filename: filename,
+			AllowDir: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.IsDir || resp.Filesize != int64(len(fileContent)) {
+			t.Fatalf("expected a file of size %d, got %+v", len(fileContent), resp)
+		}
+	})
+}
+
+// testGetStreamServer is a blobspb.Blob_GetStreamServer which records the
+// chunks sent to it.
+type testGetStreamServer struct {
+	blobspb.Blob_GetStreamServer
+	ctx    context.Context
+	chunks [][]byte
+}
+
+func (s *testGetStreamServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testGetStreamServer) Send(chunk *blobspb.StreamChunk) error {
+	s.chunks = append(s.chunks, append([]byte(nil), chunk.Payload...))
+	return nil
+}
+
+func TestBlobServiceExists(t *testing.T) {
+	storage := newMemStorage()
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, []byte("file_content"))
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		filename string
+		exists   bool
+		isDir    bool
+	}{
+		{filename, true, false},
+		{"path/to/file", true, true},
+		{"path/to/file/missing.txt", false, false},
+		{"missing/dir/content.txt", false, false},
+	} {
+		resp, err := service.Exists(ctx, &blobspb.ExistsRequest{Filename: tc.filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Exists != tc.exists || resp.IsDir != tc.isDir {
+			t.Fatalf("%s: expected exists=%t is_dir=%t, got exists=%t is_dir=%t",
+				tc.filename, tc.exists, tc.isDir, resp.Exists, resp.IsDir)
+		}
+	}
+	t.Run("file-outside-extern-dir", func(t *testing.T) {
+		_, err := service.Exists(ctx, &blobspb.ExistsRequest{Filename: "../../content.txt"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceDiskUsage(t *testing.T) {
+	ctx := context.Background()
+	t.Run("mem", func(t *testing.T) {
+		storage := newMemStorage()
+		storage.setCapacity(100)
+		writeStorageFile(t, storage, "a.txt", make([]byte, 30))
+		writeStorageFile(t, storage, "dir/b.txt", make([]byte, 10))
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+
+		resp, err := service.DiskUsage(ctx, &blobspb.DiskUsageRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.CapacityBytes != 100 || resp.AvailableBytes != 60 || resp.UsedBytes != 40 {
+			t.Fatalf("expected 100 bytes of capacity, 60 available and 40 used, got %+v", resp)
+		}
+	})
+	t.Run("multiple-roots", func(t *testing.T) {
+		primary, other := newMemStorage(), newMemStorage()
+		primary.setCapacity(100)
+		other.setCapacity(50)
+		writeStorageFile(t, primary, "a.txt", make([]byte, 30))
+		writeStorageFile(t, other, "b.txt", make([]byte, 10))
+		storage := &multiRootStorage{roots: []Storage{primary, other}}
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+
+		for _, tc := range []struct {
+			path                      string
+			capacity, available, used uint64
+		}{
+			{"", 100, 70, 30},
+			{"backups/a", 100, 70, 30},
+			{"@1", 50, 40, 10},
+			{"@1/imports/b", 50, 40, 10},
+		} {
+			resp, err := service.DiskUsage(ctx, &blobspb.DiskUsageRequest{Path: tc.path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.CapacityBytes != tc.capacity || resp.AvailableBytes != tc.available ||
+				resp.UsedBytes != tc.used {
+				t.Fatalf("%q: expected %d bytes of capacity, %d available and %d used, got %+v",
+					tc.path, tc.capacity, tc.available, tc.used, resp)
+			}
+		}
+		_, err := service.DiskUsage(ctx, &blobspb.DiskUsageRequest{Path: "@2"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument error, got %v", err)
+		}
+	})
+	t.Run("local", func(t *testing.T) {
+		tmpDir, cleanupFn := testutils.TempDir(t)
+		defer cleanupFn()
+		service, err := NewBlobService(tmpDir, ServiceOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := service.DiskUsage(ctx, &blobspb.DiskUsageRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.CapacityBytes == 0 || resp.AvailableBytes > resp.CapacityBytes {
+			t.Fatalf("unexpected disk usage %+v", resp)
+		}
+	})
+}
+
+func TestBlobServiceGetStream(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	fileContent := []byte("0123456789")
+	filename := "path/to/file/content.txt"
+	writeTestFile(t, filepath.Join(tmpDir, filename), fileContent)
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	t.Run("default-chunk-size", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		if err := service.GetStream(&blobspb.GetRequest{Filename: filename}, stream); err != nil {
+			t.Fatal(err)
+		}
+		if len(stream.chunks) != 1 {
+			t.Fatalf("expected 1 chunk, got %d", len(stream.chunks))
+		}
+		if !bytes.Equal(stream.chunks[0], fileContent) {
+			t.Fatalf("expected %s, got %s", fileContent, stream.chunks[0])
+		}
+	})
+	t.Run("requested-chunk-size", func(t *testing.T) {
+		const requested = 8 << 10
+		largeContent := bytes.Repeat(fileContent, 2*requested/len(fileContent)+1)
+		writeTestFile(t, filepath.Join(tmpDir, "large.txt"), largeContent)
+		stream := &testGetStreamServer{ctx: ctx}
+		if err := service.GetStream(&blobspb.GetRequest{
+			Filename:  "large.txt",
+			ChunkSize: requested,
+		}, stream); err != nil {
+			t.Fatal(err)
+		}
+		if len(stream.chunks) != 3 {
+			t.Fatalf("expected 3 chunks, got %d", len(stream.chunks))
+		}
+		for _, chunk := range stream.chunks {
+			if len(chunk) > requested {
+				t.Fatalf("chunk of size %d exceeds requested size", len(chunk))
+			}
+		}
+		if content := bytes.Join(stream.chunks, nil); !bytes.Equal(content, largeContent) {
+			t.Fatalf("expected %d bytes, got %d", len(largeContent), len(content))
+		}
+	})
+	t.Run("offset-and-length", func(t *testing.T) {
+		for _, tc := range []struct {
+			offset, length int64
+			expected       string
+		}{
+			{0, 0, "0123456789"},
+			{3, 0, "3456789"},
+			{3, 4, "3456"},
+			{8, 4, "89"},
+			{10, 0, ""},
+		} {
+			stream := &testGetStreamServer{ctx: ctx}
+			if err := service.GetStream(&blobspb.GetRequest{
+				Filename: filename,
+				Offset:   tc.offset,
+				Length:   tc.length,
+			}, stream); err != nil {
+				t.Fatal(err)
+			}
+			if content := bytes.Join(stream.chunks, nil); string(content) != tc.expected {
+				t.Fatalf("offset %d, length %d: expected %q, got %q",
+					tc.offset, tc.length, tc.expected, content)
+			}
+		}
+	})
+	t.Run("gzip-compression", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		if err := service.GetStream(&blobspb.GetRequest{
+			Filename:    filename,
+			Compression: blobspb.Compression_GZIP,
+		}, stream); err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(bytes.NewReader(bytes.Join(stream.chunks, nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, fileContent) {
+			t.Fatalf("expected %s, got %s", fileContent, content)
+		}
+	})
+	t.Run("unsupported-compression", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		err := service.GetStream(&blobspb.GetRequest{
+			Filename:    filename,
+			Compression: blobspb.Compression(-1),
+		}, stream)
+		if !testutils.IsError(err, "unsupported compression") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("offset-past-eof", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		err := service.GetStream(&blobspb.GetRequest{Filename: filename, Offset: 11}, stream)
+		if !testutils.IsError(err, "past the end") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("file-not-exist", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		err := service.GetStream(&blobspb.GetRequest{Filename: "file/does/not/exist"}, stream)
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		if len(stream.chunks) != 0 {
+			t.Fatalf("expected no chunks to be sent, got %d", len(stream.chunks))
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		stream := &testGetStreamServer{ctx: ctx}
+		err := service.GetStream(&blobspb.GetRequest{Filename: "file/../../content.txt"}, stream)
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+// testPutStreamServer is a blobspb.Blob_PutStreamServer which hands out the
+// given chunks and then returns err, or io.EOF if err is nil.
+type testPutStreamServer struct {
+	blobspb.Blob_PutStreamServer
+	ctx    context.Context
+	chunks [][]byte
+	err    error
+}
+
+func newTestPutStreamServer(
+	ctx context.Context, filename string, chunks [][]byte, err error,
+) *testPutStreamServer {
+	return &testPutStreamServer{
+		ctx:    metadata.NewIncomingContext(ctx, metadata.Pairs("filename", filename)),
+		chunks: chunks,
+		err:    err,
+	}
+}
+
+func (s *testPutStreamServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testPutStreamServer) Recv() (*blobspb.StreamChunk, error) {
+	if len(s.chunks) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return &blobspb.StreamChunk{Payload: chunk}, nil
+}
+
+func (s *testPutStreamServer) SendAndClose(*blobspb.StreamResponse) error {
+	return nil
+}
+
+// testWriteBlobServer is a blobspb.Blob_WriteBlobServer which hands out the
+// given messages and then returns err, or io.EOF if err is nil.
+type testWriteBlobServer struct {
+	blobspb.Blob_WriteBlobServer
+	ctx  context.Context
+	msgs []*blobspb.WriteChunk
+	err  error
+	resp *blobspb.WriteResponse
+}
+
+func newTestWriteBlobServer(
+	ctx context.Context, filename string, chunks [][]byte, err error,
+) *testWriteBlobServer {
+	msgs := []*blobspb.WriteChunk{{Filename: filename}}
+	for _, chunk := range chunks {
+		msgs = append(msgs, &blobspb.WriteChunk{Payload: chunk})
+	}
+	return &testWriteBlobServer{ctx: ctx, msgs: msgs, err: err}
+}
+
+func (s *testWriteBlobServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testWriteBlobServer) Recv() (*blobspb.WriteChunk, error) {
+	if len(s.msgs) == 0 {
+		if s.err != nil {
+			return nil, s.err
+		}
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *testWriteBlobServer) SendAndClose(resp *blobspb.WriteResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestBlobServiceWriteBlob(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// expectNoFiles checks that dir, relative to the external IO dir, holds
+	// no file, not even a temporary one.
+	expectNoFiles := func(t *testing.T, dir string) {
+		entries, err := ioutil.ReadDir(filepath.Join(tmpDir, dir))
+		if err != nil && !oserror.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected no files to be left behind, found %d", len(entries))
+		}
+	}
+
+	t.Run("write", func(t *testing.T) {
+		filename := "path/to/file/content.txt"
+		stream := newTestWriteBlobServer(
+			ctx, filename, [][]byte{[]byte("file_"), []byte("content")}, nil,
+		)
+		if err := service.WriteBlob(stream); err != nil {
+			t.Fatal(err)
+		}
+		if stream.resp == nil || stream.resp.Filesize != int64(len("file_content")) {
+			t.Fatalf("expected a response with a size of %d, got %v", len("file_content"), stream.resp)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "file_content" {
+			t.Fatalf("expected file_content, got %s", b)
+		}
+		entries, err := ioutil.ReadDir(filepath.Join(tmpDir, filepath.Dir(filename)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("expected the temporary file to be renamed, found %d files", len(entries))
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		stream := newTestWriteBlobServer(ctx, "../outside.txt", [][]byte{[]byte("a")}, nil)
+		err := service.WriteBlob(stream)
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "..", "outside.txt")); !oserror.IsNotExist(err) {
+			t.Fatalf("expected no file outside of the external IO dir, got %v", err)
+		}
+	})
+	t.Run("no-filename", func(t *testing.T) {
+		err := service.WriteBlob(&testWriteBlobServer{ctx: ctx, msgs: []*blobspb.WriteChunk{
+			{Payload: []byte("a")},
+		}})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected an InvalidArgument error, got %v", err)
+		}
+	})
+	t.Run("empty-stream", func(t *testing.T) {
+		err := service.WriteBlob(&testWriteBlobServer{ctx: ctx})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected an InvalidArgument error, got %v", err)
+		}
+	})
+	t.Run("filename-in-later-message", func(t *testing.T) {
+		stream := newTestWriteBlobServer(ctx, "later/file.txt", [][]byte{[]byte("a")}, nil)
+		stream.msgs = append(stream.msgs, &blobspb.WriteChunk{Filename: "other.txt"})
+		err := service.WriteBlob(stream)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected an InvalidArgument error, got %v", err)
+		}
+		expectNoFiles(t, "later")
+	})
+	t.Run("stream-error", func(t *testing.T) {
+		streamErr := errors.New("stream broke")
+		stream := newTestWriteBlobServer(ctx, "broken/file.txt", [][]byte{[]byte("a")}, streamErr)
+		if err := service.WriteBlob(stream); !testutils.IsError(err, "stream broke") {
+			t.Fatalf("expected the stream error, got %v", err)
+		}
+		expectNoFiles(t, "broken")
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		stream := newTestWriteBlobServer(ctx, "cancelled/file.txt", [][]byte{[]byte("a")}, nil)
+		if err := service.WriteBlob(stream); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		expectNoFiles(t, "cancelled")
+	})
+}
+
+type testReadBlobServer struct {
+	blobspb.Blob_ReadBlobServer
+	ctx    context.Context
+	chunks [][]byte
+}
+
+func (s *testReadBlobServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testReadBlobServer) Send(chunk *blobspb.ReadChunk) error {
+	s.chunks = append(s.chunks, append([]byte(nil), chunk.Payload...))
+	return nil
+}
+
+func TestBlobServiceReadBlob(t *testing.T) {
+	storage := newMemStorage()
+	const fileSize = 5 << 20
+	filename := "path/to/file/content.bin"
+	writeStorageFile(t, storage, filename, make([]byte, fileSize))
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name              string
+		chunkSize         int32
+		expectedChunkSize int
+	}{
+		{"default", 0, defaultReadChunkSize},
+		{"negative", -1, defaultReadChunkSize},
+		{"explicit", 512 << 10, 512 << 10},
+		{"oversized", 1 << 30, maxChunkSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &testReadBlobServer{ctx: ctx}
+			if err := service.ReadBlob(&blobspb.ReadRequest{
+				Filename:  filename,
+				ChunkSize: tc.chunkSize,
+			}, stream); err != nil {
+				t.Fatal(err)
+			}
+			var total int
+			for i, chunk := range stream.chunks {
+				if i < len(stream.chunks)-1 && len(chunk) != tc.expectedChunkSize {
+					t.Fatalf("expected chunks of %d bytes, got %d", tc.expectedChunkSize, len(chunk))
+				}
+				total += len(chunk)
+			}
+			if total != fileSize {
+				t.Fatalf("expected %d bytes, got %d", fileSize, total)
+			}
+		})
+	}
+	t.Run("non-existent-file", func(t *testing.T) {
+		stream := &testReadBlobServer{ctx: ctx}
+		err := service.ReadBlob(&blobspb.ReadRequest{Filename: "missing.bin"}, stream)
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		if len(stream.chunks) != 0 {
+			t.Fatalf("expected no chunks to be sent, got %d", len(stream.chunks))
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		err := service.ReadBlob(
+			&blobspb.ReadRequest{Filename: "../outside.bin"}, &testReadBlobServer{ctx: ctx},
+		)
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceGetBlobs(t *testing.T) {
+	storage := newMemStorage()
+	writeStorageFile(t, storage, "a.txt", []byte("a_content"))
+	writeStorageFile(t, storage, "dir/b.txt", []byte("b_content"))
+	writeStorageFile(t, storage, "large.bin", make([]byte, maxBatchGetSize))
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	resp, err := service.GetBlobs(ctx, &blobspb.BatchGetRequest{
+		Filenames: []string{"a.txt", "missing.txt", "../outside.txt", "dir/b.txt", "large.bin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(resp.Results))
+	}
+	for i, tc := range []struct {
+		filename        string
+		expectedPayload string
+		expectedErr     string
+	}{
+		{filename: "a.txt", expectedPayload: "a_content"},
+		{filename: "missing.txt", expectedErr: "no such file"},
+		{filename: "../outside.txt", expectedErr: "outside of external-io-dir is not allowed"},
+		{filename: "dir/b.txt", expectedPayload: "b_content"},
+		// The files before it take up part of the batch.
+		{filename: "large.bin", expectedErr: "does not fit in the"},
+	} {
+		result := resp.Results[i]
+		if result.Filename != tc.filename {
+			t.Fatalf("result %d: expected %s, got %s", i, tc.filename, result.Filename)
+		}
+		if string(result.Payload) != tc.expectedPayload {
+			t.Fatalf("%s: expected payload %q, got %q", tc.filename, tc.expectedPayload, result.Payload)
+		}
+		if (tc.expectedErr == "") != (result.Error == "") ||
+			!strings.Contains(result.Error, tc.expectedErr) {
+			t.Fatalf("%s: incorrect error message: %q", tc.filename, result.Error)
+		}
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := service.GetBlobs(ctx, &blobspb.BatchGetRequest{Filenames: []string{"a.txt"}})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+	})
+}
+
+func TestBlobServiceGetStreamChunkSize(t *testing.T) {
+	storage := newMemStorage()
+	const fileSize = 5 << 20
+	filename := "path/to/file/content.bin"
+	writeStorageFile(t, storage, filename, make([]byte, fileSize))
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name              string
+		chunkSize         int32
+		expectedChunkSize int
+	}{
+		{"zero", 0, chunkSize},
+		{"negative", -1 << 20, chunkSize},
+		{"requested", 300 << 10, 300 << 10},
+		{"undersized", 1, minChunkSize},
+		{"oversized", 1 << 30, maxChunkSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &testGetStreamServer{ctx: ctx}
+			if err := service.GetStream(&blobspb.GetRequest{
+				Filename:  filename,
+				ChunkSize: tc.chunkSize,
+			}, stream); err != nil {
+				t.Fatal(err)
+			}
+			// Every chunk but the last one is full.
+			expectedChunks := (fileSize + tc.expectedChunkSize - 1) / tc.expectedChunkSize
+			if len(stream.chunks) != expectedChunks {
+				t.Fatalf("expected %d chunks, got %d", expectedChunks, len(stream.chunks))
+			}
+			for i, chunk := range stream.chunks[:len(stream.chunks)-1] {
+				if len(chunk) != tc.expectedChunkSize {
+					t.Fatalf("expected chunk %d to have %d bytes, got %d",
+						i, tc.expectedChunkSize, len(chunk))
+				}
+			}
+		})
+	}
+}
+
+func TestBlobServiceGetStreamChecksum(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("file_content")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	sha := sha256.Sum256(fileContent)
+	digest := hex.EncodeToString(sha[:])
+
+	t.Run("match", func(t *testing.T) {
+		for _, expected := range []string{digest, strings.ToUpper(digest)} {
+			stream := &testGetStreamServer{ctx: ctx}
+			if err := service.GetStream(&blobspb.GetRequest{
+				Filename:         filename,
+				ExpectedChecksum: expected,
+				Algorithm:        blobspb.ChecksumAlgorithm_SHA256,
+			}, stream); err != nil {
+				t.Fatal(err)
+			}
+			if content := bytes.Join(stream.chunks, nil); !bytes.Equal(content, fileContent) {
+				t.Fatalf("expected %s, got %s", fileContent, content)
+			}
+		}
+	})
+	t.Run("mismatch", func(t *testing.T) {
+		// The file rotted on disk after its checksum was recorded.
+		writeStorageFile(t, storage, "rotten.txt", []byte("file_c0ntent"))
+		err := service.GetStream(&blobspb.GetRequest{
+			Filename:         "rotten.txt",
+			ExpectedChecksum: digest,
+			Algorithm:        blobspb.ChecksumAlgorithm_SHA256,
+		}, &testGetStreamServer{ctx: ctx})
+		if status.Code(err) != codes.DataLoss || !errors.Is(err, ErrChecksumMismatch) {
+			t.Fatalf("expected a checksum mismatch, got %v", err)
+		}
+	})
+	t.Run("compressed", func(t *testing.T) {
+		// The checksum is the one of the file, not of the compressed stream.
+		if err := service.GetStream(&blobspb.GetRequest{
+			Filename:         filename,
+			Compression:      blobspb.Compression_GZIP,
+			ExpectedChecksum: digest,
+			Algorithm:        blobspb.ChecksumAlgorithm_SHA256,
+		}, &testGetStreamServer{ctx: ctx}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("partial-read", func(t *testing.T) {
+		for _, req := range []*blobspb.GetRequest{
+			{Filename: filename, Offset: 1, ExpectedChecksum: digest},
+			{Filename: filename, Length: 4, ExpectedChecksum: digest},
+		} {
+			err := service.GetStream(req, &testGetStreamServer{ctx: ctx})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("expected InvalidArgument error, got %v", err)
+			}
+		}
+	})
+	t.Run("unsupported-algorithm", func(t *testing.T) {
+		err := service.GetStream(&blobspb.GetRequest{
+			Filename:         filename,
+			ExpectedChecksum: digest,
+			Algorithm:        blobspb.ChecksumAlgorithm(42),
+		}, &testGetStreamServer{ctx: ctx})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected InvalidArgument error, got %v", err)
+		}
+	})
+}
+
+func TestBlobServicePutStream(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	chunks := [][]byte{[]byte("file_"), []byte("content")}
+
+	// expectEmptyDir checks that no file, temporary or otherwise, was left
+	// behind in dir.
+	expectEmptyDir := func(t *testing.T, dir string) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil && !oserror.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected no files in %s, found %d", dir, len(entries))
+		}
+	}
+
+	t.Run("write-correct-file", func(t *testing.T) {
+		filename := "path/to/file/content.txt"
+		if err := service.PutStream(newTestPutStreamServer(ctx, filename, chunks, nil)); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := bytes.Join(chunks, nil); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+	})
+	t.Run("stream-error-removes-temp-file", func(t *testing.T) {
+		filename := "stream/error/content.txt"
+		streamErr := errors.New("stream broke")
+		err := service.PutStream(newTestPutStreamServer(ctx, filename, chunks, streamErr))
+		if !errors.Is(err, streamErr) {
+			t.Fatalf("expected stream error, got %v", err)
+		}
+		expectEmptyDir(t, filepath.Join(tmpDir, filepath.Dir(filename)))
+	})
+	t.Run("cancelled-context-removes-temp-file", func(t *testing.T) {
+		filename := "cancelled/content.txt"
+		cancelledCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		err := service.PutStream(newTestPutStreamServer(cancelledCtx, filename, chunks, nil))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		expectEmptyDir(t, filepath.Join(tmpDir, filepath.Dir(filename)))
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		err := service.PutStream(newTestPutStreamServer(ctx, "file/../../content.txt", chunks, nil))
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("gzip-compression", func(t *testing.T) {
+		filename := "compressed/content.txt"
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(bytes.Join(chunks, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		stream := newTestPutStreamServer(ctx, filename, [][]byte{compressed.Bytes()}, nil)
+		stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+			"filename", filename, "compression", blobspb.Compression_GZIP.String()))
+		if err := service.PutStream(stream); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := bytes.Join(chunks, nil); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+	})
+	t.Run("decompression-bomb", func(t *testing.T) {
+		limited := NewBlobServiceWithStorage(service.storage, ServiceOptions{
+			MaxDecompressedBytes: 1 << 20,
+		})
+		filename := "bomb/content.txt"
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		if _, err := gz.Write(make([]byte, 64<<20)); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		stream := newTestPutStreamServer(ctx, filename, [][]byte{compressed.Bytes()}, nil)
+		stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+			"filename", filename, "compression", blobspb.Compression_GZIP.String()))
+		err := limited.PutStream(stream)
+		if !testutils.IsError(err, "decompressed payload exceeds the maximum of 1048576 bytes") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		expectEmptyDir(t, filepath.Join(tmpDir, filepath.Dir(filename)))
+	})
+	t.Run("unsupported-compression", func(t *testing.T) {
+		filename := "unsupported/content.txt"
+		stream := newTestPutStreamServer(ctx, filename, chunks, nil)
+		stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+			"filename", filename, "compression", "ZSTD"))
+		if err := service.PutStream(stream); !testutils.IsError(err, "unsupported compression") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		expectEmptyDir(t, filepath.Join(tmpDir, filepath.Dir(filename)))
+	})
+	t.Run("no-filename", func(t *testing.T) {
+		err := service.PutStream(newTestPutStreamServer(ctx, "", chunks, nil))
+		if !testutils.IsError(err, "no filename in metadata") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("mode", func(t *testing.T) {
+		for _, tc := range []struct {
+			mode     string
+			expected os.FileMode
+		}{
+			{"", 0600},
+			{"0640", 0640},
+			{"0755", 0755},
+		} {
+			filename := "mode/" + tc.mode + "content.txt"
+			stream := newTestPutStreamServer(ctx, filename, chunks, nil)
+			stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+				"filename", filename, "mode", tc.mode))
+			if err := service.PutStream(stream); err != nil {
+				t.Fatal(err)
+			}
+			fi, err := os.Stat(filepath.Join(tmpDir, filename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fi.Mode().Perm() != tc.expected {
+				t.Fatalf("%q: expected mode %s, got %s", tc.mode, tc.expected, fi.Mode().Perm())
+			}
+		}
+	})
+	t.Run("if-not-exists", func(t *testing.T) {
+		filename := "if-not-exists/content.txt"
+		putIfNotExists := func(content string) error {
+			stream := newTestPutStreamServer(ctx, filename, [][]byte{[]byte(content)}, nil)
+			stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+				"filename", filename, "if-not-exists", "true"))
+			return service.PutStream(stream)
+		}
+		if err := putIfNotExists("first"); err != nil {
+			t.Fatal(err)
+		}
+		err := putIfNotExists("second")
+		if status.Code(err) != codes.AlreadyExists {
+			t.Fatalf("expected AlreadyExists error, got %v", err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "first" {
+			t.Fatalf("expected the file not to be overwritten, got %s", content)
+		}
+		entries, err := ioutil.ReadDir(filepath.Join(tmpDir, filepath.Dir(filename)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("expected no temporary files to be left behind, found %d files", len(entries))
+		}
+	})
+	t.Run("invalid-mode", func(t *testing.T) {
+		for _, mode := range []string{"rw-r--r--", "04755"} {
+			filename := "invalid-mode/content.txt"
+			stream := newTestPutStreamServer(ctx, filename, chunks, nil)
+			stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+				"filename", filename, "mode", mode))
+			if err := service.PutStream(stream); !testutils.IsError(err, "invalid file mode") {
+				t.Fatalf("%q: incorrect error message: %v", mode, err)
+			}
+		}
+	})
+	t.Run("sync", func(t *testing.T) {
+		putWithSync := func(filename, sync string) error {
+			stream := newTestPutStreamServer(ctx, filename, chunks, nil)
+			stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+				"filename", filename, "sync", sync))
+			return service.PutStream(stream)
+		}
+		filename := "sync/content.txt"
+		if err := putWithSync(filename, "true"); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := bytes.Join(chunks, nil); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+		if err := putWithSync(filename, "sometimes"); !testutils.IsError(err, "invalid sync value") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("expected-size", func(t *testing.T) {
+		putWithSize := func(filename, expectedSize string) error {
+			stream := newTestPutStreamServer(ctx, filename, chunks, nil)
+			stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+				"filename", filename, "expected-size", expectedSize))
+			return service.PutStream(stream)
+		}
+		size := len(bytes.Join(chunks, nil))
+		if err := putWithSize("sized/content.txt", strconv.Itoa(size)); err != nil {
+			t.Fatal(err)
+		}
+		for _, expectedSize := range []int{size + 1, size - 1} {
+			filename := "mismatch/content.txt"
+			err := putWithSize(filename, strconv.Itoa(expectedSize))
+			if status.Code(err) != codes.DataLoss {
+				t.Fatalf("expected %d: expected DataLoss error, got %v", expectedSize, err)
+			}
+			expectEmptyDir(t, filepath.Join(tmpDir, filepath.Dir(filename)))
+		}
+	})
+}
+
+func TestBlobServiceAppendBlob(t *testing.T) {
+	storage := newMemStorage()
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+	filename := "path/to/file/content.txt"
+
+	t.Run("append-creates-and-extends-file", func(t *testing.T) {
+		var expected []byte
+		for _, payload := range []string{"first,", "second"} {
+			resp, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+				Filename: filename,
+				Payload:  []byte(payload),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, payload...)
+			if resp.Filesize != int64(len(expected)) {
+				t.Fatalf("expected filesize: %d, got %d", len(expected), resp.Filesize)
+			}
+		}
+		if content := readStorageFile(t, storage, filename); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+	})
+	t.Run("append-to-directory", func(t *testing.T) {
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: filepath.Dir(filename),
+			Payload:  []byte("a"),
+		})
+		if !testutils.IsError(err, "expected a file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "file/../../content.txt",
+			Payload:  []byte("a"),
+		})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceWriteBlobAt(t *testing.T) {
+	storage := newMemStorage()
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+	filename := "path/to/file/content.txt"
+
+	writeAt := func(offset int64, payload string) (*blobspb.WriteAtResponse, error) {
+		return service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: filename,
+			Offset:   offset,
+			Payload:  []byte(payload),
+		})
+	}
+
+	t.Run("write-past-end-fills-gap", func(t *testing.T) {
+		resp, err := writeAt(4, "cd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Filesize != 6 {
+			t.Fatalf("expected filesize: 6, got %d", resp.Filesize)
+		}
+		expected := []byte("\x00\x00\x00\x00cd")
+		if content := readStorageFile(t, storage, filename); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %q, got %q", expected, content)
+		}
+	})
+	t.Run("write-leaves-rest-of-file", func(t *testing.T) {
+		resp, err := writeAt(1, "ab")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Filesize != 6 {
+			t.Fatalf("expected filesize: 6, got %d", resp.Filesize)
+		}
+		expected := []byte("\x00ab\x00cd")
+		if content := readStorageFile(t, storage, filename); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %q, got %q", expected, content)
+		}
+	})
+	t.Run("negative-offset", func(t *testing.T) {
+		_, err := writeAt(-1, "a")
+		if !testutils.IsError(err, "negative offset") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("write-to-directory", func(t *testing.T) {
+		_, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: filepath.Dir(filename),
+			Payload:  []byte("a"),
+		})
+		if !testutils.IsError(err, "expected a file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: "file/../../content.txt",
+			Payload:  []byte("a"),
+		})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceCopyBlob(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("file_content")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	expectContent := func(t *testing.T, filename string, expected []byte) {
+		if content := readStorageFile(t, storage, filename); !bytes.Equal(content, expected) {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+	}
+
+	t.Run("copy-to-new-directory", func(t *testing.T) {
+		destination := "new/dir/copy.txt"
+		if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+			Source:      filename,
+			Destination: destination,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, destination, fileContent)
+		expectContent(t, filename, fileContent)
+	})
+	t.Run("copy-overwrites-destination", func(t *testing.T) {
+		destination := "existing.txt"
+		writeStorageFile(t, storage, destination, []byte("old_content_which_is_longer"))
+		if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+			Source:      filename,
+			Destination: destination,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectContent(t, destination, fileContent)
+	})
+	t.Run("source-not-exist", func(t *testing.T) {
+		_, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+			Source:      "file/does/not/exist",
+			Destination: "copy.txt",
+		})
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		for _, req := range []*blobspb.CopyRequest{
+			{Source: "file/../../content.txt", Destination: "copy.txt"},
+			{Source: filename, Destination: "file/../../copy.txt"},
+		} {
+			_, err := service.CopyBlob(ctx, req)
+			if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+				t.Fatalf("incorrect error message: %v", err)
+			}
+		}
+	})
+}
+
+type testCopyProgressServer struct {
+	blobspb.Blob_CopyBlobProgressServer
+	ctx  context.Context
+	msgs []*blobspb.CopyProgress
+}
+
+func (s *testCopyProgressServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testCopyProgressServer) Send(msg *blobspb.CopyProgress) error {
+	s.msgs = append(s.msgs, msg)
+	return nil
+}
+
+func TestBlobServiceCopyBlobProgress(t *testing.T) {
+	defer func(d time.Duration) { copyProgressInterval = d }(copyProgressInterval)
+	copyProgressInterval = 0
+
+	storage := newMemStorage()
+	fileContent := bytes.Repeat([]byte("0123456789"), 10000)
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	t.Run("copy", func(t *testing.T) {
+		stream := &testCopyProgressServer{ctx: ctx}
+		if err := service.CopyBlobProgress(&blobspb.CopyRequest{
+			Source:      filename,
+			Destination: "copy.txt",
+		}, stream); err != nil {
+			t.Fatal(err)
+		}
+		if content := readStorageFile(t, storage, "copy.txt"); !bytes.Equal(content, fileContent) {
+			t.Fatalf("expected the copy to match the source, got %d bytes", len(content))
+		}
+		size := int64(len(fileContent))
+		if len(stream.msgs) < 2 {
+			t.Fatalf("expected progress messages followed by a final one, got %v", stream.msgs)
+		}
+		var copied int64
+		for _, msg := range stream.msgs[:len(stream.msgs)-1] {
+			if msg.Done || msg.TotalBytes != size ||
+				msg.BytesCopied <= copied || msg.BytesCopied > size {
+				t.Fatalf("unexpected progress message %v after %d bytes", msg, copied)
+			}
+			copied = msg.BytesCopied
+		}
+		final := stream.msgs[len(stream.msgs)-1]
+		if !final.Done || final.BytesCopied != size || final.TotalBytes != size {
+			t.Fatalf("unexpected final message %v", final)
+		}
+	})
+	t.Run("source-not-exist", func(t *testing.T) {
+		stream := &testCopyProgressServer{ctx: ctx}
+		err := service.CopyBlobProgress(&blobspb.CopyRequest{
+			Source:      "file/does/not/exist",
+			Destination: "copy.txt",
+		}, stream)
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+		if len(stream.msgs) != 0 {
+			t.Fatalf("expected no progress messages, got %v", stream.msgs)
+		}
+	})
+}
+
+func TestBlobServiceMoveBlob(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	fileContent := []byte("file_content")
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	testMove := func(t *testing.T) {
+		source, destination := "path/to/file/content.txt", "new/dir/moved.txt"
+		writeTestFile(t, filepath.Join(tmpDir, source), fileContent)
+		if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      source,
+			Destination: destination,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(tmpDir, destination))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(content, fileContent) {
+			t.Fatalf("expected %s, got %s", fileContent, content)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, source)); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	}
+
+	t.Run("move-same-filesystem", testMove)
+	t.Run("move-across-filesystems", func(t *testing.T) {
+		defer func(f func(string, string) error) { renameFile = f }(renameFile)
+		renameFile = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		testMove(t)
+	})
+	t.Run("move-directory-across-filesystems", func(t *testing.T) {
+		defer func(f func(string, string) error) { renameFile = f }(renameFile)
+		renameFile = func(oldpath, newpath string) error {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+		}
+		source := "dir/to/move"
+		writeTestFile(t, filepath.Join(tmpDir, source, "content.txt"), fileContent)
+		_, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      source,
+			Destination: "moved/dir",
+		})
+		if !testutils.IsError(err, "cannot move directory") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, source, "content.txt")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "moved/dir")); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	})
+	t.Run("source-not-exist", func(t *testing.T) {
+		_, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      "file/does/not/exist",
+			Destination: "moved.txt",
+		})
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		for _, req := range []*blobspb.MoveRequest{
+			{Source: "file/../../content.txt", Destination: "moved.txt"},
+			{Source: "content.txt", Destination: "file/../../moved.txt"},
+		} {
+			_, err := service.MoveBlob(ctx, req)
+			if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+				t.Fatalf("incorrect error message: %v", err)
+			}
+		}
+	})
+}
+
+// crossDeviceStorage is a memStorage which cannot rename atomically, and
+// which fails to delete the files in undeletable.
+type crossDeviceStorage struct {
+	*memStorage
+	undeletable map[string]bool
+}
+
+func (s crossDeviceStorage) Rename(source, destination string) error {
+	return errors.Mark(errors.New("cross-device rename"), ErrCrossDevice)
+}
+
+func (s crossDeviceStorage) Delete(filename string) error {
+	if s.undeletable[filename] {
+		return &os.PathError{Op: "remove", Path: filename, Err: syscall.EACCES}
+	}
+	return s.memStorage.Delete(filename)
+}
+
+func TestBlobServiceMoveBlobFallback(t *testing.T) {
+	storage := crossDeviceStorage{
+		memStorage:  newMemStorage(),
+		undeletable: map[string]bool{"undeletable.txt": true},
+	}
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	t.Run("copy-and-delete", func(t *testing.T) {
+		writeStorageFile(t, storage, "content.txt", []byte("a"))
+		if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      "content.txt",
+			Destination: "moved/content.txt",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []byte("a"), readStorageFile(t, storage, "moved/content.txt"))
+		if _, err := storage.FileInfo("content.txt"); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	})
+	t.Run("source-not-deleted", func(t *testing.T) {
+		writeStorageFile(t, storage, "undeletable.txt", []byte("a"))
+		_, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      "undeletable.txt",
+			Destination: "moved/undeletable.txt",
+		})
+		if !testutils.IsError(err, "permission denied") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		// The file must not be left in both places.
+		if _, err := storage.FileInfo("moved/undeletable.txt"); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceMkdir(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	filename := "path/to/file/content.txt"
+	writeTestFile(t, filepath.Join(tmpDir, filename), []byte("file_content"))
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	t.Run("create-directory", func(t *testing.T) {
+		dir := "export/dir/nested"
+		// Creating the directory a second time must succeed too.
+		for i := 0; i < 2; i++ {
+			if _, err := service.Mkdir(ctx, &blobspb.MkdirRequest{Path: dir}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fi, err := os.Stat(filepath.Join(tmpDir, dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.IsDir() {
+			t.Fatalf("expected %s to be a directory", dir)
+		}
+	})
+	t.Run("existing-file", func(t *testing.T) {
+		_, err := service.Mkdir(ctx, &blobspb.MkdirRequest{Path: filename})
+		if !testutils.IsError(err, "a file with that name already exists") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.Mkdir(ctx, &blobspb.MkdirRequest{Path: "dir/../../outside"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceTouch(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	filename := "path/to/file/content.txt"
+	writeTestFile(t, filepath.Join(tmpDir, filename), []byte("file_content"))
+	// Backdate the file, so that updating its modification time is visible.
+	past := timeutil.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(filepath.Join(tmpDir, filename), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	stat := func(t *testing.T, filename string) os.FileInfo {
+		t.Helper()
+		fi, err := os.Stat(filepath.Join(tmpDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi
+	}
+
+	t.Run("create-file", func(t *testing.T) {
+		marker := "backup/dir/COMPLETE"
+		if _, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: marker}); err != nil {
+			t.Fatal(err)
+		}
+		if fi := stat(t, marker); fi.IsDir() || fi.Size() != 0 {
+			t.Fatalf("expected an empty file, got %v", fi)
+		}
+	})
+	t.Run("existing-file", func(t *testing.T) {
+		if _, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: filename}); err != nil {
+			t.Fatal(err)
+		}
+		fi := stat(t, filename)
+		if fi.Size() != int64(len("file_content")) || !fi.ModTime().Equal(past) {
+			t.Fatalf("expected the file to be left untouched, got size %d and mod time %s",
+				fi.Size(), fi.ModTime())
+		}
+
+		if _, err := service.Touch(ctx, &blobspb.TouchRequest{
+			Filename: filename, UpdateModTime: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		fi = stat(t, filename)
+		if fi.Size() != int64(len("file_content")) || !fi.ModTime().After(past) {
+			t.Fatalf("expected the mod time to be updated, got size %d and mod time %s",
+				fi.Size(), fi.ModTime())
+		}
+	})
+	t.Run("directory", func(t *testing.T) {
+		_, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: filepath.Dir(filename)})
+		if !testutils.IsError(err, "expected a file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: "dir/../../outside"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceTruncateBlob(t *testing.T) {
+	storage := newMemStorage()
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		size     int64
+		expected []byte
+	}{
+		{"shrink", 4, []byte("0123")},
+		{"same-size", 10, []byte("0123456789")},
+		{"zero-extend", 13, []byte("0123456789\x00\x00\x00")},
+		{"empty", 0, []byte{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := "truncate/" + tc.name + ".txt"
+			writeStorageFile(t, storage, filename, []byte("0123456789"))
+			if _, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{
+				Filename: filename,
+				Size:     tc.size,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if content := readStorageFile(t, storage, filename); !bytes.Equal(content, tc.expected) {
+				t.Fatalf("expected %q, got %q", tc.expected, content)
+			}
+		})
+	}
+	t.Run("non-existent-file", func(t *testing.T) {
+		_, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{Filename: "missing.txt"})
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected NotFound error, got %v", err)
+		}
+	})
+	t.Run("negative-size", func(t *testing.T) {
+		filename := "truncate/negative.txt"
+		writeStorageFile(t, storage, filename, []byte("0123456789"))
+		_, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{Filename: filename, Size: -1})
+		if !testutils.IsError(err, "negative size") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{Filename: "../outside.txt"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceChecksum(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("file_content")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+
+	crc := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc, crc32.Checksum(fileContent, crc32.MakeTable(crc32.Castagnoli)))
+	sha := sha256.Sum256(fileContent)
+
+	for _, tc := range []struct {
+		algorithm blobspb.ChecksumAlgorithm
+		expected  string
+	}{
+		{blobspb.ChecksumAlgorithm_CRC32C, hex.EncodeToString(crc)},
+		{blobspb.ChecksumAlgorithm_SHA256, hex.EncodeToString(sha[:])},
+	} {
+		t.Run(tc.algorithm.String(), func(t *testing.T) {
+			resp, err := service.Checksum(ctx, &blobspb.ChecksumRequest{
+				Filename:  filename,
+				Algorithm: tc.algorithm,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Digest != tc.expected {
+				t.Fatalf("expected digest %s, got %s", tc.expected, resp.Digest)
+			}
+		})
+	}
+	t.Run("unsupported-algorithm", func(t *testing.T) {
+		_, err := service.Checksum(ctx, &blobspb.ChecksumRequest{
+			Filename:  filename,
+			Algorithm: blobspb.ChecksumAlgorithm(-1),
+		})
+		if !testutils.IsError(err, "unsupported checksum algorithm") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("file-not-exist", func(t *testing.T) {
+		_, err := service.Checksum(ctx, &blobspb.ChecksumRequest{
+			Filename: "file/does/not/exist",
+		})
+		if !testutils.IsError(err, "no such file") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.Checksum(ctx, &blobspb.ChecksumRequest{
+			Filename: "file/../../content.txt",
+		})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceDiskSpaceCheck(t *testing.T) {
+	ctx := context.Background()
+	putStream := func(filename, expectedSize string, payload []byte) *testPutStreamServer {
+		stream := newTestPutStreamServer(ctx, filename, [][]byte{payload}, nil)
+		stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+			"filename", filename, "expected-size", expectedSize))
+		return stream
+	}
+
+	t.Run("check", func(t *testing.T) {
+		storage := newMemStorage()
+		storage.setCapacity(10)
+		writeStorageFile(t, storage, "existing.txt", []byte("0123"))
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "existing.txt", Payload: []byte("4567890"),
+		})
+		if status.Code(err) != codes.ResourceExhausted ||
+			!testutils.IsError(err, "insufficient disk space") {
+			t.Fatalf("expected insufficient disk space error, got %v", err)
+		}
+		if content := readStorageFile(t, storage, "existing.txt"); string(content) != "0123" {
+			t.Fatalf("expected the file to be left untouched, got %q", content)
+		}
+		if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "existing.txt", Payload: []byte("456789"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		storage.setCapacity(20)
+		err = service.PutStream(putStream("put.txt", "11", []byte("0123456789a")))
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected insufficient disk space error, got %v", err)
+		}
+		if exists, _, err := fileExists(storage, "put.txt"); err != nil || exists {
+			t.Fatalf("expected no file to be written, got exists=%t, err=%v", exists, err)
+		}
+		if err := service.PutStream(putStream("put.txt", "10", []byte("0123456789"))); err != nil {
+			t.Fatal(err)
+		}
+		// The check is only done when the size of the file is declared.
+		if err := service.PutStream(
+			newTestPutStreamServer(ctx, "undeclared.txt", [][]byte{[]byte("0123456789")}, nil),
+		); err != nil {
+			t.Fatal(err)
+		}
+		err = service.PutStream(putStream("invalid.txt", "-1", []byte("0")))
+		if !testutils.IsError(err, "invalid expected-size") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+	t.Run("multiple-roots", func(t *testing.T) {
+		// The free space is checked on the root the file is written to, which
+		// here is the only one without enough space.
+		primary, other := newMemStorage(), newMemStorage()
+		primary.setCapacity(100)
+		other.setCapacity(5)
+		storage := &multiRootStorage{roots: []Storage{primary, other}}
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+
+		err := service.PutStream(putStream("@1/put.txt", "10", []byte("0123456789")))
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected insufficient disk space error, got %v", err)
+		}
+		_, err = service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: "@1/write.txt", Payload: []byte("0123456789"),
+		})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected insufficient disk space error, got %v", err)
+		}
+		if err := service.PutStream(putStream("put.txt", "10", []byte("0123456789"))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "@1/append.txt", Payload: []byte("01234"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("skip", func(t *testing.T) {
+		storage := newMemStorage()
+		storage.setCapacity(10)
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{SkipDiskSpaceCheck: true})
+		if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "file.txt", Payload: []byte("0123456789a"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.PutStream(putStream("put.txt", "11", []byte("0123456789a"))); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestBlobServiceMaxFileSize(t *testing.T) {
+	ctx := context.Background()
+	storage := newMemStorage()
+	writeStorageFile(t, storage, "existing.txt", []byte("0123"))
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{MaxFileSize: 10})
+
+	expectTooLarge := func(t *testing.T, err error) {
+		t.Helper()
+		if status.Code(err) != codes.ResourceExhausted || !errors.Is(err, ErrFileTooLarge) {
+			t.Fatalf("expected a file too large error, got %v", err)
+		}
+	}
+	expectContent := func(t *testing.T, filename, expected string) {
+		t.Helper()
+		if content := readStorageFile(t, storage, filename); string(content) != expected {
+			t.Fatalf("expected %q, got %q", expected, content)
+		}
+	}
+
+	t.Run("put", func(t *testing.T) {
+		// The write fails as soon as the file grows too large, without waiting
+		// for the rest of the stream.
+		stream := newTestPutStreamServer(ctx, "existing.txt", [][]byte{
+			[]byte("abcdef"), []byte("ghijkl"), []byte("mnopqr"),
+		}, nil)
+		expectTooLarge(t, service.PutStream(stream))
+		if len(stream.chunks) == 0 {
+			t.Fatal("expected the write to fail before the end of the stream")
+		}
+		expectContent(t, "existing.txt", "0123")
+
+		stream = newTestPutStreamServer(ctx, "declared.txt", [][]byte{[]byte("0")}, nil)
+		stream.ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(
+			"filename", "declared.txt", "expected-size", "11"))
+		expectTooLarge(t, service.PutStream(stream))
+		if exists, _, err := fileExists(storage, "declared.txt"); err != nil || exists {
+			t.Fatalf("expected no file to be written, got exists=%t, err=%v", exists, err)
+		}
+
+		if err := service.PutStream(
+			newTestPutStreamServer(ctx, "max.txt", [][]byte{[]byte("0123456789")}, nil),
+		); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("append", func(t *testing.T) {
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "existing.txt", Payload: []byte("4567890"),
+		})
+		expectTooLarge(t, err)
+		expectContent(t, "existing.txt", "0123")
+		if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "existing.txt", Payload: []byte("456789"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("write-at", func(t *testing.T) {
+		_, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: "write-at.txt", Offset: 8, Payload: []byte("abc"),
+		})
+		expectTooLarge(t, err)
+		if _, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+			Filename: "write-at.txt", Offset: 8, Payload: []byte("ab"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("truncate", func(t *testing.T) {
+		_, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{
+			Filename: "max.txt", Size: 11,
+		})
+		expectTooLarge(t, err)
+		expectContent(t, "max.txt", "0123456789")
+	})
+}
+
+// auditInterceptor collects the blob storage events logged while it
+// intercepts the logs.
+type auditInterceptor struct {
+	syncutil.Mutex
+	events []string
+}
+
+func (i *auditInterceptor) Intercept(entry []byte) {
+	var e logpb.Entry
+	if err := json.Unmarshal(entry, &e); err != nil {
+		panic(err)
+	}
+	if e.Channel != logpb.Channel_OPS || e.StructuredEnd == 0 {
+		return
+	}
+	event := redact.RedactableString(e.Message[e.StructuredStart:e.StructuredEnd]).StripMarkers()
+	if !strings.Contains(event, `"EventType":"blob_`) {
+		return
+	}
+	i.Lock()
+	defer i.Unlock()
+	i.events = append(i.events, event)
+}
+
+func (i *auditInterceptor) take() []string {
+	i.Lock()
+	defer i.Unlock()
+	events := i.events
+	i.events = nil
+	return events
+}
+
+func TestBlobServiceAuditLog(t *testing.T) {
+	storage := newMemStorage()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 26257},
+	})
+	interceptor := &auditInterceptor{}
+	defer log.InterceptWith(ctx, interceptor)()
+
+	// expectEvents checks that the events logged since the last call contain
+	// the given fields, in order.
+	expectEvents := func(t *testing.T, expected ...[]string) {
+		t.Helper()
+		events := interceptor.take()
+		if len(events) != len(expected) {
+			t.Fatalf("expected %d events, got %d: %v", len(expected), len(events), events)
+		}
+		for i, fields := range expected {
+			for _, field := range fields {
+				if !strings.Contains(events[i], field) {
+					t.Errorf("expected %s in event %s", field, events[i])
+				}
+			}
+		}
+	}
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{AuditLog: true})
+
+	t.Run("delete", func(t *testing.T) {
+		writeStorageFile(t, storage, "file.txt", []byte("hello"))
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "file.txt"}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, []string{
+			`"EventType":"blob_delete"`, `"Caller":"10.0.0.1:26257"`,
+			`"Path":"file.txt"`, `"Bytes":5`,
+		})
+	})
+	t.Run("delete-recursive", func(t *testing.T) {
+		writeStorageFile(t, storage, "dir/a.txt", []byte("hello"))
+		writeStorageFile(t, storage, "dir/sub/b.txt", []byte("world!"))
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{
+			Filename: "dir", Recursive: true,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, []string{
+			`"EventType":"blob_delete"`, `"Path":"dir"`, `"Bytes":11`, `"Recursive":true`,
+		})
+	})
+	t.Run("delete-blobs", func(t *testing.T) {
+		writeStorageFile(t, storage, "a.txt", []byte("hello"))
+		resp, err := service.DeleteBlobs(ctx, &blobspb.BatchDeleteRequest{
+			Filenames: []string{"a.txt", "missing.txt"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Results[1].Error == "" {
+			t.Fatal("expected deleting a missing file to fail")
+		}
+		// The failed deletion is not reported.
+		expectEvents(t, []string{`"EventType":"blob_delete"`, `"Path":"a.txt"`, `"Bytes":5`})
+	})
+	t.Run("move", func(t *testing.T) {
+		writeStorageFile(t, storage, "source.txt", []byte("hello"))
+		if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source: "source.txt", Destination: "moved/dest.txt",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, []string{
+			`"EventType":"blob_move"`, `"Path":"source.txt"`, `"Bytes":5`,
+			`"Destination":"moved/dest.txt"`,
+		})
+	})
+	t.Run("copy", func(t *testing.T) {
+		writeStorageFile(t, storage, "source.txt", []byte("hello"))
+		// A copy to a new file replaces nothing.
+		if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+			Source: "source.txt", Destination: "copy.txt",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t)
+
+		writeStorageFile(t, storage, "copy.txt", []byte("old_content"))
+		if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+			Source: "source.txt", Destination: "copy.txt",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t, []string{
+			`"EventType":"blob_overwrite"`, `"Path":"copy.txt"`, `"Bytes":11`,
+		})
+	})
+	t.Run("disabled", func(t *testing.T) {
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+		writeStorageFile(t, storage, "file.txt", []byte("hello"))
+		if _, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "file.txt"}); err != nil {
+			t.Fatal(err)
+		}
+		expectEvents(t)
+	})
+}
+
+func TestBlobServiceReadOnly(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("file_content")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{ReadOnly: true})
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name string
+		rpc  func() error
+	}{
+		{"put", func() error {
+			return service.PutStream(
+				newTestPutStreamServer(ctx, filename, [][]byte{[]byte("new")}, nil),
+			)
+		}},
+		{"write", func() error {
+			return service.WriteBlob(
+				newTestWriteBlobServer(ctx, filename, [][]byte{[]byte("new")}, nil),
+			)
+		}},
+		{"append", func() error {
+			_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+				Filename: filename, Payload: []byte("new"),
+			})
+			return err
+		}},
+		{"write-at", func() error {
+			_, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+				Filename: filename, Payload: []byte("new"),
+			})
+			return err
+		}},
+		{"copy", func() error {
+			_, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+				Source: filename, Destination: "copy.txt",
+			})
+			return err
+		}},
+		{"copy-progress", func() error {
+			return service.CopyBlobProgress(
+				&blobspb.CopyRequest{Source: filename, Destination: "copy.txt"},
+				&testCopyProgressServer{ctx: ctx},
+			)
+		}},
+		{"move", func() error {
+			_, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+				Source: filename, Destination: "moved.txt",
+			})
+			return err
+		}},
+		{"mkdir", func() error {
+			_, err := service.Mkdir(ctx, &blobspb.MkdirRequest{Path: "dir"})
+			return err
+		}},
+		{"touch", func() error {
+			_, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: "touched.txt"})
+			return err
+		}},
+		{"truncate", func() error {
+			_, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{Filename: filename})
+			return err
+		}},
+		{"delete", func() error {
+			_, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: filename})
+			return err
+		}},
+		{"delete-recursive", func() error {
+			_, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "path", Recursive: true})
+			return err
+		}},
+		{"delete-blobs", func() error {
+			_, err := service.DeleteBlobs(ctx, &blobspb.BatchDeleteRequest{
+				Filenames: []string{filename},
+			})
+			return err
+		}},
+		{"acquire-lock", func() error {
+			_, err := service.AcquireLock(ctx, &blobspb.LockRequest{Name: "lock", TtlNanos: 1e9})
+			return err
+		}},
+		{"release-lock", func() error {
+			_, err := service.ReleaseLock(ctx, &blobspb.ReleaseRequest{Name: "lock", Token: "token"})
+			return err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.rpc()
+			if status.Code(err) != codes.FailedPrecondition || !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("expected a read-only error, got %v", err)
+			}
+			if !testutils.IsError(err, "service is read-only") {
+				t.Fatalf("unexpected error message: %v", err)
+			}
+		})
+	}
+
+	// Nothing was modified.
+	if content := readStorageFile(t, storage, filename); !bytes.Equal(content, fileContent) {
+		t.Fatalf("expected %s, got %s", fileContent, content)
+	}
+	for _, path := range []string{"copy.txt", "moved.txt", "dir", "touched.txt"} {
+		if _, err := storage.FileInfo(path); !oserror.IsNotExist(err) {
+			t.Fatalf("expected %s not to exist, got %v", path, err)
+		}
+	}
+
+	t.Run("reads", func(t *testing.T) {
+		if err := service.GetStream(
+			&blobspb.GetRequest{Filename: filename}, &testGetStreamServer{ctx: ctx},
+		); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.List(ctx, &blobspb.GlobRequest{Pattern: "path/to/file/*"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := service.Exists(ctx, &blobspb.ExistsRequest{Filename: filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Exists {
+			t.Fatalf("expected %s to exist", filename)
+		}
+	})
+}
+
+func TestBlobServiceRateLimit(t *testing.T) {
+	storage := newMemStorage()
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, []byte("0123456789"))
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{
+		ReadBytesPerSecond:  1,
+		WriteBytesPerSecond: 1,
+	})
+
+	// The first chunk is admitted by the full token bucket, after which the
+	// limiter has to wait for more tokens. Cancelling the context must stop
+	// that wait rather than blocking for the duration of the transfer.
+	t.Run("read-respects-cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		stream := &testGetStreamServer{ctx: ctx}
+		err := service.GetStream(&blobspb.GetRequest{Filename: filename, ChunkSize: 2}, stream)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+	})
+	t.Run("write-respects-cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "appended.txt",
+			Payload:  []byte("0123456789"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "appended.txt",
+			Payload:  []byte("0123456789"),
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+	})
+}
+
+func TestBlobServiceSettings(t *testing.T) {
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	storage := newMemStorage()
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, []byte("0123456789"))
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{Settings: st})
+
+	get := func(ctx context.Context) error {
+		return service.GetStream(
+			&blobspb.GetRequest{Filename: filename}, &testGetStreamServer{ctx: ctx},
+		)
+	}
+	appendPayload := func(ctx context.Context) error {
+		_, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+			Filename: "appended.txt",
+			Payload:  []byte("0123456789"),
+		})
+		return err
+	}
+	// expectThrottled checks that f has to wait for longer than a test can
+	// reasonably wait.
+	expectThrottled := func(t *testing.T, f func(context.Context) error) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		if err := f(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the transfer to be throttled, got %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		setting *settings.ByteSizeSetting
+		f       func(context.Context) error
+	}{
+		{"read", readRate, get},
+		{"write", writeRate, appendPayload},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Transfers are unlimited by default.
+			for i := 0; i < 3; i++ {
+				if err := tc.f(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// The first transfer, which reads or writes the whole payload at
+			// once, is admitted by the full token bucket, after which transfers
+			// have to wait for more tokens.
+			tc.setting.Override(ctx, &st.SV, 1)
+			if err := tc.f(ctx); err != nil {
+				t.Fatal(err)
+			}
+			expectThrottled(t, tc.f)
+			// Lifting the limit applies to the next transfers right away.
+			tc.setting.Override(ctx, &st.SV, 0)
+			if err := tc.f(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("max-concurrent-ops", func(t *testing.T) {
+		stat := func() error {
+			_, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename})
+			return err
+		}
+		maxConcurrentOps.Override(ctx, &st.SV, 1)
+		rejectWhenBusy.Override(ctx, &st.SV, true)
+		release, err := service.acquireOp(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		if err := stat(); status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected ResourceExhausted, got %v", err)
+		}
+		// Raising the limit frees up a slot for the next RPC.
+		maxConcurrentOps.Override(ctx, &st.SV, 2)
+		if err := stat(); err != nil {
+			t.Fatal(err)
+		}
+		// Lifting the limit lets RPCs through while a slot is held.
+		maxConcurrentOps.Override(ctx, &st.SV, 0)
+		if err := stat(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestBlobServiceMaxConcurrentOps(t *testing.T) {
+	storage := newMemStorage()
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, []byte("file_content"))
+	ctx := context.Background()
+
+	t.Run("reject-when-busy", func(t *testing.T) {
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{
+			MaxConcurrentOps: 1,
+			RejectWhenBusy:   true,
+		})
+		release, err := service.acquireOp(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = service.Stat(ctx, &blobspb.StatRequest{Filename: filename})
+		if status.Code(err) != codes.ResourceExhausted {
+			t.Fatalf("expected ResourceExhausted, got %v", err)
+		}
+		release()
+		if _, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("queue-respects-cancellation", func(t *testing.T) {
+		service := NewBlobServiceWithStorage(storage, ServiceOptions{MaxConcurrentOps: 1})
+		release, err := service.acquireOp(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer release()
+		cancelCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = service.Stat(cancelCtx, &blobspb.StatRequest{Filename: filename})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+	})
+}
+
+// cancellingGetStreamServer is a testGetStreamServer which cancels its
+// context once it has been sent a chunk.
+type cancellingGetStreamServer struct {
+	testGetStreamServer
+	cancel func()
+}
+
+func (s *cancellingGetStreamServer) Send(chunk *blobspb.StreamChunk) error {
+	s.cancel()
+	return s.testGetStreamServer.Send(chunk)
+}
+
+// cancellingPutStreamServer is a testPutStreamServer which cancels its
+// context once it has handed out a chunk.
+type cancellingPutStreamServer struct {
+	*testPutStreamServer
+	cancel func()
+}
+
+func (s *cancellingPutStreamServer) Recv() (*blobspb.StreamChunk, error) {
+	chunk, err := s.testPutStreamServer.Recv()
+	s.cancel()
+	return chunk, err
+}
+
+func TestBlobServiceCancellation(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	const largeFileSize = 4 << 20
+	const transferChunkSize = 64 << 10
+	filename := "large/file.bin"
+	writeLargeFile(t, filepath.Join(tmpDir, filename), largeFileSize)
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("get-stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stream := &cancellingGetStreamServer{
+			testGetStreamServer: testGetStreamServer{ctx: ctx},
+			cancel:              cancel,
+		}
+		err := service.GetStream(&blobspb.GetRequest{
+			Filename:  filename,
+			ChunkSize: transferChunkSize,
+		}, stream)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		if len(stream.chunks) != 1 {
+			t.Fatalf("expected the transfer to stop after 1 chunk, got %d", len(stream.chunks))
+		}
+	})
+	t.Run("put-stream", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		target := "cancelled/file.bin"
+		chunks := make([][]byte, largeFileSize/transferChunkSize)
+		for i := range chunks {
+			chunks[i] = make([]byte, transferChunkSize)
+		}
+		stream := &cancellingPutStreamServer{
+			testPutStreamServer: newTestPutStreamServer(ctx, target, chunks, nil),
+			cancel:              cancel,
+		}
+		err := service.PutStream(stream)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context cancellation error, got %v", err)
+		}
+		if len(stream.chunks) == 0 {
+			t.Fatal("expected the transfer to stop before all chunks were received")
+		}
+		entries, err := ioutil.ReadDir(filepath.Join(tmpDir, filepath.Dir(target)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("expected no files to be left behind, found %d", len(entries))
+		}
+	})
+}
+
+func TestBlobServiceMetrics(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("a")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	metrics := service.Metrics()
+	ctx := context.Background()
+
+	if err := service.GetStream(
+		&blobspb.GetRequest{Filename: filename}, &testGetStreamServer{ctx: ctx},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReadBlob(
+		&blobspb.ReadRequest{Filename: filename}, &testReadBlobServer{ctx: ctx},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.PutStream(
+		newTestPutStreamServer(ctx, "put.txt", [][]byte{[]byte("bc")}, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.WriteBlob(
+		newTestWriteBlobServer(ctx, "write.txt", [][]byte{[]byte("ij")}, nil),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.List(ctx, &blobspb.GlobRequest{Pattern: "*"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+		Filename: "append.txt", Payload: []byte("def"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.WriteBlobAt(ctx, &blobspb.WriteAtRequest{
+		Filename: "writeat.txt", Offset: 1, Payload: []byte("gh"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+		Source: filename, Destination: "copy.txt",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+		Source: "copy.txt", Destination: "moved.txt",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Mkdir(ctx, &blobspb.MkdirRequest{Path: "dir"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Touch(ctx, &blobspb.TouchRequest{Filename: "touched.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{
+		Filename: "moved.txt", Size: 0,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Checksum(ctx, &blobspb.ChecksumRequest{Filename: "append.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Exists(ctx, &blobspb.ExistsRequest{Filename: "dir"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetBlobs(ctx, &blobspb.BatchGetRequest{
+		Filenames: []string{filename},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: filename}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.DeleteBlobs(ctx, &blobspb.BatchDeleteRequest{
+		Filenames: []string{"put.txt"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.DiskUsage(ctx, &blobspb.DiskUsageRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.WalkBlobs(&blobspb.WalkRequest{}, &testWalkBlobsServer{ctx: ctx}); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := service.AcquireLock(ctx, &blobspb.LockRequest{Name: "lock", TtlNanos: 1e9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ReleaseLock(ctx, &blobspb.ReleaseRequest{
+		Name: "lock", Token: lock.Token,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is read by GetStream, ReadBlob, CopyBlob and GetBlobs, and
+	// append.txt by Checksum.
+	// Besides PutStream and WriteBlob, bytes are written by AppendBlob,
+	// WriteBlobAt and CopyBlob.
+	for _, tc := range []struct {
+		name     string
+		counter  *metric.Counter
+		expected int64
+	}{
+		{"bytes read", metrics.BytesRead, 4*int64(len(fileContent)) + 3},
+		{"bytes written", metrics.BytesWritten, 2 + 2 + 3 + 2 + int64(len(fileContent))},
+		{"get count", metrics.GetCount, 1},
+		{"read count", metrics.ReadCount, 1},
+		{"write count", metrics.WriteCount, 1},
+		{"put count", metrics.PutCount, 1},
+		{"list count", metrics.ListCount, 1},
+		{"delete count", metrics.DeleteCount, 1},
+		{"stat count", metrics.StatCount, 1},
+		{"append count", metrics.AppendCount, 1},
+		{"write at count", metrics.WriteAtCount, 1},
+		{"copy count", metrics.CopyCount, 1},
+		{"move count", metrics.MoveCount, 1},
+		{"mkdir count", metrics.MkdirCount, 1},
+		{"touch count", metrics.TouchCount, 1},
+		{"truncate count", metrics.TruncateCount, 1},
+		{"checksum count", metrics.ChecksumCount, 1},
+		{"exists count", metrics.ExistsCount, 1},
+		{"batch get count", metrics.BatchGetCount, 1},
+		{"batch delete count", metrics.BatchDeleteCount, 1},
+		{"disk usage count", metrics.DiskUsageCount, 1},
+		{"walk count", metrics.WalkCount, 1},
+		{"lock count", metrics.LockCount, 1},
+		{"unlock count", metrics.UnlockCount, 1},
+	} {
+		if actual := tc.counter.Count(); actual != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, actual)
+		}
+	}
+	for _, tc := range []struct {
+		name    string
+		latency *metric.Histogram
+	}{
+		{"get latency", metrics.GetLatency},
+		{"read latency", metrics.ReadLatency},
+		{"write latency", metrics.WriteLatency},
+		{"put latency", metrics.PutLatency},
+		{"list latency", metrics.ListLatency},
+		{"delete latency", metrics.DeleteLatency},
+		{"stat latency", metrics.StatLatency},
+		{"append latency", metrics.AppendLatency},
+		{"write at latency", metrics.WriteAtLatency},
+		{"copy latency", metrics.CopyLatency},
+		{"move latency", metrics.MoveLatency},
+		{"mkdir latency", metrics.MkdirLatency},
+		{"touch latency", metrics.TouchLatency},
+		{"truncate latency", metrics.TruncateLatency},
+		{"checksum latency", metrics.ChecksumLatency},
+		{"exists latency", metrics.ExistsLatency},
+		{"batch get latency", metrics.BatchGetLatency},
+		{"batch delete latency", metrics.BatchDeleteLatency},
+		{"disk usage latency", metrics.DiskUsageLatency},
+		{"walk latency", metrics.WalkLatency},
+		{"lock latency", metrics.LockLatency},
+		{"unlock latency", metrics.UnlockLatency},
+	} {
+		if count := tc.latency.TotalCount(); count != 1 {
+			t.Errorf("%s: expected 1 recorded value, got %d", tc.name, count)
+		}
+	}
+}
+
+func TestBlobServiceTracing(t *testing.T) {
+	storage := newMemStorage()
+	fileContent := []byte("file_content")
+	filename := "path/to/file/content.txt"
+	writeStorageFile(t, storage, filename, fileContent)
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+
+	tr := tracing.NewTracer()
+	ctx, sp := tr.StartSpanCtx(
+		context.Background(), "test", tracing.WithRecording(tracing.RecordingVerbose),
+	)
+	if err := service.GetStream(
+		&blobspb.GetRequest{Filename: filename}, &testGetStreamServer{ctx: ctx},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ReadBlob(
+		&blobspb.ReadRequest{Filename: filename}, &testReadBlobServer{ctx: ctx},
+	); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "missing.txt"}); err == nil {
+		t.Fatal("expected stat of a missing file to fail")
+	}
+	rec := sp.FinishAndGetRecording(tracing.RecordingVerbose)
+
+	get, ok := rec.FindSpan("blob.Get")
+	if !ok {
+		t.Fatalf("expected a blob.Get span in recording:\n%s", rec)
+	}
+	if get.Tags["filename"] != filename {
+		t.Fatalf("expected filename tag %s, got %s", filename, get.Tags["filename"])
+	}
+	if expected := strconv.Itoa(len(fileContent)); get.Tags["bytes"] != expected {
+		t.Fatalf("expected bytes tag %s, got %s", expected, get.Tags["bytes"])
+	}
+	if _, ok := get.Tags["duration"]; !ok {
+		t.Fatal("expected a duration tag")
+	}
+	if _, ok := get.Tags["error"]; ok {
+		t.Fatalf("unexpected error tag %s", get.Tags["error"])
+	}
+
+	read, ok := rec.FindSpan("blob.Read")
+	if !ok {
+		t.Fatalf("expected a blob.Read span in recording:\n%s", rec)
+	}
+	if read.Tags["filename"] != filename {
+		t.Fatalf("expected filename tag %s, got %s", filename, read.Tags["filename"])
+	}
+	var gets int
+	for _, span := range rec {
+		if span.Operation == "blob.Get" {
+			gets++
+		}
+	}
+	if gets != 1 {
+		t.Fatalf("expected ReadBlob not to be traced as blob.Get, found %d blob.Get spans", gets)
+	}
+
+	stat, ok := rec.FindSpan("blob.Stat")
+	if !ok {
+		t.Fatalf("expected a blob.Stat span in recording:\n%s", rec)
+	}
+	if !strings.Contains(stat.Tags["error"], "no such file") {
+		t.Fatalf("expected the error to be recorded, got %q", stat.Tags["error"])
+	}
+}
+
+func TestBlobServiceLocks(t *testing.T) {
+	storage := newMemStorage()
+	service := NewBlobServiceWithStorage(storage, ServiceOptions{})
+	ctx := context.Background()
+	ttl := time.Hour.Nanoseconds()
+
+	acquire := func(name string) (*blobspb.LockResponse, error) {
+		return service.AcquireLock(ctx, &blobspb.LockRequest{Name: name, TtlNanos: ttl})
+	}
+	releaseLock := func(name, token string) error {
+		_, err := service.ReleaseLock(ctx, &blobspb.ReleaseRequest{Name: name, Token: token})
+		return err
+	}
+	expectLockHeld := func(t *testing.T, err error) {
+		t.Helper()
+		if !errors.Is(err, ErrLockHeld) || status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected a lock held error, got %v", err)
+		}
+	}
+
+	t.Run("acquire-release", func(t *testing.T) {
+		lock, err := acquire("backup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = acquire("backup")
+		expectLockHeld(t, err)
+		// Locks with other names are independent.
+		if _, err := acquire("restore"); err != nil {
+			t.Fatal(err)
+		}
+
+		expectLockHeld(t, releaseLock("backup", "not-the-token"))
+		if err := releaseLock("backup", lock.Token); err != nil {
+			t.Fatal(err)
+		}
+		if err := releaseLock("backup", lock.Token); status.Code(err) != codes.NotFound {
+			t.Fatalf("expected a NotFound error, got %v", err)
+		}
+		if _, err := acquire("backup"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		lock, err := acquire("expired")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Simulate a holder which crashed a while ago.
+		if err := writeLockHolder(ctx, storage, filepath.Join(lockDir, "expired"), lockHolder{
+			token:      lock.Token,
+			expiration: timeutil.Now().Add(-time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		newLock, err := acquire("expired")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if newLock.Token == lock.Token {
+			t.Fatal("expected a new token")
+		}
+		// The previous holder cannot release the lock anymore.
+		expectLockHeld(t, releaseLock("expired", lock.Token))
+		if err := releaseLock("expired", newLock.Token); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, name := range []string{"", ".", "..", "a/b", "../backup"} {
+			if _, err := acquire(name); status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("%q: expected an InvalidArgument error, got %v", name, err)
+			}
+		}
+		_, err := service.AcquireLock(ctx, &blobspb.LockRequest{Name: "backup"})
+		if !testutils.IsError(err, "invalid lock TTL") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceErrorCodes(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	writeTestFile(t, filepath.Join(tmpDir, "dir/content.txt"), []byte("content"))
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		fn       func() error
+		expected codes.Code
+	}{
+		{"not-found", func() error {
+			_, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "missing.txt"})
+			return err
+		}, codes.NotFound},
+		{"outside-external-io-dir", func() error {
+			_, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "../content.txt"})
+			return err
+		}, codes.InvalidArgument},
+		{"invalid-request", func() error {
+			_, err := service.List(ctx, &blobspb.GlobRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"directory-not-empty", func() error {
+			_, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "dir"})
+			return err
+		}, codes.FailedPrecondition},
+		{"not-a-file", func() error {
+			_, err := service.Checksum(ctx, &blobspb.ChecksumRequest{Filename: "dir"})
+			return err
+		}, codes.FailedPrecondition},
+		{"cancelled", func() error {
+			ctx, cancel := context.WithCancel(ctx)
+			cancel()
+			_, err := service.GetBlobs(ctx, &blobspb.BatchGetRequest{Filenames: []string{"a.txt"}})
+			return err
+		}, codes.Canceled},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fn()
+			if status.Code(err) != tc.expected || ErrorCode(err) != tc.expected {
+				t.Fatalf("expected a %s error, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	t.Run("external-io-disabled", func(t *testing.T) {
+		service, err := NewBlobService("", ServiceOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = service.Stat(ctx, &blobspb.StatRequest{Filename: "../content.txt"})
+		if !errors.Is(err, ErrExternalIODisabled) {
+			t.Fatalf("expected %v, got %v", ErrExternalIODisabled, err)
+		}
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Fatalf("expected a %s error, got %v", codes.FailedPrecondition, err)
+		}
+		err = service.GetStream(
+			&blobspb.GetRequest{Filename: "content.txt"}, &testGetStreamServer{ctx: ctx},
+		)
+		if !errors.Is(err, ErrExternalIODisabled) {
+			t.Fatalf("expected %v, got %v", ErrExternalIODisabled, err)
+		}
+	})
+
+	t.Run("classify", func(t *testing.T) {
+		for _, tc := range []struct {
+			err      error
+			expected codes.Code
+		}{
+			{nil, codes.OK},
+			{errors.New("unexpected"), codes.Internal},
+			{errors.Wrap(ErrFileExists, "writing"), codes.AlreadyExists},
+			// Errors received from a remote blob service keep their code, even
+			// once wrapped by the client.
+			{errors.Wrap(status.Error(codes.ResourceExhausted, "disk full"), "writing"),
+				codes.ResourceExhausted},
+		} {
+			if code := ErrorCode(tc.err); code != tc.expected {
+				t.Errorf("expected %v to be classified as %s, got %s", tc.err, tc.expected, code)
+			}
+		}
+	})
+}
+
+func TestBlobServiceSymlinkEscape(t *testing.T) {
+	tmpDir, cleanupFn := testutils.TempDir(t)
+	defer cleanupFn()
+
+	// The symlinks point at a file outside of the external IO dir which stands
+	// in for something like /etc/passwd.
+	outsideDir, cleanupOutside := testutils.TempDir(t)
+	defer cleanupOutside()
+	target := filepath.Join(outsideDir, "passwd")
+	writeTestFile(t, target, []byte("root:x:0:0"))
+	if err := os.MkdirAll(filepath.Join(tmpDir, "links"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, filepath.Join(tmpDir, "links/passwd")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(tmpDir, "links/etc")); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if _, err := os.Stat(target); err != nil {
+			t.Fatalf("expected %s to be left untouched: %v", target, err)
+		}
+	}()
+
+	service, err := NewBlobService(tmpDir, ServiceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	const outsideErr = "outside of external-io-dir is not allowed"
+
+	for _, filename := range []string{"links/passwd", "links/etc/passwd"} {
+		t.Run(filename, func(t *testing.T) {
+			t.Run("get", func(t *testing.T) {
+				err := service.GetStream(
+					&blobspb.GetRequest{Filename: filename}, &testGetStreamServer{ctx: ctx},
+				)
+				if !testutils.IsError(err, outsideErr) {
+					t.Fatalf("expected error %q, got %v", outsideErr, err)
+				}
+			})
+			t.Run("stat", func(t *testing.T) {
+				_, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename})
+				if !testutils.IsError(err, outsideErr) {
+					t.Fatalf("expected error %q, got %v", outsideErr, err)
+				}
+			})
+			t.Run("delete", func(t *testing.T) {
+				_, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: filename})
+				if !testutils.IsError(err, outsideErr) {
+					t.Fatalf("expected error %q, got %v", outsideErr, err)
+				}
+			})
+		})
+	}
+	t.Run("put-through-dangling-symlink", func(t *testing.T) {
+		outsideDir, cleanupOutside := testutils.TempDir(t)
+		defer cleanupOutside()
+		missing := filepath.Join(outsideDir, "missing.txt")
+		if err := os.Symlink(missing, filepath.Join(tmpDir, "links/dangling")); err != nil {
+			t.Fatal(err)
+		}
+		err := service.PutStream(
+			newTestPutStreamServer(ctx, "links/dangling", [][]byte{[]byte("a")}, nil),
+		)
+		if !testutils.IsError(err, outsideErr) {
+			t.Fatalf("expected error %q, got %v", outsideErr, err)
+		}
+		if _, err := os.Stat(missing); !oserror.IsNotExist(err) {
+			t.Fatalf("expected %s not to be created, got %v", missing, err)
+		}
+	})
+	t.Run("contained-symlink", func(t *testing.T) {
+		writeTestFile(t, filepath.Join(tmpDir, "real.txt"), []byte("a"))
+		if err := os.Symlink(
+			filepath.Join(tmpDir, "real.txt"), filepath.Join(tmpDir, "links/real.txt"),
+		); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "links/real.txt"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestBlobServiceMultipleRoots(t *testing.T) {
+	primaryDir, cleanupPrimary := testutils.TempDir(t)
+	defer cleanupPrimary()
+	otherDir, cleanupOther := testutils.TempDir(t)
+	defer cleanupOther()
+	outsideDir, cleanupOutside := testutils.TempDir(t)
+	defer cleanupOutside()
+
+	service, err := NewBlobService(primaryDir, ServiceOptions{
+		AdditionalExternalIODirs: []string{otherDir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	put := func(t *testing.T, filename, content string) {
+		t.Helper()
+		if err := service.PutStream(
+			newTestPutStreamServer(ctx, filename, [][]byte{[]byte(content)}, nil),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(t *testing.T, filename string) string {
+		t.Helper()
+		stream := &testGetStreamServer{ctx: ctx}
+		if err := service.GetStream(&blobspb.GetRequest{Filename: filename}, stream); err != nil {
+			t.Fatal(err)
+		}
+		return string(bytes.Join(stream.chunks, nil))
+	}
+
+	t.Run("primary", func(t *testing.T) {
+		put(t, "backups/a.csv", "a")
+		content, err := ioutil.ReadFile(filepath.Join(primaryDir, "backups/a.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "a" {
+			t.Fatalf("expected a, got %s", content)
+		}
+		if content := get(t, "/backups/a.csv"); content != "a" {
+			t.Fatalf("expected a, got %s", content)
+		}
+	})
+
+	t.Run("additional", func(t *testing.T) {
+		const filename = "@1/imports/b.csv"
+		put(t, filename, "bb")
+		content, err := ioutil.ReadFile(filepath.Join(otherDir, "imports/b.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "bb" {
+			t.Fatalf("expected bb, got %s", content)
+		}
+		if content := get(t, filename); content != "bb" {
+			t.Fatalf("expected bb, got %s", content)
+		}
+		stat, err := service.Stat(ctx, &blobspb.StatRequest{Filename: filename})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Filesize != 2 {
+			t.Fatalf("expected filesize 2, got %d", stat.Filesize)
+		}
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		// An absolute path is relative to the primary root like with a single
+		// external IO dir, even when it is within an additional root.
+		filename := filepath.Join(otherDir, "absolute.csv")
+		put(t, filename, "d")
+		if _, err := os.Stat(filepath.Join(primaryDir, filename)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filename); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+		// A leading separator does not change which root a selector addresses.
+		if content := get(t, "/@1/imports/b.csv"); content != "bb" {
+			t.Fatalf("expected bb, got %s", content)
+		}
+	})
+
+	t.Run("selector", func(t *testing.T) {
+		// A selector without a matching root is rejected rather than being
+		// written to the primary root, while an element which merely starts
+		// like a selector is a regular path of the primary root.
+		for _, filename := range []string{"@0/e.csv", "@2/e.csv", "/@2/e.csv"} {
+			err := service.PutStream(newTestPutStreamServer(ctx, filename, [][]byte{[]byte("e")}, nil))
+			if !testutils.IsError(err, "does not select an external IO dir") {
+				t.Fatalf("%s: unexpected error: %v", filename, err)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(primaryDir, "@2")); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+		put(t, "@latest/e.csv", "e")
+		if _, err := os.Stat(filepath.Join(primaryDir, "@latest/e.csv")); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("outside", func(t *testing.T) {
+		// A symlink in the additional root must not give access to the files it
+		// points at, while an absolute path outside of every root is relative to
+		// the primary root like with a single external IO dir.
+		if err := os.Symlink(outsideDir, filepath.Join(otherDir, "link")); err != nil {
+			t.Fatal(err)
+		}
+		const outsideErr = "outside of external-io-dir is not allowed"
+		for _, filename := range []string{"../escape.csv", "@1/link/escape.csv", "@1/../../escape.csv"} {
+			err := service.PutStream(
+				newTestPutStreamServer(ctx, filename, [][]byte{[]byte("x")}, nil),
+			)
+			if !testutils.IsError(err, outsideErr) {
+				t.Fatalf("%s: expected error %q, got %v", filename, outsideErr, err)
+			}
+		}
+		put(t, filepath.Join(outsideDir, "escape.csv"), "x")
+		if _, err := os.Stat(filepath.Join(primaryDir, outsideDir, "escape.csv")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(outsideDir, "escape.csv")); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	})
+
+	t.Run("move-across-roots", func(t *testing.T) {
+		source, destination := "move/c.csv", "@1/moved/c.csv"
+		put(t, source, "ccc")
+		if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+			Source:      source,
+			Destination: destination,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if content := get(t, destination); content != "ccc" {
+			t.Fatalf("expected ccc, got %s", content)
+		}
+		if _, err := os.Stat(filepath.Join(primaryDir, source)); !oserror.IsNotExist(err) {
+			t.Fatalf("expected not exists err, got: %v", err)
+		}
+	})
+
+	t.Run("delete-root", func(t *testing.T) {
+		_, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "@1", Recursive: true})
+		if !testutils.IsError(err, "deleting an external-io-dir is not allowed") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(otherDir); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("overlapping-roots", func(t *testing.T) {
+		_, err := NewBlobService(primaryDir, ServiceOptions{
+			AdditionalExternalIODirs: []string{filepath.Join(primaryDir, "nested")},
+		})
+		if !testutils.IsError(err, "overlap") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBlobServiceMemStorage(t *testing.T) {
+	service := NewBlobServiceWithStorage(newMemStorage(), ServiceOptions{})
+	ctx := context.Background()
+
+	put := func(filename, content string) {
+		t.Helper()
+		if err := service.PutStream(
+			newTestPutStreamServer(ctx, filename, [][]byte{[]byte(content)}, nil),
+		); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(filename string) string {
+		t.Helper()
+		stream := &testGetStreamServer{ctx: ctx}
+		if err := service.GetStream(&blobspb.GetRequest{Filename: filename}, stream); err != nil {
+			t.Fatal(err)
+		}
+		return string(bytes.Join(stream.chunks, nil))
+	}
+	list := func(pattern string, recursive bool) []string {
+		t.Helper()
+		resp, err := service.List(ctx, &blobspb.GlobRequest{Pattern: pattern, Recursive: recursive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Files
+	}
+
+	put("dir/a.csv", "a")
+	put("dir/nested/b.csv", "bb")
+	put("other.csv", "ccc")
+
+	if content := get("dir/a.csv"); content != "a" {
+		t.Fatalf("expected a, got %s", content)
+	}
+	stat, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "dir/nested/b.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Filesize != 2 {
+		t.Fatalf("expected filesize 2, got %d", stat.Filesize)
+	}
+	exists, err := service.Exists(ctx, &blobspb.ExistsRequest{Filename: "dir/nested"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists.Exists || !exists.IsDir {
+		t.Fatalf("expected dir/nested to be an existing directory, got %+v", exists)
+	}
+
+	assert.Equal(t, []string{"/dir/a.csv", "/dir/nested/b.csv"}, list("dir", false))
+	assert.Equal(t, []string{"/dir/a.csv", "/dir/nested"}, list("dir/*", false))
+	assert.Equal(t, []string{"/dir/a.csv", "/dir/nested/b.csv", "/other.csv"}, list("*", true))
+
+	if _, err := service.CopyBlob(ctx, &blobspb.CopyRequest{
+		Source: "other.csv", Destination: "copy/other.csv",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.MoveBlob(ctx, &blobspb.MoveRequest{
+		Source: "dir/nested", Destination: "moved",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.AppendBlob(ctx, &blobspb.AppendRequest{
+		Filename: "moved/b.csv", Payload: []byte("b"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.TruncateBlob(ctx, &blobspb.TruncateRequest{
+		Filename: "copy/other.csv", Size: 1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "bbb", get("moved/b.csv"))
+	assert.Equal(t, "c", get("copy/other.csv"))
+
+	if _, err := service.Delete(ctx, &blobspb.DeleteRequest{Filename: "dir", Recursive: true}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.Stat(ctx, &blobspb.StatRequest{Filename: "dir/a.csv"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound error, got %v", err)
+	}
+	assert.Equal(t, []string{"/copy/other.csv", "/moved/b.csv", "/other.csv"}, list("*", true))
+
+	t.Run("not-in-external-io-dir", func(t *testing.T) {
+		_, err := service.Stat(ctx, &blobspb.StatRequest{Filename: "../outside.csv"})
+		if !testutils.IsError(err, "outside of external-io-dir is not allowed") {
+			t.Fatalf("incorrect error message: %v", err)
+		}
+	})
+}
+
+func TestBufferPool(t *testing.T) {
+	var pool bufferPool
+	for _, tc := range []struct {
+		size, expectedCap int
+	}{
+		{0, 4 << 10},
+		{1, 4 << 10},
+		{4 << 10, 4 << 10},
+		{4<<10 + 1, 8 << 10},
+		{chunkSize, chunkSize},
+		{maxChunkSize, maxChunkSize},
+		// Buffers larger than maxChunkSize are not pooled.
+		{maxChunkSize + 1, maxChunkSize + 1},
+	} {
+		buf := pool.get(tc.size)
+		if len(*buf) != tc.size || cap(*buf) != tc.expectedCap {
+			t.Errorf("get(%d): expected length %d and capacity %d, got %d and %d",
+				tc.size, tc.size, tc.expectedCap, len(*buf), cap(*buf))
+		}
+		pool.put(buf)
+	}
 }
@@ -0,0 +1,63 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+var readRate = settings.RegisterByteSizeSetting(
+	settings.TenantWritable,
+	"bulkio.blob_service.read_rate",
+	"the rate limit (bytes/sec) on file contents read by the blob service of each node; 0 means unlimited",
+	0,
+	settings.NonNegativeInt,
+)
+
+var writeRate = settings.RegisterByteSizeSetting(
+	settings.TenantWritable,
+	"bulkio.blob_service.write_rate",
+	"the rate limit (bytes/sec) on file contents written by the blob service of each node; 0 means unlimited",
+	0,
+	settings.NonNegativeInt,
+)
+
+var maxConcurrentOps = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"bulkio.blob_service.max_concurrent_ops",
+	"the maximum number of requests handled at the same time by the blob service of each node; 0 means unlimited",
+	0,
+	settings.NonNegativeInt,
+)
+
+var rejectWhenBusy = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"bulkio.blob_service.reject_when_busy",
+	"if set, requests exceeding bulkio.blob_service.max_concurrent_ops fail immediately rather than wait",
+	false,
+)
+
+// watchSettings makes the limits of s follow the cluster settings in sv.
+func (s *Service) watchSettings(sv *settings.Values) {
+	apply := func(ctx context.Context) {
+		s.readLimit.setLimit(readRate.Get(sv))
+		s.writeLimit.setLimit(writeRate.Get(sv))
+		s.setMaxConcurrentOps(maxConcurrentOps.Get(sv))
+		s.setRejectWhenBusy(rejectWhenBusy.Get(sv))
+	}
+	readRate.SetOnChange(sv, apply)
+	writeRate.SetOnChange(sv, apply)
+	maxConcurrentOps.SetOnChange(sv, apply)
+	rejectWhenBusy.SetOnChange(sv, apply)
+	apply(context.Background())
+}
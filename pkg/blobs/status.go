@@ -0,0 +1,121 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"os"
+
+	"github.com/cockroachdb/cockroach/pkg/util/sysutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errInvalidArgument marks the errors returned for a request which is invalid
+// whatever the state of the storage, e.g. because of a malformed option.
+var errInvalidArgument = errors.New("invalid argument")
+
+// errNotAFile marks the errors returned when a file operation is applied to a
+// directory, or a write to a special file.
+var errNotAFile = errors.New("not a file")
+
+// invalidArgumentf returns an error marked with errInvalidArgument.
+func invalidArgumentf(format string, args ...interface{}) error {
+	return errors.Mark(errors.Newf(format, args...), errInvalidArgument)
+}
+
+// notAFileError returns the error reported when a file operation is applied
+// to the directory name.
+func notAFileError(name string) error {
+	return errors.Mark(errors.Errorf("expected a file but %q is a directory", name), errNotAFile)
+}
+
+// notARegularFileError returns the error reported when writing to name, which
+// is a special file, e.g. a FIFO, a device or a socket.
+func notARegularFileError(name string, mode os.FileMode) error {
+	return errors.Mark(
+		errors.Errorf("cannot write to %q: not a regular file (mode %s)", name, mode),
+		errNotAFile,
+	)
+}
+
+// ErrorCode returns the gRPC status code which classifies err. It can be used
+// on the errors of both local and remote blob clients: for the latter, it is
+// the code sent by the blob service, which the service derives from the error
+// the same way. Unexpected errors are classified as codes.Internal.
+func ErrorCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	// gRPC hides the underlying error of a remote blob service, so check
+	// whether err wraps the status it was sent with.
+	if s, ok := status.FromError(errors.UnwrapAll(err)); ok {
+		return s.Code()
+	}
+	switch {
+	case oserror.IsNotExist(err):
+		return codes.NotFound
+	case errors.IsAny(err, errOutsideExternalIODir, errInvalidArgument):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrFileExists):
+		return codes.AlreadyExists
+	case errors.IsAny(
+		err, ErrDirNotEmpty, ErrCrossDevice, errNotAFile, ErrLockHeld, ErrExternalIODisabled,
+		ErrReadOnly,
+	):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrFileTooLarge) || sysutil.IsErrNoSpace(err):
+		return codes.ResourceExhausted
+	case errors.Is(err, ErrChecksumMismatch):
+		return codes.DataLoss
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// statusError is an error which gRPC sends with the code returned by
+// ErrorCode for its cause. It keeps the cause, so that errors.Is still works
+// on the errors which a Service returns to a local caller.
+type statusError struct {
+	cause error
+	code  codes.Code
+}
+
+func (e *statusError) Error() string { return e.cause.Error() }
+
+func (e *statusError) Unwrap() error { return e.cause }
+
+// GRPCStatus is used by gRPC to convert the error into a status.
+func (e *statusError) GRPCStatus() *status.Status {
+	return status.New(e.code, e.cause.Error())
+}
+
+// toStatus classifies err with a gRPC status code, so that a client can tell
+// what kind of error it got without matching messages. The message of err is
+// sent along with the code.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return &statusError{cause: err, code: ErrorCode(err)}
+}
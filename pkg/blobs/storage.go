@@ -0,0 +1,305 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// Storage holds the files served by a blob Service. Paths are relative to
+// the root of the storage, which for LocalStorage is the external IO dir.
+//
+// A Storage only provides primitive operations; copies, moves, listings and
+// recursive deletions are built on top of them by the Service, so that every
+// backend behaves the same. Missing files are reported with errors for which
+// oserror.IsNotExist is true.
+type Storage interface {
+	// ReadFile returns a reader for the content of filename, starting at
+	// offset, along with the size of the whole file.
+	ReadFile(filename string, offset int64) (io.ReadCloser, int64, error)
+	// WriterWithOptions returns a writer which replaces the content of
+	// filename once it is closed, unless ctx is cancelled before that. The
+	// parent directories of filename are created if needed.
+	WriterWithOptions(ctx context.Context, filename string, opts WriteOptions) (io.WriteCloser, error)
+	// Append appends payload to filename, creating it if needed, and returns
+	// the size of the file after the append.
+	Append(filename string, payload []byte) (int64, error)
+	// WriteAt writes payload at offset in filename, creating it if needed,
+	// and returns the size of the file after the write. A write past the end
+	// of the file fills the gap with zeroes. The file is written in place.
+	WriteAt(filename string, offset int64, payload []byte) (int64, error)
+	// Truncate changes the size of filename, extending it with zeroes if needed.
+	Truncate(filename string, size int64) error
+	// Rename renames a file or directory. The parent directory of destination
+	// must exist. A rename which the backend cannot perform atomically, e.g.
+	// across filesystems, fails with an error marked as ErrCrossDevice.
+	Rename(source, destination string) error
+	// Mkdir creates a directory along with any missing parents.
+	Mkdir(path string) error
+	// Touch creates an empty file along with any missing parents. A file
+	// which exists is left untouched, except that its modification time is
+	// set to the current time if updateModTime is set.
+	Touch(filename string, updateModTime bool) error
+	// ReadDir returns the entries of a directory, sorted by name. Symlinks
+	// are reported as such rather than followed.
+	ReadDir(dir string) ([]os.FileInfo, error)
+	// Delete deletes a file, a symlink or an empty directory. Deleting a
+	// directory which is not empty fails with ErrDirNotEmpty.
+	Delete(filename string) error
+	// FileInfo returns the os.FileInfo of a file or directory.
+	FileInfo(filename string) (os.FileInfo, error)
+	// DiskUsage returns the disk usage of the filesystem which holds, or
+	// would hold, path.
+	DiskUsage(path string) (vfs.DiskUsage, error)
+}
+
+// ErrCrossDevice marks the errors returned by Storage.Rename when the rename
+// cannot be performed atomically.
+var ErrCrossDevice = errors.New("cannot rename across filesystems")
+
+// errOutsideExternalIODir marks the errors returned when a path, once
+// resolved, escapes the root of a Storage.
+var errOutsideExternalIODir = errors.New("outside of external-io-dir")
+
+// outsideExternalIODirError returns the error reported for a path which
+// escapes the root of a Storage.
+func outsideExternalIODirError(path string) error {
+	return errors.Mark(
+		errors.Errorf("local file access to paths outside of external-io-dir is not allowed: %s", path),
+		errOutsideExternalIODir,
+	)
+}
+
+// errDeleteRoot marks the errors returned when deleting the root of a Storage,
+// or one of the roots of a multi-root storage.
+var errDeleteRoot = errors.New("cannot delete a root of the storage")
+
+// deleteRootError returns the error reported when deleting path, which is a
+// root of the storage.
+func deleteRootError(format string, path string) error {
+	return errors.Mark(invalidArgumentf(format, path), errDeleteRoot)
+}
+
+// validatePath checks that path, once joined to the root of a Storage, does
+// not escape it. Backends may perform further checks, e.g. LocalStorage also
+// resolves symlinks.
+func validatePath(path string) error {
+	// Like filepath.Join(root, path), this treats an absolute path as being
+	// relative to the root.
+	rel := filepath.Join(".", path)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return outsideExternalIODirError(path)
+	}
+	return nil
+}
+
+// statBlob returns the BlobStat of filename. Directories are reported with
+// IsDir set if allowDir is set, and are an error otherwise.
+func statBlob(storage Storage, filename string, allowDir bool) (*blobspb.BlobStat, error) {
+	fi, err := storage.FileInfo(filename)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		if !allowDir {
+			return nil, notAFileError(fi.Name())
+		}
+		return &blobspb.BlobStat{
+			ModTimeNanos: fi.ModTime().UnixNano(),
+			IsDir:        true,
+		}, nil
+	}
+	return &blobspb.BlobStat{
+		Filesize:     fi.Size(),
+		ModTimeNanos: fi.ModTime().UnixNano(),
+	}, nil
+}
+
+// fileExists reports whether filename exists in storage. Unlike statBlob, a
+// missing path is not an error.
+func fileExists(storage Storage, filename string) (exists bool, isDir bool, _ error) {
+	fi, err := storage.FileInfo(filename)
+	if err != nil {
+		if oserror.IsNotExist(err) {
+			return false, false, nil
+		}
+		return false, false, err
+	}
+	return true, fi.IsDir(), nil
+}
+
+// rootPath returns path relative to the root of a storage, with a leading
+// separator. Listings return paths in this form.
+func rootPath(path string) string {
+	return filepath.Join(string(filepath.Separator), path)
+}
+
+// copyFile copies the content of source to destination, overwriting it if it
+// exists, and returns the number of bytes copied. The destination is written
+// like any other file, so that it is only ever visible in its complete form.
+//
+// If progress is set, it is called with the number of bytes copied so far and
+// the size of source after each write to the destination. The copy fails with
+// the error progress returns, if any.
+func copyFile(
+	ctx context.Context,
+	storage Storage,
+	source, destination string,
+	progress func(copied, total int64) error,
+) (int64, error) {
+	src, total, err := storage.ReadFile(source, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := storage.WriterWithOptions(ctx, destination, WriteOptions{})
+	if err != nil {
+		return 0, err
+	}
+	var dst io.Writer = w
+	if progress != nil {
+		dst = &progressWriter{w: w, progress: func(copied int64) error {
+			return progress(copied, total)
+		}}
+	}
+	buf := buffers.get(chunkSize)
+	defer buffers.put(buf)
+	n, err := io.CopyBuffer(dst, src, *buf)
+	if err != nil {
+		// Cancel so that the partially written file is discarded.
+		cancel()
+		return n, errors.CombineErrors(err, w.Close())
+	}
+	return n, w.Close()
+}
+
+// progressWriter is an io.Writer which reports the number of bytes written
+// through it after each write.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	progress func(written int64) error
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, w.progress(w.written)
+}
+
+// moveFile renames source to destination, creating the parent directories of
+// the destination if needed.
+//
+// When the storage can rename source atomically, the move is atomic.
+// Otherwise a file is moved by copying it and then deleting the source: the
+// destination is still only ever visible in its complete form, and it is
+// deleted again if the source cannot be, so that the file does not end up in
+// both places. Directories cannot be moved that way.
+func moveFile(ctx context.Context, storage Storage, source, destination string) error {
+	fi, err := storage.FileInfo(source)
+	if err != nil {
+		return err
+	}
+	targetDir := filepath.Dir(rootPath(destination))
+	if err := storage.Mkdir(targetDir); err != nil {
+		return errors.Wrapf(err, "creating target directory %q", targetDir)
+	}
+	err = storage.Rename(source, destination)
+	if !errors.Is(err, ErrCrossDevice) {
+		return err
+	}
+	if fi.IsDir() {
+		return errors.Wrapf(err, "cannot move directory %q to %q", source, destination)
+	}
+	if _, err := copyFile(ctx, storage, source, destination, nil /* progress */); err != nil {
+		return err
+	}
+	if err := storage.Delete(source); err != nil {
+		return errors.CombineErrors(
+			errors.Wrapf(err, "deleting %q after copying it", source),
+			errors.Wrap(storage.Delete(destination), "cleaning up"),
+		)
+	}
+	return nil
+}
+
+// deleteRecursive deletes a file or a directory along with everything it
+// contains, and returns the total size of the files it deleted within
+// directories. It refuses to delete the root of the storage. Symlinks are not
+// followed, but, like Delete, it fails on a symlink which points outside of
+// the storage.
+func deleteRecursive(storage Storage, filename string) (int64, error) {
+	p := rootPath(filename)
+	if p == string(filepath.Separator) {
+		return 0, deleteRootError(
+			"recursively deleting the external-io-dir is not allowed: %s", filename,
+		)
+	}
+	err := storage.Delete(p)
+	if !errors.Is(err, ErrDirNotEmpty) {
+		return 0, err
+	}
+	entries, err := storage.ReadDir(p)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for _, fi := range entries {
+		child := filepath.Join(p, fi.Name())
+		if fi.IsDir() {
+			var n int64
+			n, err = deleteRecursive(storage, child)
+			deleted += n
+		} else if err = storage.Delete(child); err == nil && fi.Mode().IsRegular() {
+			deleted += fi.Size()
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, storage.Delete(p)
+}
+
+// removeEmptyParents deletes the parent directories of filename which are
+// empty, walking upward until it reaches a directory which is not empty or
+// the root of the storage, which is never deleted.
+func removeEmptyParents(storage Storage, filename string) error {
+	dir := filepath.Dir(rootPath(filename))
+	for dir != string(filepath.Separator) {
+		err := storage.Delete(dir)
+		if errors.IsAny(err, ErrDirNotEmpty, errDeleteRoot) || oserror.IsNotExist(err) {
+			// A directory which is not empty, or which another operation
+			// deleted concurrently, ends the walk, as does an additional root
+			// of a multi-root storage.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		dir = filepath.Dir(dir)
+	}
+	return nil
+}
@@ -11,14 +11,16 @@
 package blobs
 
 import (
+	"compress/gzip"
+	"context"
 	"io"
 
 	"github.com/cockroachdb/cockroach/pkg/blobs/blobspb"
 )
 
-// Within the blob service, streaming is used in two functions:
-//   - GetStream, streaming from server to client
-//   - PutStream, streaming from client to server
+// Within the blob service, streaming is used in these functions:
+//   - GetStream and ReadBlob, streaming from server to client
+//   - PutStream and WriteBlob, streaming from client to server
 // These functions are used to read or write files on a remote node.
 // The io.ReadCloser we implement here are used on the _receiver's_
 // side, to read from either Blob_GetStreamClient or Blob_PutStreamServer.
@@ -33,6 +35,35 @@ import (
 // starts decreasing.
 var chunkSize = 128 * 1 << 10
 
+// defaultReadChunkSize is the size of the chunks sent by ReadBlob unless the
+// request asks for another size.
+const defaultReadChunkSize = 1 << 20
+
+// maxChunkSize bounds the chunk size a request can ask for, since the server
+// needs a buffer of that size for every stream. It is the size of the largest
+// buffers recycled by bufferPool.
+const maxChunkSize = 4 << 20
+
+// minChunkSize is the smallest chunk size a request can ask for, so that a
+// stream is not split into a message per handful of bytes.
+const minChunkSize = 4 << 10
+
+// clampChunkSize returns the chunk size to use for a request asking for
+// chunks of the given size: the default if it is not positive, and between
+// minChunkSize and maxChunkSize otherwise.
+func clampChunkSize(requested int32, defaultSize int) int {
+	if requested <= 0 {
+		return defaultSize
+	}
+	if requested < minChunkSize {
+		return minChunkSize
+	}
+	if requested > maxChunkSize {
+		return maxChunkSize
+	}
+	return int(requested)
+}
+
 // blobStreamReader implements a ReadCloser which receives
 // gRPC streaming messages.
 var _ io.ReadCloser = &blobStreamReader{}
@@ -67,6 +98,28 @@ func newPutStreamReader(client blobspb.Blob_PutStreamServer) io.ReadCloser {
 	return &blobStreamReader{stream: client}
 }
 
+// writeBlobReceiver adapts a WriteBlob stream, past its first message, so
+// that a blobStreamReader can receive from it. The response is sent by
+// WriteBlob itself, once the file has been written.
+type writeBlobReceiver struct {
+	stream blobspb.Blob_WriteBlobServer
+}
+
+func (r writeBlobReceiver) Recv() (*blobspb.StreamChunk, error) {
+	chunk, err := r.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if chunk.Filename != "" {
+		return nil, invalidArgumentf("only the first message can carry the filename")
+	}
+	return &blobspb.StreamChunk{Payload: chunk.Payload}, nil
+}
+
+func (writeBlobReceiver) SendAndClose(*blobspb.StreamResponse) error {
+	return nil
+}
+
 type blobStreamReader struct {
 	lastPayload []byte
 	lastOffset  int
@@ -123,11 +176,16 @@ type streamSender interface {
 	Send(*blobspb.StreamChunk) error
 }
 
-// streamContent splits the content into chunks, of size `chunkSize`,
-// and streams those chunks to sender.
+// streamContent splits the content into chunks, of size `size` or
+// `chunkSize` if size is not positive, and streams those chunks to sender.
 // Note: This does not close the stream.
-func streamContent(sender streamSender, content io.Reader) error {
-	payload := make([]byte, chunkSize)
+func streamContent(sender streamSender, content io.Reader, size int) error {
+	if size <= 0 {
+		size = chunkSize
+	}
+	buf := buffers.get(size)
+	defer buffers.put(buf)
+	payload := *buf
 	var chunk blobspb.StreamChunk
 	for {
 		n, err := content.Read(payload)
@@ -143,3 +201,93 @@ func streamContent(sender streamSender, content io.Reader) error {
 		}
 	}
 }
+
+// streamCompressedContent is like streamContent, but gzip compresses the
+// content before splitting it into chunks.
+func streamCompressedContent(sender streamSender, content io.Reader, size int) error {
+	w := newChunkWriter(sender, size)
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, content); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// chunkWriter is an io.Writer which buffers what is written to it and sends
+// it to sender in chunks of a fixed size.
+type chunkWriter struct {
+	sender streamSender
+	chunk  blobspb.StreamChunk
+}
+
+func newChunkWriter(sender streamSender, size int) *chunkWriter {
+	if size <= 0 {
+		size = chunkSize
+	}
+	return &chunkWriter{
+		sender: sender,
+		chunk:  blobspb.StreamChunk{Payload: make([]byte, 0, size)},
+	}
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		buf := w.chunk.Payload
+		l := copy(buf[len(buf):cap(buf)], p)
+		w.chunk.Payload = buf[:len(buf)+l]
+		p = p[l:]
+		n += l
+		if len(w.chunk.Payload) == cap(w.chunk.Payload) {
+			if err := w.Flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Flush sends the buffered content, if any.
+func (w *chunkWriter) Flush() error {
+	if len(w.chunk.Payload) == 0 {
+		return nil
+	}
+	err := w.sender.Send(&w.chunk)
+	w.chunk.Payload = w.chunk.Payload[:0]
+	return err
+}
+
+// contextReader is an io.Reader which stops reading once its context is
+// done, so that copying from it stops promptly when an RPC is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// maxSizeReader is an io.Reader which fails with tooLarge once more than max
+// bytes have been read from it.
+type maxSizeReader struct {
+	r        io.Reader
+	max      int64
+	read     int64
+	tooLarge error
+}
+
+func (r *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.read += int64(n)
+	if r.read > r.max {
+		return 0, r.tooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package blobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// blobOp is an RPC handled by the blob service. It is traced in a span of its
+// own, and counted in the service's metrics once it finishes.
+type blobOp struct {
+	sp      *tracing.Span
+	count   *metric.Counter
+	latency *metric.Histogram
+	start   time.Time
+	// bytes is the number of bytes of file contents read or written by the RPC.
+	bytes int64
+}
+
+// startOp starts an RPC named opName, e.g. "blob.Get", on filename, which is
+// empty if the RPC is not about a single file. The span of the RPC is a child
+// of the span of ctx, if there is one, and is held by the returned context.
+// The RPC must be ended with finish.
+func startOp(
+	ctx context.Context,
+	opName, filename string,
+	count *metric.Counter,
+	latency *metric.Histogram,
+) (context.Context, blobOp) {
+	op := blobOp{count: count, latency: latency, start: timeutil.Now()}
+	ctx, op.sp = tracing.ChildSpan(ctx, opName)
+	if filename != "" {
+		op.setTag("filename", filename)
+	}
+	return ctx, op
+}
+
+// setTag sets a tag on the span of the RPC.
+func (op *blobOp) setTag(key, value string) {
+	op.sp.SetTag(key, attribute.StringValue(value))
+}
+
+// addBytes adds n to the number of bytes read or written by the RPC.
+func (op *blobOp) addBytes(n int64) {
+	op.bytes += n
+}
+
+// finish ends the RPC, err being the error it returned, if any.
+func (op *blobOp) finish(err error) {
+	elapsed := timeutil.Since(op.start)
+	op.count.Inc(1)
+	op.latency.RecordValue(elapsed.Nanoseconds())
+	if op.sp == nil {
+		return
+	}
+	op.sp.SetTag("bytes", attribute.Int64Value(op.bytes))
+	op.sp.SetTag("duration", attribute.StringValue(elapsed.String()))
+	if err != nil {
+		op.sp.SetTag("error", attribute.StringValue(err.Error()))
+		op.sp.Recordf("%v", err)
+	}
+	op.sp.Finish()
+}
@@ -948,8 +948,10 @@ the root of the first store.`,
 		Description: `
 The local file path under which remotely-initiated operations that can specify
 node-local I/O paths, such as BACKUP, RESTORE or IMPORT, can access files.
-Following symlinks _is_ allowed, meaning that other paths can be added by
-symlinking to them from within this path.
+Symlinks within this path are only followed if they resolve to a location that
+is also within this path; other paths can no longer be added by symlinking to
+them. To give access to another location, point this flag at it instead, or at
+a directory that contains it.
 <PRE>
 
 </PRE>
@@ -63,9 +63,9 @@ func Example_nodelocal_disabled() {
 
 	// Output:
 	// nodelocal upload empty.csv /test/file1.csv
-	// ERROR: local file access is disabled
+	// ERROR: external I/O is disabled on this node
 	// nodelocal upload test.csv /test/file1.csv
-	// ERROR: local file access is disabled
+	// ERROR: external I/O is disabled on this node
 }
 
 func TestNodeLocalFileUpload(t *testing.T) {
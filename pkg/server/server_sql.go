@@ -355,11 +355,15 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*SQLServer, error) {
 		}
 	}
 	// Create blob service for inter-node file sharing.
-	blobService, err := blobs.NewBlobService(cfg.Settings.ExternalIODir)
+	blobService, err := blobs.NewBlobService(cfg.Settings.ExternalIODir, blobs.ServiceOptions{
+		HistogramWindowInterval: cfg.HistogramWindowInterval(),
+		Settings:                cfg.Settings,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "creating blob service")
 	}
 	blobspb.RegisterBlobServer(cfg.grpcServer, blobService)
+	cfg.registry.AddMetricStruct(blobService.Metrics())
 
 	// Create trace service for inter-node sharing of inflight trace spans.
 	tracingService := service.New(cfg.Tracer)
@@ -806,6 +810,8 @@ func newSQLServer(ctx context.Context, cfg sqlServerArgs) (*SQLServer, error) {
 		ieFactory,
 		sql.ValidateForwardIndexes,
 		sql.ValidateInvertedIndexes,
+		sql.ValidateForeignKey,
+		sql.ValidateUniqueConstraint,
 		sql.NewFakeSessionData,
 	)
 	execCfg.InternalExecutorFactory = ieFactory
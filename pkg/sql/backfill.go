@@ -1765,6 +1765,7 @@ func ValidateForwardIndexes(
 							idx.GetPredicate(),
 							ie,
 							txn,
+							sessiondata.NodeUserSessionDataOverride,
 						); err != nil {
 							return err
 						}
@@ -2269,7 +2270,9 @@ func validateFkInTxn(
 	}
 	ie := ief(ctx, sd)
 	return ie.WithSyntheticDescriptors(syntheticDescs, func() error {
-		return validateForeignKey(ctx, tableDesc, fk, ie, txn, codec)
+		return validateForeignKey(
+			ctx, tableDesc, fk, ie, txn, codec, sessiondata.NodeUserSessionDataOverride,
+		)
 	})
 }
 
@@ -2310,7 +2313,10 @@ func validateUniqueWithoutIndexConstraintInTxn(
 	}
 
 	return ie.WithSyntheticDescriptors(syntheticDescs, func() error {
-		return validateUniqueConstraint(ctx, tableDesc, uc.Name, uc.ColumnIDs, uc.Predicate, ie, txn)
+		return validateUniqueConstraint(
+			ctx, tableDesc, uc.Name, uc.ColumnIDs, uc.Predicate, ie, txn,
+			sessiondata.NodeUserSessionDataOverride,
+		)
 	})
 }
 
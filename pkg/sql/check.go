@@ -235,11 +235,12 @@ func nonMatchingRowQuery(
 // reuse an existing kv.Txn safely.
 func validateForeignKey(
 	ctx context.Context,
-	srcTable *tabledesc.Mutable,
+	srcTable catalog.TableDescriptor,
 	fk *descpb.ForeignKeyConstraint,
 	ie sqlutil.InternalExecutor,
 	txn *kv.Txn,
 	codec keys.SQLCodec,
+	execOverride sessiondata.InternalExecutorOverride,
 ) error {
 	targetTable, err := catalogkv.MustGetTableDescByID(ctx, txn, codec, fk.ReferencedTableID)
 	if err != nil {
@@ -265,13 +266,13 @@ func validateForeignKey(
 
 		log.Infof(ctx, "validating MATCH FULL FK %q (%q [%v] -> %q [%v]) with query %q",
 			fk.Name,
-			srcTable.Name, colNames,
+			srcTable.GetName(), colNames,
 			targetTable.GetName(), referencedColumnNames,
 			query,
 		)
 
 		values, err := ie.QueryRowEx(ctx, "validate foreign key constraint",
-			txn, sessiondata.NodeUserSessionDataOverride, query)
+			txn, execOverride, query)
 		if err != nil {
 			return err
 		}
@@ -292,23 +293,62 @@ func validateForeignKey(
 
 	log.Infof(ctx, "validating FK %q (%q [%v] -> %q [%v]) with query %q",
 		fk.Name,
-		srcTable.Name, colNames, targetTable.GetName(), referencedColumnNames,
+		srcTable.GetName(), colNames, targetTable.GetName(), referencedColumnNames,
 		query,
 	)
 
 	values, err := ie.QueryRowEx(ctx, "validate fk constraint", txn,
-		sessiondata.NodeUserSessionDataOverride, query)
+		execOverride, query)
 	if err != nil {
 		return err
 	}
 	if values.Len() > 0 {
 		return pgerror.WithConstraintName(pgerror.Newf(pgcode.ForeignKeyViolation,
 			"foreign key violation: %q row %s has no match in %q",
-			srcTable.Name, formatValues(colNames, values), targetTable.GetName()), fk.Name)
+			srcTable.GetName(), formatValues(colNames, values), targetTable.GetName()), fk.Name)
 	}
 	return nil
 }
 
+// ValidateForeignKey verifies that all the rows of tbl have a matching row in
+// the table referenced by fk, which is an outbound foreign key of tbl. The
+// validation query runs in a transaction provided by runHistoricalTxn.
+func ValidateForeignKey(
+	ctx context.Context,
+	codec keys.SQLCodec,
+	tbl catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	runHistoricalTxn sqlutil.HistoricalInternalExecTxnRunner,
+	execOverride sessiondata.InternalExecutorOverride,
+) error {
+	return runHistoricalTxn(ctx, func(
+		ctx context.Context, txn *kv.Txn, ie sqlutil.InternalExecutor,
+	) error {
+		return validateForeignKey(ctx, tbl, fk, ie, txn, codec, execOverride)
+	})
+}
+
+// ValidateUniqueConstraint verifies that all the rows of tbl which satisfy
+// pred, if it is not empty, have unique values for columnIDs. The validation
+// query runs in a transaction provided by runHistoricalTxn.
+func ValidateUniqueConstraint(
+	ctx context.Context,
+	tbl catalog.TableDescriptor,
+	constraintName string,
+	columnIDs []descpb.ColumnID,
+	pred string,
+	runHistoricalTxn sqlutil.HistoricalInternalExecTxnRunner,
+	execOverride sessiondata.InternalExecutorOverride,
+) error {
+	return runHistoricalTxn(ctx, func(
+		ctx context.Context, txn *kv.Txn, ie sqlutil.InternalExecutor,
+	) error {
+		return validateUniqueConstraint(
+			ctx, tbl, constraintName, columnIDs, pred, ie, txn, execOverride,
+		)
+	})
+}
+
 // duplicateRowQuery generates and returns a query for column values that
 // violate the specified unique constraint. Rows in the table with any null
 // values in the key are excluded from matching.
@@ -377,6 +417,7 @@ func validateUniqueConstraint(
 	pred string,
 	ie sqlutil.InternalExecutor,
 	txn *kv.Txn,
+	execOverride sessiondata.InternalExecutorOverride,
 ) error {
 	query, colNames, err := duplicateRowQuery(
 		srcTable, columnIDs, pred, true, /* limitResults */
@@ -393,7 +434,7 @@ func validateUniqueConstraint(
 	)
 
 	values, err := ie.QueryRowEx(ctx, "validate unique constraint", txn,
-		sessiondata.NodeUserSessionDataOverride, query)
+		execOverride, query)
 	if err != nil {
 		return err
 	}
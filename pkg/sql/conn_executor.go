@@ -2948,7 +2948,8 @@ func (ex *connExecutor) runPreCommitStages(ctx context.Context) error {
 	)
 
 	after, jobID, err := scrun.RunPreCommitPhase(
-		ctx, ex.server.cfg.DeclarativeSchemaChangerTestingKnobs, deps, scs.state,
+		ctx, ex.server.cfg.DeclarativeSchemaChangerTestingKnobs, ex.server.cfg.Settings,
+		deps, scs.state,
 	)
 	if err != nil {
 		return err
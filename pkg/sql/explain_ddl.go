@@ -73,6 +73,13 @@ func (n *explainDDLNode) startExec(params runParams) error {
 	if err != nil {
 		return errors.WithAssertionFailure(err)
 	}
+	if n.options.Flags[tree.ExplainFlagVerbose] {
+		// Rather than a diagram, render the stages and their ops as text.
+		n.values = tree.Datums{
+			tree.NewDString(sc.Explain().String()),
+		}
+		return nil
+	}
 	var vizURL string
 	if n.options.Flags[tree.ExplainFlagDeps] {
 		if vizURL, err = scgraphviz.DependenciesURL(sc); err != nil {
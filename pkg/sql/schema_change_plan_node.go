@@ -138,7 +138,8 @@ func (s *schemaChangePlanNode) startExec(params runParams) error {
 		p.User(), p.ExecCfg(), p.Txn(), p.Descriptors(), p.EvalContext(), scs.jobID, scs.stmts,
 	)
 	after, jobID, err := scrun.RunStatementPhase(
-		params.ctx, p.ExecCfg().DeclarativeSchemaChangerTestingKnobs, runDeps, s.plannedState,
+		params.ctx, p.ExecCfg().DeclarativeSchemaChangerTestingKnobs, p.ExecCfg().Settings,
+		runDeps, s.plannedState,
 	)
 	if err != nil {
 		return err
@@ -162,6 +163,7 @@ func newSchemaChangerTxnRunDependencies(
 		txn,
 		user,
 		descriptors,
+		execCfg.InternalExecutor,
 		execCfg.JobRegistry,
 		execCfg.IndexBackfiller,
 		// Use a no-op tracker and flusher because while backfilling in a
@@ -141,13 +141,13 @@ func execStatementWithTestDeps(
 		// Run statement phase.
 		deps.IncrementPhase()
 		deps.LogSideEffectf("# begin %s", deps.Phase())
-		state, _, err = scrun.RunStatementPhase(ctx, s.TestingKnobs(), s, state)
+		state, _, err = scrun.RunStatementPhase(ctx, s.TestingKnobs(), s.ClusterSettings(), s, state)
 		require.NoError(t, err, "error in %s", s.Phase())
 		deps.LogSideEffectf("# end %s", deps.Phase())
 		// Run pre-commit phase.
 		deps.IncrementPhase()
 		deps.LogSideEffectf("# begin %s", deps.Phase())
-		state, jobID, err = scrun.RunPreCommitPhase(ctx, s.TestingKnobs(), s, state)
+		state, jobID, err = scrun.RunPreCommitPhase(ctx, s.TestingKnobs(), s.ClusterSettings(), s, state)
 		require.NoError(t, err, "error in %s", s.Phase())
 		deps.LogSideEffectf("# end %s", deps.Phase())
 	})
@@ -219,7 +219,13 @@ func findOrAddColumnFamily(
 	if !create {
 		panic(errors.Errorf("unknown family %q", family))
 	}
-	return b.NextColumnFamilyID(table)
+	familyID = b.NextColumnFamilyID(table)
+	b.EnqueueAdd(&scpb.ColumnFamily{
+		TableID:  table.GetID(),
+		FamilyID: familyID,
+		Name:     family,
+	})
+	return familyID
 }
 
 func maybeAddSequenceReferenceDependencies(
@@ -278,7 +278,8 @@ func decomposeColumnIntoElements(
 			seqID := column.GetOwnsSequenceID(seqOrd)
 			// Remove dependencies to this sequences.
 			sequenceOwnedBy := &scpb.SequenceOwnedBy{SequenceID: seqID,
-				OwnerTableID: tbl.GetID()}
+				OwnerTableID:  tbl.GetID(),
+				OwnerColumnID: column.GetID()}
 			addOrDropForDir(b, dir, sequenceOwnedBy)
 		}
 	}
@@ -328,8 +329,9 @@ func decomposeSequenceDescToElements(
 ) {
 	if seq.GetSequenceOpts().SequenceOwner.OwnerTableID != descpb.InvalidID {
 		sequenceOwnedBy := &scpb.SequenceOwnedBy{
-			SequenceID:   seq.GetID(),
-			OwnerTableID: seq.GetSequenceOpts().SequenceOwner.OwnerTableID}
+			SequenceID:    seq.GetID(),
+			OwnerTableID:  seq.GetSequenceOpts().SequenceOwner.OwnerTableID,
+			OwnerColumnID: seq.GetSequenceOpts().SequenceOwner.OwnerColumnID}
 		if !b.HasTarget(dir, sequenceOwnedBy) {
 			addOrDropForDir(b, dir, sequenceOwnedBy)
 		}
@@ -449,6 +451,8 @@ func decomposeTableDescToElements(
 			ConstraintOrdinal: uint32(idx),
 			IndexID:           0, // Invalid ID
 			ColumnIDs:         constraint.ColumnIDs,
+			Name:              constraint.Name,
+			Predicate:         constraint.Predicate,
 		}
 		addOrDropForDir(b, dir, uniqueWithoutConstraint)
 		addOrDropForDir(b, dir, constraintName)
@@ -41,12 +41,14 @@ func (b buildCtx) NextColumnID(tbl catalog.TableDescriptor) descpb.ColumnID {
 // interface.
 func (b buildCtx) NextColumnFamilyID(tbl catalog.TableDescriptor) descpb.FamilyID {
 	nextFamilyID := tbl.GetNextFamilyID()
-	scpb.ForEachColumn(b, func(status scpb.Status, dir scpb.Target_Direction, column *scpb.Column) {
-		if dir != scpb.Target_ADD || column.TableID != tbl.GetID() {
+	scpb.ForEachColumnFamily(b, func(
+		_ scpb.Status, dir scpb.Target_Direction, family *scpb.ColumnFamily,
+	) {
+		if dir != scpb.Target_ADD || family.TableID != tbl.GetID() {
 			return
 		}
-		if column.FamilyID > nextFamilyID {
-			nextFamilyID = column.FamilyID
+		if family.FamilyID >= nextFamilyID {
+			nextFamilyID = family.FamilyID + 1
 		}
 	})
 	return nextFamilyID
@@ -28,6 +28,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scexec/scmutationexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/errors"
 )
 
@@ -48,6 +50,7 @@ func NewExecutorDependencies(
 	txn *kv.Txn,
 	user security.SQLUsername,
 	descsCollection *descs.Collection,
+	internalExecutor sqlutil.InternalExecutor,
 	jobRegistry JobRegistry,
 	backfiller scexec.Backfiller,
 	backfillTracker scexec.BackfillTracker,
@@ -63,6 +66,7 @@ func NewExecutorDependencies(
 			txn:                txn,
 			codec:              codec,
 			descsCollection:    descsCollection,
+			internalExecutor:   internalExecutor,
 			jobRegistry:        jobRegistry,
 			indexValidator:     indexValidator,
 			eventLogger:        eventLogger,
@@ -81,6 +85,7 @@ type txnDeps struct {
 	txn                *kv.Txn
 	codec              keys.SQLCodec
 	descsCollection    *descs.Collection
+	internalExecutor   sqlutil.InternalExecutor
 	jobRegistry        JobRegistry
 	indexValidator     scexec.IndexValidator
 	eventLogger        scexec.EventLogger
@@ -224,6 +229,14 @@ func (b *catalogChangeBatcher) DeleteName(
 	return nil
 }
 
+// AddName implements the scexec.CatalogChangeBatcher interface.
+func (b *catalogChangeBatcher) AddName(
+	ctx context.Context, nameInfo descpb.NameInfo, id descpb.ID,
+) error {
+	b.batch.CPut(catalogkeys.EncodeNameKey(b.codec, nameInfo), id, nil /* expValue */)
+	return nil
+}
+
 // DeleteDescriptor implements the scexec.CatalogChangeBatcher interface.
 func (b *catalogChangeBatcher) DeleteDescriptor(ctx context.Context, id descpb.ID) error {
 	b.batch.Del(catalogkeys.MakeDescMetadataKey(b.codec, id))
@@ -370,6 +383,48 @@ func (d *execDeps) EventLogger() scexec.EventLogger {
 	return d.eventLogger
 }
 
+// CommentUpdater implements the scexec.Dependencies interface.
+func (d *execDeps) CommentUpdater() scexec.CommentUpdater {
+	return d
+}
+
+var _ scexec.CommentUpdater = (*txnDeps)(nil)
+
+// UpsertComment implements the scexec.CommentUpdater interface.
+func (d *txnDeps) UpsertComment(
+	ctx context.Context, commentType int, objID descpb.ID, subID uint32, comment string,
+) error {
+	_, err := d.internalExecutor.ExecEx(
+		ctx,
+		"upsert-comment",
+		d.txn,
+		sessiondata.InternalExecutorOverride{User: security.RootUserName()},
+		"UPSERT INTO system.comments VALUES ($1, $2, $3, $4)",
+		commentType,
+		objID,
+		subID,
+		comment,
+	)
+	return err
+}
+
+// DeleteComment implements the scexec.CommentUpdater interface.
+func (d *txnDeps) DeleteComment(
+	ctx context.Context, commentType int, objID descpb.ID, subID uint32,
+) error {
+	_, err := d.internalExecutor.ExecEx(
+		ctx,
+		"delete-comment",
+		d.txn,
+		sessiondata.InternalExecutorOverride{User: security.RootUserName()},
+		"DELETE FROM system.comments WHERE type=$1 AND object_id=$2 AND sub_id=$3",
+		commentType,
+		objID,
+		subID,
+	)
+	return err
+}
+
 // NewNoOpBackfillTracker constructs a backfill tracker which does not do
 // anything. It will always return progress for a given backfill which
 // contains a full set of CompletedSpans corresponding to the source index
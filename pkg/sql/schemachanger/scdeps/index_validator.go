@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
@@ -46,18 +47,42 @@ type ValidateInvertedIndexesFn func(
 	execOverride sessiondata.InternalExecutorOverride,
 ) error
 
+// ValidateForeignKeyFn callback function for validating foreign keys.
+type ValidateForeignKeyFn func(
+	ctx context.Context,
+	codec keys.SQLCodec,
+	tbl catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	runHistoricalTxn sqlutil.HistoricalInternalExecTxnRunner,
+	execOverride sessiondata.InternalExecutorOverride,
+) error
+
+// ValidateUniqueConstraintFn callback function for validating unique
+// constraints without an index.
+type ValidateUniqueConstraintFn func(
+	ctx context.Context,
+	tbl catalog.TableDescriptor,
+	constraintName string,
+	columnIDs []descpb.ColumnID,
+	pred string,
+	runHistoricalTxn sqlutil.HistoricalInternalExecTxnRunner,
+	execOverride sessiondata.InternalExecutorOverride,
+) error
+
 // NewFakeSessionDataFn callback function used to create session data
 // for the internal executor.
 type NewFakeSessionDataFn func(sv *settings.Values) *sessiondata.SessionData
 
 type indexValidator struct {
-	db                      *kv.DB
-	codec                   keys.SQLCodec
-	settings                *cluster.Settings
-	ieFactory               sqlutil.SessionBoundInternalExecutorFactory
-	validateForwardIndexes  ValidateForwardIndexesFn
-	validateInvertedIndexes ValidateInvertedIndexesFn
-	newFakeSessionData      NewFakeSessionDataFn
+	db                       *kv.DB
+	codec                    keys.SQLCodec
+	settings                 *cluster.Settings
+	ieFactory                sqlutil.SessionBoundInternalExecutorFactory
+	validateForwardIndexes   ValidateForwardIndexesFn
+	validateInvertedIndexes  ValidateInvertedIndexesFn
+	validateForeignKey       ValidateForeignKeyFn
+	validateUniqueConstraint ValidateUniqueConstraintFn
+	newFakeSessionData       NewFakeSessionDataFn
 }
 
 // ValidateForwardIndexes checks that the indexes have entries for all the rows.
@@ -102,6 +127,48 @@ func (iv indexValidator) ValidateInvertedIndexes(
 	return iv.validateInvertedIndexes(ctx, iv.codec, tbl, indexes, txnRunner, withFirstMutationPublic, gatherAllInvalid, override)
 }
 
+// ValidateForeignKey checks that all the rows of the table have a match in the
+// table referenced by the foreign key.
+func (iv indexValidator) ValidateForeignKey(
+	ctx context.Context,
+	tbl catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	override sessiondata.InternalExecutorOverride,
+) error {
+	// Set up a new transaction with the current timestamp.
+	txnRunner := func(ctx context.Context, fn sqlutil.InternalExecFn) error {
+		validationTxn := iv.db.NewTxn(ctx, "validation")
+		err := validationTxn.SetFixedTimestamp(ctx, iv.db.Clock().Now())
+		if err != nil {
+			return err
+		}
+		return fn(ctx, validationTxn, iv.ieFactory(ctx, iv.newFakeSessionData(&iv.settings.SV)))
+	}
+	return iv.validateForeignKey(ctx, iv.codec, tbl, fk, txnRunner, override)
+}
+
+// ValidateUniqueConstraint checks that the rows of the table have unique values
+// for the columns of the constraint.
+func (iv indexValidator) ValidateUniqueConstraint(
+	ctx context.Context,
+	tbl catalog.TableDescriptor,
+	uc *descpb.UniqueWithoutIndexConstraint,
+	override sessiondata.InternalExecutorOverride,
+) error {
+	// Set up a new transaction with the current timestamp.
+	txnRunner := func(ctx context.Context, fn sqlutil.InternalExecFn) error {
+		validationTxn := iv.db.NewTxn(ctx, "validation")
+		err := validationTxn.SetFixedTimestamp(ctx, iv.db.Clock().Now())
+		if err != nil {
+			return err
+		}
+		return fn(ctx, validationTxn, iv.ieFactory(ctx, iv.newFakeSessionData(&iv.settings.SV)))
+	}
+	return iv.validateUniqueConstraint(
+		ctx, tbl, uc.Name, uc.ColumnIDs, uc.Predicate, txnRunner, override,
+	)
+}
+
 // NewIndexValidator creates a IndexValidator interface
 // for the new schema changer.
 func NewIndexValidator(
@@ -111,15 +178,19 @@ func NewIndexValidator(
 	ieFactory sqlutil.SessionBoundInternalExecutorFactory,
 	validateForwardIndexes ValidateForwardIndexesFn,
 	validateInvertedIndexes ValidateInvertedIndexesFn,
+	validateForeignKey ValidateForeignKeyFn,
+	validateUniqueConstraint ValidateUniqueConstraintFn,
 	newFakeSessionData NewFakeSessionDataFn,
 ) scexec.IndexValidator {
 	return indexValidator{
-		db:                      db,
-		codec:                   codec,
-		settings:                settings,
-		ieFactory:               ieFactory,
-		validateForwardIndexes:  validateForwardIndexes,
-		validateInvertedIndexes: validateInvertedIndexes,
-		newFakeSessionData:      newFakeSessionData,
+		db:                       db,
+		codec:                    codec,
+		settings:                 settings,
+		ieFactory:                ieFactory,
+		validateForwardIndexes:   validateForwardIndexes,
+		validateInvertedIndexes:  validateInvertedIndexes,
+		validateForeignKey:       validateForeignKey,
+		validateUniqueConstraint: validateUniqueConstraint,
+		newFakeSessionData:       newFakeSessionData,
 	}
 }
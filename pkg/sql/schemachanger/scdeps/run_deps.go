@@ -97,6 +97,7 @@ func (d *jobExecutionDeps) WithTxnInJob(ctx context.Context, fn scrun.JobTxnFunc
 				txn:                txn,
 				codec:              d.codec,
 				descsCollection:    descriptors,
+				internalExecutor:   d.internalExecutor,
 				jobRegistry:        d.jobRegistry,
 				indexValidator:     d.indexValidator,
 				eventLogger:        d.eventLoggerFactory(txn),
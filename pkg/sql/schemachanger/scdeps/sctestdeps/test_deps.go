@@ -521,6 +521,7 @@ func (s *TestState) NewCatalogChangeBatcher() scexec.CatalogChangeBatcher {
 	return &testCatalogChangeBatcher{
 		s:             s,
 		namesToDelete: make(map[descpb.NameInfo]descpb.ID),
+		namesToAdd:    make(map[descpb.NameInfo]descpb.ID),
 	}
 }
 
@@ -528,6 +529,7 @@ type testCatalogChangeBatcher struct {
 	s                   *TestState
 	descs               []catalog.Descriptor
 	namesToDelete       map[descpb.NameInfo]descpb.ID
+	namesToAdd          map[descpb.NameInfo]descpb.ID
 	descriptorsToDelete catalog.DescriptorIDSet
 }
 
@@ -549,6 +551,14 @@ func (b *testCatalogChangeBatcher) DeleteName(
 	return nil
 }
 
+// AddName implements the scexec.CatalogChangeBatcher interface.
+func (b *testCatalogChangeBatcher) AddName(
+	ctx context.Context, nameInfo descpb.NameInfo, id descpb.ID,
+) error {
+	b.namesToAdd[nameInfo] = id
+	return nil
+}
+
 // DeleteDescriptor implements the scexec.CatalogChangeBatcher interface.
 func (b *testCatalogChangeBatcher) DeleteDescriptor(ctx context.Context, id descpb.ID) error {
 	b.descriptorsToDelete.Add(id)
@@ -575,17 +585,28 @@ func (b *testCatalogChangeBatcher) ValidateAndRun(ctx context.Context) error {
 			return errors.AssertionFailedf(
 				"expected deleted namespace entry %v to have ID %d, instead is %d", nameInfo, expectedID, actualID)
 		}
-		nameType := "object"
-		if nameInfo.ParentSchemaID == 0 {
-			if nameInfo.ParentID == 0 {
-				nameType = "database"
-			} else {
-				nameType = "schema"
-			}
-		}
-		b.s.LogSideEffectf("delete %s namespace entry %v -> %d", nameType, nameInfo, expectedID)
+		b.s.LogSideEffectf("delete %s namespace entry %v -> %d",
+			namespaceEntryType(nameInfo), nameInfo, expectedID)
 		delete(b.s.namespace, nameInfo)
 	}
+	names = names[:0]
+	for nameInfo := range b.namesToAdd {
+		names = append(names, nameInfo)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return b.namesToAdd[names[i]] < b.namesToAdd[names[j]]
+	})
+	for _, nameInfo := range names {
+		id := b.namesToAdd[nameInfo]
+		if existingID, hasEntry := b.s.namespace[nameInfo]; hasEntry {
+			return errors.AssertionFailedf(
+				"cannot add namespace entry %v -> %d, it already exists with ID %d",
+				nameInfo, id, existingID)
+		}
+		b.s.LogSideEffectf("add %s namespace entry %v -> %d",
+			namespaceEntryType(nameInfo), nameInfo, id)
+		b.s.namespace[nameInfo] = id
+	}
 	for _, desc := range b.descs {
 		var old protoutil.Message
 		if b := descBuilder(b.s.descriptors, desc.GetID()); b != nil {
@@ -605,6 +626,17 @@ func (b *testCatalogChangeBatcher) ValidateAndRun(ctx context.Context) error {
 	return catalog.Validate(ctx, b.s, catalog.NoValidationTelemetry, catalog.ValidationLevelAllPreTxnCommit, b.descs...).CombinedError()
 }
 
+// namespaceEntryType returns the kind of descriptor a namespace entry is for.
+func namespaceEntryType(nameInfo descpb.NameInfo) string {
+	if nameInfo.ParentSchemaID == 0 {
+		if nameInfo.ParentID == 0 {
+			return "database"
+		}
+		return "schema"
+	}
+	return "object"
+}
+
 var _ catalog.DescGetter = (*TestState)(nil)
 
 // GetDesc implements the catalog.DescGetter interface.
@@ -811,6 +843,28 @@ func (s *TestState) ValidateInvertedIndexes(
 	return nil
 }
 
+// ValidateForeignKey implements the index validator interface.
+func (s *TestState) ValidateForeignKey(
+	_ context.Context,
+	tbl catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	_ sessiondata.InternalExecutorOverride,
+) error {
+	s.LogSideEffectf("validate foreign key %q in table #%d", fk.Name, tbl.GetID())
+	return nil
+}
+
+// ValidateUniqueConstraint implements the index validator interface.
+func (s *TestState) ValidateUniqueConstraint(
+	_ context.Context,
+	tbl catalog.TableDescriptor,
+	uc *descpb.UniqueWithoutIndexConstraint,
+	_ sessiondata.InternalExecutorOverride,
+) error {
+	s.LogSideEffectf("validate unique constraint %q in table #%d", uc.Name, tbl.GetID())
+	return nil
+}
+
 // IndexValidator implements the scexec.Dependencies interface.
 func (s *TestState) IndexValidator() scexec.IndexValidator {
 	return s
@@ -829,3 +883,26 @@ func (s *TestState) LogEvent(
 func (s *TestState) EventLogger() scexec.EventLogger {
 	return s
 }
+
+// UpsertComment implements scexec.CommentUpdater
+func (s *TestState) UpsertComment(
+	_ context.Context, commentType int, objID descpb.ID, subID uint32, comment string,
+) error {
+	s.LogSideEffectf("upsert comment %q of type %d for descriptor #%d sub-ID %d",
+		comment, commentType, objID, subID)
+	return nil
+}
+
+// DeleteComment implements scexec.CommentUpdater
+func (s *TestState) DeleteComment(
+	_ context.Context, commentType int, objID descpb.ID, subID uint32,
+) error {
+	s.LogSideEffectf("delete comment of type %d for descriptor #%d sub-ID %d",
+		commentType, objID, subID)
+	return nil
+}
+
+// CommentUpdater implements scexec.Dependencies
+func (s *TestState) CommentUpdater() scexec.CommentUpdater {
+	return s
+}
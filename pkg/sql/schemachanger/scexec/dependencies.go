@@ -37,6 +37,7 @@ type Dependencies interface {
 	IndexValidator() IndexValidator
 	IndexSpanSplitter() IndexSpanSplitter
 	EventLogger() EventLogger
+	CommentUpdater() CommentUpdater
 
 	// Statements returns the statements behind this schema change.
 	Statements() []string
@@ -65,6 +66,16 @@ type EventLogger interface {
 	LogEvent(ctx context.Context, descID descpb.ID, metadata scpb.ElementMetadata, event eventpb.EventPayload) error
 }
 
+// CommentUpdater encapsulates the operations for updating the comments of
+// descriptors and their columns and indexes in system.comments.
+type CommentUpdater interface {
+	// UpsertComment sets the comment of an object, replacing any existing one.
+	UpsertComment(ctx context.Context, commentType int, objID descpb.ID, subID uint32, comment string) error
+
+	// DeleteComment deletes the comment of an object, if any.
+	DeleteComment(ctx context.Context, commentType int, objID descpb.ID, subID uint32) error
+}
+
 // CatalogChangeBatcher encapsulates batched updates to the catalog: descriptor
 // updates, namespace operations, etc.
 type CatalogChangeBatcher interface {
@@ -75,6 +86,9 @@ type CatalogChangeBatcher interface {
 	// DeleteName deletes a namespace entry.
 	DeleteName(ctx context.Context, nameInfo descpb.NameInfo, id descpb.ID) error
 
+	// AddName adds a namespace entry, which must not already exist.
+	AddName(ctx context.Context, nameInfo descpb.NameInfo, id descpb.ID) error
+
 	// DeleteDescriptor deletes a descriptor entry.
 	DeleteDescriptor(ctx context.Context, id descpb.ID) error
 
@@ -139,7 +153,8 @@ type Backfiller interface {
 	) error
 }
 
-// IndexValidator provides interfaces that allow indexes to be validated.
+// IndexValidator provides interfaces that allow indexes, and the constraints
+// which are backed by them, to be validated.
 type IndexValidator interface {
 	ValidateForwardIndexes(
 		ctx context.Context,
@@ -154,6 +169,24 @@ type IndexValidator interface {
 		indexes []catalog.Index,
 		override sessiondata.InternalExecutorOverride,
 	) error
+
+	// ValidateForeignKey checks that all the rows of tbl have a matching row
+	// in the table referenced by fk, an outbound foreign key of tbl.
+	ValidateForeignKey(
+		ctx context.Context,
+		tbl catalog.TableDescriptor,
+		fk *descpb.ForeignKeyConstraint,
+		override sessiondata.InternalExecutorOverride,
+	) error
+
+	// ValidateUniqueConstraint checks that the rows of tbl have unique values
+	// for the columns of uc, a unique constraint without an index of tbl.
+	ValidateUniqueConstraint(
+		ctx context.Context,
+		tbl catalog.TableDescriptor,
+		uc *descpb.UniqueWithoutIndexConstraint,
+		override sessiondata.InternalExecutorOverride,
+	) error
 }
 
 // IndexSpanSplitter can try to split an index span in the current transaction
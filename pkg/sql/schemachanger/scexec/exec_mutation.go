@@ -53,6 +53,13 @@ func executeDescriptorMutationOps(ctx context.Context, deps Dependencies, ops []
 			}
 		}
 	}
+	for id, addedNames := range mvs.addedNames {
+		for _, name := range addedNames {
+			if err := b.AddName(ctx, name, id); err != nil {
+				return err
+			}
+		}
+	}
 	// Any databases being GCed should have an entry even if none of its tables
 	// are being dropped. This entry will be used to generate the GC jobs below.
 	for _, dbID := range mvs.dbGCJobs.Ordered() {
@@ -138,6 +145,18 @@ func executeDescriptorMutationOps(ctx context.Context, deps Dependencies, ops []
 		}
 	}
 
+	for _, c := range mvs.commentUpdates {
+		var err error
+		if c.isRemoval {
+			err = deps.CommentUpdater().DeleteComment(ctx, c.commentType, c.objID, c.subID)
+		} else {
+			err = deps.CommentUpdater().UpsertComment(ctx, c.commentType, c.objID, c.subID, c.comment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, id := range mvs.descriptorsToDelete.Ordered() {
 		if err := b.DeleteDescriptor(ctx, id); err != nil {
 			return err
@@ -149,6 +168,7 @@ func executeDescriptorMutationOps(ctx context.Context, deps Dependencies, ops []
 		) error {
 			progress := *md.Progress
 			progress.GetNewSchemaChange().States = update.progress
+			progress.RunningStatus = update.runningStatus
 			updateProgress(&progress)
 			if !md.Payload.Noncancelable && update.isNonCancelable {
 				setNonCancelable()
@@ -240,6 +260,7 @@ type mutationVisitorState struct {
 	c                       Catalog
 	checkedOutDescriptors   nstree.Map
 	drainedNames            map[descpb.ID][]descpb.NameInfo
+	addedNames              map[descpb.ID][]descpb.NameInfo
 	descriptorsToDelete     catalog.DescriptorIDSet
 	dbGCJobs                catalog.DescriptorIDSet
 	descriptorGCJobs        map[descpb.ID][]jobspb.SchemaChangeGCDetails_DroppedID
@@ -247,6 +268,7 @@ type mutationVisitorState struct {
 	schemaChangerJob        *jobs.Record
 	schemaChangerJobUpdates map[jobspb.JobID]schemaChangerJobUpdate
 	eventsByStatement       map[uint32][]eventPayload
+	commentUpdates          []commentUpdate
 }
 
 type eventPayload struct {
@@ -255,13 +277,22 @@ type eventPayload struct {
 	event    eventpb.EventPayload
 }
 
+type commentUpdate struct {
+	commentType int
+	objID       descpb.ID
+	subID       uint32
+	comment     string
+	isRemoval   bool
+}
+
 type schemaChangerJobUpdate struct {
 	progress        []scpb.Status
 	isNonCancelable bool
+	runningStatus   string
 }
 
 func (mvs *mutationVisitorState) UpdateSchemaChangerJob(
-	jobID jobspb.JobID, statuses []scpb.Status, isNonCancelable bool,
+	jobID jobspb.JobID, statuses []scpb.Status, isNonCancelable bool, runningStatus string,
 ) error {
 	if mvs.schemaChangerJobUpdates == nil {
 		mvs.schemaChangerJobUpdates = make(map[jobspb.JobID]schemaChangerJobUpdate)
@@ -271,6 +302,7 @@ func (mvs *mutationVisitorState) UpdateSchemaChangerJob(
 	mvs.schemaChangerJobUpdates[jobID] = schemaChangerJobUpdate{
 		progress:        statuses,
 		isNonCancelable: isNonCancelable,
+		runningStatus:   runningStatus,
 	}
 	return nil
 }
@@ -279,6 +311,7 @@ func newMutationVisitorState(c Catalog) *mutationVisitorState {
 	return &mutationVisitorState{
 		c:                 c,
 		drainedNames:      make(map[descpb.ID][]descpb.NameInfo),
+		addedNames:        make(map[descpb.ID][]descpb.NameInfo),
 		indexGCJobs:       make(map[descpb.ID][]jobspb.SchemaChangeGCDetails_DroppedIndex),
 		descriptorGCJobs:  make(map[descpb.ID][]jobspb.SchemaChangeGCDetails_DroppedID),
 		eventsByStatement: make(map[uint32][]eventPayload),
@@ -315,6 +348,10 @@ func (mvs *mutationVisitorState) AddDrainedName(id descpb.ID, nameInfo descpb.Na
 	}
 }
 
+func (mvs *mutationVisitorState) AddName(id descpb.ID, nameInfo descpb.NameInfo) {
+	mvs.addedNames[id] = append(mvs.addedNames[id], nameInfo)
+}
+
 func (mvs *mutationVisitorState) AddNewGCJobForTable(table catalog.TableDescriptor) {
 	mvs.descriptorGCJobs[table.GetParentID()] = append(mvs.descriptorGCJobs[table.GetParentID()],
 		jobspb.SchemaChangeGCDetails_DroppedID{
@@ -339,7 +376,7 @@ func (mvs *mutationVisitorState) AddNewGCJobForIndex(
 }
 
 func (mvs *mutationVisitorState) AddNewSchemaChangerJob(
-	jobID jobspb.JobID, state scpb.State,
+	jobID jobspb.JobID, state scpb.State, runningStatus string,
 ) error {
 	if mvs.schemaChangerJob != nil {
 		return errors.AssertionFailedf("cannot create more than one new schema change job")
@@ -372,7 +409,7 @@ func (mvs *mutationVisitorState) AddNewSchemaChangerJob(
 			Authorization: &state.Authorization,
 			Statements:    state.Statements,
 		},
-		RunningStatus: "",
+		RunningStatus: jobs.RunningStatus(runningStatus),
 		NonCancelable: false,
 	}
 	return nil
@@ -419,3 +456,27 @@ func (mvs *mutationVisitorState) EnqueueEvent(
 	)
 	return nil
 }
+
+// UpsertComment implements the scmutationexec.MutationVisitorStateUpdater
+// interface.
+func (mvs *mutationVisitorState) UpsertComment(
+	commentType int, objID descpb.ID, subID uint32, comment string,
+) {
+	mvs.commentUpdates = append(mvs.commentUpdates, commentUpdate{
+		commentType: commentType,
+		objID:       objID,
+		subID:       subID,
+		comment:     comment,
+	})
+}
+
+// RemoveComment implements the scmutationexec.MutationVisitorStateUpdater
+// interface.
+func (mvs *mutationVisitorState) RemoveComment(commentType int, objID descpb.ID, subID uint32) {
+	mvs.commentUpdates = append(mvs.commentUpdates, commentUpdate{
+		commentType: commentType,
+		objID:       objID,
+		subID:       subID,
+		isRemoval:   true,
+	})
+}
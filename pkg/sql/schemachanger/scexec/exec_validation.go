@@ -54,6 +54,64 @@ func executeValidateCheckConstraint(
 	return errors.Errorf("executeValidateCheckConstraint is not implemented")
 }
 
+func executeValidateForeignKey(
+	ctx context.Context, deps Dependencies, op *scop.ValidateForeignKey,
+) error {
+	desc, err := deps.Catalog().MustReadImmutableDescriptor(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	table, ok := desc.(catalog.TableDescriptor)
+	if !ok {
+		return catalog.WrapTableDescRefErr(desc.GetID(), catalog.NewDescriptorTypeError(desc))
+	}
+	var fk *descpb.ForeignKeyConstraint
+	for _, outbound := range table.AllActiveAndInactiveForeignKeys() {
+		if outbound.Name == op.Name {
+			fk = outbound
+			break
+		}
+	}
+	if fk == nil {
+		return errors.AssertionFailedf("foreign key %q does not exist in table %q (%d)",
+			op.Name, table.GetName(), table.GetID())
+	}
+	// Execute the validation operation as a root user.
+	execOverride := sessiondata.InternalExecutorOverride{
+		User: security.RootUserName(),
+	}
+	return deps.IndexValidator().ValidateForeignKey(ctx, table, fk, execOverride)
+}
+
+func executeValidateUniqueConstraint(
+	ctx context.Context, deps Dependencies, op *scop.ValidateUniqueConstraint,
+) error {
+	desc, err := deps.Catalog().MustReadImmutableDescriptor(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	table, ok := desc.(catalog.TableDescriptor)
+	if !ok {
+		return catalog.WrapTableDescRefErr(desc.GetID(), catalog.NewDescriptorTypeError(desc))
+	}
+	var uc *descpb.UniqueWithoutIndexConstraint
+	for _, candidate := range table.AllActiveAndInactiveUniqueWithoutIndexConstraints() {
+		if candidate.Name == op.Name {
+			uc = candidate
+			break
+		}
+	}
+	if uc == nil {
+		return errors.AssertionFailedf("unique constraint %q does not exist in table %q (%d)",
+			op.Name, table.GetName(), table.GetID())
+	}
+	// Execute the validation operation as a root user.
+	execOverride := sessiondata.InternalExecutorOverride{
+		User: security.RootUserName(),
+	}
+	return deps.IndexValidator().ValidateUniqueConstraint(ctx, table, uc, execOverride)
+}
+
 func executeValidationOps(ctx context.Context, deps Dependencies, execute []scop.Op) error {
 	for _, op := range execute {
 		switch op := op.(type) {
@@ -61,6 +119,10 @@ func executeValidationOps(ctx context.Context, deps Dependencies, execute []scop
 			return executeValidateUniqueIndex(ctx, deps, op)
 		case *scop.ValidateCheckConstraint:
 			return executeValidateCheckConstraint(ctx, deps, op)
+		case *scop.ValidateForeignKey:
+			return executeValidateForeignKey(ctx, deps, op)
+		case *scop.ValidateUniqueConstraint:
+			return executeValidateUniqueConstraint(ctx, deps, op)
 		default:
 			panic("unimplemented")
 		}
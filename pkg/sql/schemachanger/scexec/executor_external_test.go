@@ -64,6 +64,7 @@ func (ti testInfra) newExecDeps(
 		txn,
 		security.RootUserName(),
 		descsCollection,
+		ti.ie,
 		noopJobRegistry{}, /* jobRegistry */
 		noopBackfiller{},  /* backfiller */
 		scdeps.NewNoOpBackfillTracker(ti.lm.Codec()),
@@ -451,6 +452,62 @@ func TestSchemaChanger(t *testing.T) {
 		}))
 		ti.tsql.Exec(t, "INSERT INTO db.foo VALUES (1, 1)")
 	})
+	t.Run("drop foreign key", func(t *testing.T) {
+		ti := setupTestInfra(t)
+		defer ti.tc.Stopper().Stop(ctx)
+		ti.tsql.Exec(t, `CREATE DATABASE db`)
+		ti.tsql.Exec(t, `CREATE TABLE db.parent (i INT PRIMARY KEY)`)
+		ti.tsql.Exec(t, `CREATE TABLE db.child (i INT PRIMARY KEY, p INT REFERENCES db.parent)`)
+
+		parentName := tree.MakeTableNameWithSchema("db", tree.PublicSchemaName, "parent")
+		childName := tree.MakeTableNameWithSchema("db", tree.PublicSchemaName, "child")
+		require.NoError(t, ti.txn(ctx, func(
+			ctx context.Context, txn *kv.Txn, descriptors *descs.Collection,
+		) error {
+			_, child, err := descriptors.GetImmutableTableByName(ctx, txn, &childName, tree.ObjectLookupFlagsWithRequired())
+			require.NoError(t, err)
+
+			// Corresponds to dropping the foreign key of the child table, as it
+			// is decomposed into elements.
+			var nodes []*scpb.Node
+			require.NoError(t, child.ForeachOutboundFK(func(fk *descpb.ForeignKeyConstraint) error {
+				nodes = append(nodes, &scpb.Node{
+					Target: scpb.NewTarget(scpb.Target_DROP, &scpb.ForeignKey{
+						OriginID:         fk.OriginTableID,
+						OriginColumns:    fk.OriginColumnIDs,
+						ReferenceColumns: fk.ReferencedColumnIDs,
+						ReferenceID:      fk.ReferencedTableID,
+						OnUpdate:         fk.OnUpdate,
+						OnDelete:         fk.OnDelete,
+						Name:             fk.Name,
+					}, &scpb.TargetMetadata{SourceElementID: 1}),
+					Status: scpb.Status_PUBLIC,
+				})
+				return nil
+			}))
+			require.Len(t, nodes, 1)
+
+			state := scpb.State{Nodes: nodes, Statements: []*scpb.Statement{{}}}
+			sc := sctestutils.MakePlan(t, state, scop.PreCommitPhase)
+			for _, s := range sc.StagesForCurrentPhase() {
+				exDeps := ti.newExecDeps(txn, descriptors)
+				require.NoError(t, scgraphviz.DecorateErrorWithPlanDetails(scexec.ExecuteStage(ctx, exDeps, s.Ops()), sc))
+			}
+			return nil
+		}))
+		require.NoError(t, ti.txn(ctx, func(
+			ctx context.Context, txn *kv.Txn, descriptors *descs.Collection,
+		) error {
+			_, parent, err := descriptors.GetImmutableTableByName(ctx, txn, &parentName, tree.ObjectLookupFlagsWithRequired())
+			require.NoError(t, err)
+			_, child, err := descriptors.GetImmutableTableByName(ctx, txn, &childName, tree.ObjectLookupFlagsWithRequired())
+			require.NoError(t, err)
+			require.Empty(t, child.TableDesc().OutboundFKs)
+			require.Empty(t, parent.TableDesc().InboundFKs)
+			return nil
+		}))
+		ti.tsql.Exec(t, "INSERT INTO db.child VALUES (1, 1)")
+	})
 }
 
 type noopJobRegistry struct{}
@@ -512,6 +569,24 @@ func (noopIndexValidator) ValidateInvertedIndexes(
 	return nil
 }
 
+func (noopIndexValidator) ValidateForeignKey(
+	ctx context.Context,
+	tableDesc catalog.TableDescriptor,
+	fk *descpb.ForeignKeyConstraint,
+	override sessiondata.InternalExecutorOverride,
+) error {
+	return nil
+}
+
+func (noopIndexValidator) ValidateUniqueConstraint(
+	ctx context.Context,
+	tableDesc catalog.TableDescriptor,
+	uc *descpb.UniqueWithoutIndexConstraint,
+	override sessiondata.InternalExecutorOverride,
+) error {
+	return nil
+}
+
 type noopPartitioner struct{}
 
 func (noopPartitioner) AddPartitioning(
@@ -11,10 +11,12 @@
 package scmutationexec
 
 import (
+	"bytes"
 	"context"
 	"sort"
 
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/dbdesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
@@ -74,6 +76,9 @@ type MutationVisitorStateUpdater interface {
 	// AddDrainedName marks a namespace entry as being drained.
 	AddDrainedName(id descpb.ID, nameInfo descpb.NameInfo)
 
+	// AddName enqueues a namespace entry to be added.
+	AddName(id descpb.ID, nameInfo descpb.NameInfo)
+
 	// DeleteDescriptor adds a descriptor for deletion.
 	DeleteDescriptor(id descpb.ID)
 
@@ -87,14 +92,22 @@ type MutationVisitorStateUpdater interface {
 	AddNewGCJobForIndex(tbl catalog.TableDescriptor, index catalog.Index)
 
 	// AddNewSchemaChangerJob adds a schema changer job.
-	AddNewSchemaChangerJob(jobID jobspb.JobID, state scpb.State) error
+	AddNewSchemaChangerJob(jobID jobspb.JobID, state scpb.State, runningStatus string) error
 
-	// UpdateSchemaChangerJob will update the progress and payload of the
-	// schema changer job.
-	UpdateSchemaChangerJob(jobID jobspb.JobID, statuses []scpb.Status, isNonCancelable bool) error
+	// UpdateSchemaChangerJob will update the progress, the running status and
+	// the payload of the schema changer job.
+	UpdateSchemaChangerJob(
+		jobID jobspb.JobID, statuses []scpb.Status, isNonCancelable bool, runningStatus string,
+	) error
 
 	// EnqueueEvent will enqueue an event to be written to the event log.
 	EnqueueEvent(id descpb.ID, metadata *scpb.ElementMetadata, event eventpb.EventPayload) error
+
+	// UpsertComment will enqueue a comment to be written to system.comments.
+	UpsertComment(commentType int, objID descpb.ID, subID uint32, comment string)
+
+	// RemoveComment will enqueue a comment to be deleted from system.comments.
+	RemoveComment(commentType int, objID descpb.ID, subID uint32)
 }
 
 // NewMutationVisitor creates a new scop.MutationVisitor.
@@ -169,13 +182,15 @@ func (m *visitor) swapSchemaChangeJobID(
 func (m *visitor) CreateDeclarativeSchemaChangerJob(
 	ctx context.Context, job scop.CreateDeclarativeSchemaChangerJob,
 ) error {
-	return m.s.AddNewSchemaChangerJob(job.JobID, job.State)
+	return m.s.AddNewSchemaChangerJob(job.JobID, job.State, job.RunningStatus)
 }
 
 func (m *visitor) UpdateSchemaChangerJob(
 	ctx context.Context, progress scop.UpdateSchemaChangerJob,
 ) error {
-	return m.s.UpdateSchemaChangerJob(progress.JobID, progress.Statuses, progress.IsNonCancelable)
+	return m.s.UpdateSchemaChangerJob(
+		progress.JobID, progress.Statuses, progress.IsNonCancelable, progress.RunningStatus,
+	)
 }
 
 func (m *visitor) checkOutTable(ctx context.Context, id descpb.ID) (*tabledesc.Mutable, error) {
@@ -296,6 +311,23 @@ func (m *visitor) UpdateRelationDeps(ctx context.Context, op scop.UpdateRelation
 	return nil
 }
 
+func (m *visitor) AddColumnDefaultExpression(
+	ctx context.Context, op scop.AddColumnDefaultExpression,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	column, err := tbl.FindColumnWithID(op.ColumnID)
+	if err != nil {
+		return err
+	}
+	expr := op.DefaultExpr
+	column.ColumnDesc().DefaultExpr = &expr
+	column.ColumnDesc().UsesSequenceIds = append([]descpb.ID(nil), op.UsesSequenceIDs...)
+	return nil
+}
+
 func (m *visitor) RemoveColumnDefaultExpression(
 	ctx context.Context, op scop.RemoveColumnDefaultExpression,
 ) error {
@@ -315,6 +347,68 @@ func (m *visitor) RemoveColumnDefaultExpression(
 	return nil
 }
 
+func (m *visitor) AddColumnComputedExpression(
+	ctx context.Context, op scop.AddColumnComputedExpression,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	column, err := tbl.FindColumnWithID(op.ColumnID)
+	if err != nil {
+		return err
+	}
+	expr := op.Expr
+	column.ColumnDesc().ComputeExpr = &expr
+	return nil
+}
+
+func (m *visitor) RemoveColumnComputedExpression(
+	ctx context.Context, op scop.RemoveColumnComputedExpression,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	column, err := tbl.FindColumnWithID(op.ColumnID)
+	if err != nil {
+		return err
+	}
+	column.ColumnDesc().ComputeExpr = nil
+	return nil
+}
+
+func (m *visitor) AddColumnOnUpdateExpression(
+	ctx context.Context, op scop.AddColumnOnUpdateExpression,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	column, err := tbl.FindColumnWithID(op.ColumnID)
+	if err != nil {
+		return err
+	}
+	expr := op.Expr
+	column.ColumnDesc().OnUpdateExpr = &expr
+	return nil
+}
+
+func (m *visitor) RemoveColumnOnUpdateExpression(
+	ctx context.Context, op scop.RemoveColumnOnUpdateExpression,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	column, err := tbl.FindColumnWithID(op.ColumnID)
+	if err != nil {
+		return err
+	}
+	column.ColumnDesc().OnUpdateExpr = nil
+	return nil
+}
+
 func (m *visitor) AddTypeBackRef(ctx context.Context, op scop.AddTypeBackRef) error {
 	typ, err := m.checkOutType(ctx, op.TypeID)
 	if err != nil {
@@ -414,6 +508,37 @@ func (m *visitor) RemoveSequenceOwnedBy(ctx context.Context, op scop.RemoveSeque
 	return nil
 }
 
+func (m *visitor) UpdateSequenceOwnedBy(ctx context.Context, op scop.UpdateSequenceOwnedBy) error {
+	seq, err := m.checkOutTable(ctx, op.SequenceID)
+	if err != nil {
+		return err
+	}
+	ownedByTbl, err := m.checkOutTable(ctx, op.OwnerTableID)
+	if err != nil {
+		return err
+	}
+	col, err := ownedByTbl.FindColumnWithID(op.OwnerColumnID)
+	if err != nil {
+		return err
+	}
+	// Set up the ownership inside the owning column first.
+	colDesc := col.ColumnDesc()
+	found := false
+	for _, id := range colDesc.OwnsSequenceIds {
+		if id == op.SequenceID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		colDesc.OwnsSequenceIds = append(colDesc.OwnsSequenceIds, op.SequenceID)
+	}
+	// Next, set the ownership on the sequence.
+	seq.GetSequenceOpts().SequenceOwner.OwnerTableID = op.OwnerTableID
+	seq.GetSequenceOpts().SequenceOwner.OwnerColumnID = op.OwnerColumnID
+	return nil
+}
+
 func (m *visitor) RemoveTypeBackRef(ctx context.Context, op scop.RemoveTypeBackRef) error {
 	typ, err := m.checkOutType(ctx, op.TypeID)
 	if err != nil {
@@ -423,6 +548,48 @@ func (m *visitor) RemoveTypeBackRef(ctx context.Context, op scop.RemoveTypeBackR
 	return nil
 }
 
+func (m *visitor) AddEnumMember(ctx context.Context, op scop.AddEnumMember) error {
+	typ, err := m.checkOutType(ctx, op.TypeID)
+	if err != nil {
+		return err
+	}
+	// New members are added in the READ_ONLY capability to ensure that they
+	// aren't written before all other nodes know how to decode their physical
+	// representation. The members are kept sorted by it.
+	member := descpb.TypeDescriptor_EnumMember{
+		LogicalRepresentation:  op.LogicalRepresentation,
+		PhysicalRepresentation: op.PhysicalRepresentation,
+		Capability:             descpb.TypeDescriptor_EnumMember_READ_ONLY,
+		Direction:              descpb.TypeDescriptor_EnumMember_ADD,
+	}
+	idx := sort.Search(len(typ.EnumMembers), func(i int) bool {
+		return bytes.Compare(typ.EnumMembers[i].PhysicalRepresentation, op.PhysicalRepresentation) >= 0
+	})
+	typ.EnumMembers = append(typ.EnumMembers, descpb.TypeDescriptor_EnumMember{})
+	copy(typ.EnumMembers[idx+1:], typ.EnumMembers[idx:])
+	typ.EnumMembers[idx] = member
+	return nil
+}
+
+func (m *visitor) MakeAddedEnumMemberPublic(
+	ctx context.Context, op scop.MakeAddedEnumMemberPublic,
+) error {
+	typ, err := m.checkOutType(ctx, op.TypeID)
+	if err != nil {
+		return err
+	}
+	for i := range typ.EnumMembers {
+		member := &typ.EnumMembers[i]
+		if member.LogicalRepresentation == op.LogicalRepresentation {
+			member.Capability = descpb.TypeDescriptor_EnumMember_ALL
+			member.Direction = descpb.TypeDescriptor_EnumMember_NONE
+			return nil
+		}
+	}
+	return errors.AssertionFailedf(
+		"enum member %q not found in type %d", op.LogicalRepresentation, op.TypeID)
+}
+
 func (m *visitor) CreateGcJobForTable(ctx context.Context, op scop.CreateGcJobForTable) error {
 	desc, err := m.checkOutTable(ctx, op.TableID)
 	if err != nil {
@@ -500,6 +667,31 @@ func (m *visitor) DrainDescriptorName(ctx context.Context, op scop.DrainDescript
 	return nil
 }
 
+func (m *visitor) UpdateParentSchema(ctx context.Context, op scop.UpdateParentSchema) error {
+	desc, err := m.s.CheckOutDescriptor(ctx, op.DescID)
+	if err != nil {
+		return err
+	}
+	// Types are not supported: their implicit array type, along with its
+	// namespace entry, would have to be moved with them.
+	tbl, ok := desc.(*tabledesc.Mutable)
+	if !ok {
+		return errors.AssertionFailedf("cannot move %s %q (%d) to another schema",
+			desc.DescriptorType(), desc.GetName(), desc.GetID())
+	}
+	tbl.SetParentSchemaID(op.SchemaID)
+	return nil
+}
+
+func (m *visitor) AddNamespaceEntry(_ context.Context, op scop.AddNamespaceEntry) error {
+	m.s.AddName(op.DescID, descpb.NameInfo{
+		ParentID:       op.DatabaseID,
+		ParentSchemaID: op.SchemaID,
+		Name:           op.Name,
+	})
+	return nil
+}
+
 func (m *visitor) MakeColumnPublic(ctx context.Context, op scop.MakeColumnPublic) error {
 	tbl, err := m.checkOutTable(ctx, op.TableID)
 	if err != nil {
@@ -788,6 +980,89 @@ func (m *visitor) AddCheckConstraint(ctx context.Context, op scop.AddCheckConstr
 	return nil
 }
 
+func (m *visitor) MakeAddedCheckConstraintPublic(
+	ctx context.Context, op scop.MakeAddedCheckConstraintPublic,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	for _, ck := range tbl.Checks {
+		if ck.Name == op.Name {
+			ck.Validity = descpb.ConstraintValidity_Validated
+			return nil
+		}
+	}
+	return errors.AssertionFailedf("failed to find check constraint %q in table %q (%d)",
+		op.Name, tbl.GetName(), tbl.GetID())
+}
+
+func (m *visitor) RemoveCheckConstraint(ctx context.Context, op scop.RemoveCheckConstraint) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	for i, ck := range tbl.Checks {
+		if ck.Name == op.Name {
+			tbl.Checks = append(tbl.Checks[:i], tbl.Checks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *visitor) AddUniqueWithoutIndexConstraint(
+	ctx context.Context, op scop.AddUniqueWithoutIndexConstraint,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	tbl.UniqueWithoutIndexConstraints = append(tbl.UniqueWithoutIndexConstraints,
+		descpb.UniqueWithoutIndexConstraint{
+			TableID:   op.TableID,
+			ColumnIDs: op.ColumnIDs,
+			Name:      op.Name,
+			Validity:  descpb.ConstraintValidity_Validating,
+			Predicate: op.Predicate,
+		})
+	return nil
+}
+
+func (m *visitor) MakeAddedUniqueWithoutIndexConstraintPublic(
+	ctx context.Context, op scop.MakeAddedUniqueWithoutIndexConstraintPublic,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	for i := range tbl.UniqueWithoutIndexConstraints {
+		if uc := &tbl.UniqueWithoutIndexConstraints[i]; uc.Name == op.Name {
+			uc.Validity = descpb.ConstraintValidity_Validated
+			return nil
+		}
+	}
+	return errors.AssertionFailedf("failed to find unique constraint %q in table %q (%d)",
+		op.Name, tbl.GetName(), tbl.GetID())
+}
+
+func (m *visitor) RemoveUniqueWithoutIndexConstraint(
+	ctx context.Context, op scop.RemoveUniqueWithoutIndexConstraint,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	ucs := tbl.UniqueWithoutIndexConstraints
+	for i := range ucs {
+		if ucs[i].Name == op.Name {
+			tbl.UniqueWithoutIndexConstraints = append(ucs[:i], ucs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
 func (m *visitor) MakeAddedSecondaryIndexPublic(
 	ctx context.Context, op scop.MakeAddedSecondaryIndexPublic,
 ) error {
@@ -854,6 +1129,13 @@ func (m *visitor) AddColumnFamily(ctx context.Context, op scop.AddColumnFamily)
 	if err != nil {
 		return err
 	}
+	for i := range tbl.Families {
+		// The family may already have been added by a previous execution of
+		// this op.
+		if tbl.Families[i].ID == op.Family.ID {
+			return nil
+		}
+	}
 	tbl.AddFamily(op.Family)
 	if op.Family.ID >= tbl.NextFamilyID {
 		tbl.NextFamilyID = op.Family.ID + 1
@@ -861,6 +1143,29 @@ func (m *visitor) AddColumnFamily(ctx context.Context, op scop.AddColumnFamily)
 	return nil
 }
 
+func (m *visitor) AddForeignKeyRef(ctx context.Context, op scop.AddForeignKeyRef) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	fks := &tbl.TableDesc().OutboundFKs
+	if !op.Outbound {
+		fks = &tbl.TableDesc().InboundFKs
+	}
+	for i := range *fks {
+		// The reference may already have been added, either by a previous
+		// execution of this op or before being validated, in which case it
+		// is replaced.
+		if fk := &(*fks)[i]; fk.OriginTableID == op.ForeignKey.OriginTableID &&
+			fk.Name == op.ForeignKey.Name {
+			*fk = op.ForeignKey
+			return nil
+		}
+	}
+	*fks = append(*fks, op.ForeignKey)
+	return nil
+}
+
 func (m *visitor) DropForeignKeyRef(ctx context.Context, op scop.DropForeignKeyRef) error {
 	tbl, err := m.checkOutTable(ctx, op.TableID)
 	if err != nil {
@@ -870,7 +1175,9 @@ func (m *visitor) DropForeignKeyRef(ctx context.Context, op scop.DropForeignKeyR
 	if !op.Outbound {
 		fks = tbl.TableDesc().InboundFKs
 	}
-	newFks := make([]descpb.ForeignKeyConstraint, 0, len(fks)-1)
+	// The reference may already have been dropped, e.g. by both the ForeignKey
+	// and the ForeignKeyBackReference elements of the same foreign key.
+	newFks := make([]descpb.ForeignKeyConstraint, 0, len(fks))
 	for _, fk := range fks {
 		if op.Outbound && (fk.OriginTableID != op.TableID ||
 			op.Name != fk.Name) {
@@ -983,6 +1290,42 @@ func (m *visitor) AddIndexPartitionInfo(ctx context.Context, op scop.AddIndexPar
 	)
 }
 
+func (m *visitor) RemoveIndexPartitionInfo(
+	ctx context.Context, op scop.RemoveIndexPartitionInfo,
+) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	index, err := tbl.FindIndexWithID(op.IndexID)
+	if err != nil {
+		return err
+	}
+	// Implicit partitioning columns are part of the index key, so removing them
+	// requires building a new index rather than updating this one in place.
+	if n := index.GetPartitioning().NumImplicitColumns(); n > 0 {
+		return errors.AssertionFailedf(
+			"cannot remove partitioning with %d implicit columns from index %d in table %q (%d)",
+			n, op.IndexID, tbl.GetName(), tbl.GetID())
+	}
+	index.IndexDesc().Partitioning = descpb.PartitioningDescriptor{}
+	return nil
+}
+
+func (m *visitor) SetTableLocality(ctx context.Context, op scop.SetTableLocality) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	if op.Locality == nil {
+		tbl.LocalityConfig = nil
+		return nil
+	}
+	locality := *op.Locality
+	tbl.LocalityConfig = &locality
+	return nil
+}
+
 func (m *visitor) SetIndexName(ctx context.Context, op scop.SetIndexName) error {
 	tbl, err := m.checkOutTable(ctx, op.TableID)
 	if err != nil {
@@ -996,6 +1339,71 @@ func (m *visitor) SetIndexName(ctx context.Context, op scop.SetIndexName) error
 	return nil
 }
 
+func (m *visitor) SetConstraintName(ctx context.Context, op scop.SetConstraintName) error {
+	tbl, err := m.checkOutTable(ctx, op.TableID)
+	if err != nil {
+		return err
+	}
+	switch op.ConstraintType {
+	case scpb.ConstraintType_Check:
+		for _, ck := range tbl.AllActiveAndInactiveChecks() {
+			if ck.Name == op.OldName {
+				ck.Name = op.Name
+				return nil
+			}
+		}
+	case scpb.ConstraintType_UniqueWithoutIndex:
+		for _, uc := range tbl.AllActiveAndInactiveUniqueWithoutIndexConstraints() {
+			if uc.Name == op.OldName {
+				uc.Name = op.Name
+				return nil
+			}
+		}
+	default:
+		return errors.AssertionFailedf("unsupported constraint type %s", op.ConstraintType)
+	}
+	return errors.AssertionFailedf("%s constraint %q not found in table %q (%d)",
+		op.ConstraintType, op.OldName, tbl.GetName(), tbl.GetID())
+}
+
+func (m *visitor) UpdateOwner(ctx context.Context, op scop.UpdateOwner) error {
+	desc, err := m.s.CheckOutDescriptor(ctx, op.DescID)
+	if err != nil {
+		return err
+	}
+	desc.GetPrivileges().SetOwner(security.MakeSQLUsernameFromPreNormalizedString(op.Owner))
+	return nil
+}
+
+func (m *visitor) UpsertUserPrivileges(ctx context.Context, op scop.UpsertUserPrivileges) error {
+	desc, err := m.s.CheckOutDescriptor(ctx, op.DescID)
+	if err != nil {
+		return err
+	}
+	user := security.MakeSQLUsernameFromPreNormalizedString(op.Username)
+	desc.GetPrivileges().FindOrCreateUser(user).Privileges = op.Privileges
+	return nil
+}
+
+func (m *visitor) UpsertComment(_ context.Context, op scop.UpsertComment) error {
+	m.s.UpsertComment(op.CommentType, op.ObjectID, op.SubID, op.Comment)
+	return nil
+}
+
+func (m *visitor) RemoveComment(_ context.Context, op scop.RemoveComment) error {
+	m.s.RemoveComment(op.CommentType, op.ObjectID, op.SubID)
+	return nil
+}
+
+func (m *visitor) RemoveUserPrivileges(ctx context.Context, op scop.RemoveUserPrivileges) error {
+	desc, err := m.s.CheckOutDescriptor(ctx, op.DescID)
+	if err != nil {
+		return err
+	}
+	desc.GetPrivileges().RemoveUser(security.MakeSQLUsernameFromPreNormalizedString(op.Username))
+	return nil
+}
+
 func (m *visitor) DeleteDescriptor(_ context.Context, op scop.DeleteDescriptor) error {
 	m.s.DeleteDescriptor(op.DescriptorID)
 	return nil
@@ -11,6 +11,9 @@
 package scgraph
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/rel"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
@@ -282,34 +285,71 @@ func (g *Graph) Order() int {
 	return n
 }
 
-// Validate returns an error if there's a cycle in the graph.
-func (g *Graph) Validate() (err error) {
-	marks := make(map[*scpb.Node]bool, g.Order())
-	var visit func(n *scpb.Node)
-	visit = func(n *scpb.Node) {
-		if err != nil {
-			return
+// Validate returns an error if there's a cycle in the graph. Both op edges and
+// dep edges are followed, as a cycle may go through several statuses of the
+// same target. The error lists the edges which form the cycle, along with the
+// rules which generated its dep edges.
+func (g *Graph) Validate() error {
+	const (
+		_ = iota // unvisited
+		visiting
+		visited
+	)
+	marks := make(map[*scpb.Node]int, g.Order())
+	// path holds the edges leading from the root of the current traversal to
+	// the node being visited.
+	var path []Edge
+	var visit func(n *scpb.Node) error
+	follow := func(e Edge) error {
+		path = append(path, e)
+		if err := visit(e.To()); err != nil {
+			return err
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+	visit = func(n *scpb.Node) error {
+		switch marks[n] {
+		case visited:
+			return nil
+		case visiting:
+			return makeCycleError(path, n)
 		}
-		permanent, marked := marks[n]
-		if marked && !permanent {
-			err = errors.AssertionFailedf("graph is not acyclical")
-			return
+		marks[n] = visiting
+		if oe, ok := g.GetOpEdgeFrom(n); ok {
+			if err := follow(oe); err != nil {
+				return err
+			}
 		}
-		if marked && permanent {
-			return
+		if err := g.ForEachDepEdgeFrom(n, func(de *DepEdge) error {
+			return follow(de)
+		}); err != nil {
+			return err
 		}
-		marks[n] = false
-		_ = g.ForEachDepEdgeTo(n, func(de *DepEdge) error {
-			visit(de.From())
-			return nil
-		})
-		marks[n] = true
-	}
-	_ = g.ForEachNode(func(n *scpb.Node) error {
-		visit(n)
+		marks[n] = visited
 		return nil
-	})
-	return err
+	}
+	return g.ForEachNode(visit)
+}
+
+// makeCycleError returns an error describing the cycle formed by the edges in
+// path which follow the first edge from n.
+func makeCycleError(path []Edge, n *scpb.Node) error {
+	for len(path) > 0 && path[0].From() != n {
+		path = path[1:]
+	}
+	var sb strings.Builder
+	for _, e := range path {
+		sb.WriteString("\n  ")
+		switch e := e.(type) {
+		case *DepEdge:
+			fmt.Fprintf(&sb, "%s (rule %q)", e, e.Name())
+		default:
+			fmt.Fprintf(&sb, "%s", e)
+		}
+	}
+	return errors.AssertionFailedf("graph is not acyclical, cycle of %d edges:%s",
+		len(path), sb.String())
 }
 
 // compareNodes compares two nodes in a graph. A nil nodes is the minimum value.
@@ -146,3 +146,50 @@ func TestGraphRanks(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) { run(t, tc) })
 	}
 }
+
+// TestGraphValidateCycle checks that a cycle formed by dep edges between
+// different statuses of two targets is detected, and that the error names the
+// rules and nodes involved.
+func TestGraphValidateCycle(t *testing.T) {
+	state := scpb.State{}
+	for _, id := range []descpb.ID{1, 2} {
+		state.Nodes = append(state.Nodes, &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, &scpb.Table{TableID: id}, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		})
+	}
+	graph, err := scgraph.New(state)
+	require.NoError(t, err)
+	for _, n := range state.Nodes {
+		require.NoError(t, graph.AddOpEdges(n.Target,
+			scpb.Status_ABSENT,
+			scpb.Status_DELETE_ONLY,
+			true,
+			scop.StatementPhase,
+			&scop.MakeColumnAbsent{}))
+		require.NoError(t, graph.AddOpEdges(n.Target,
+			scpb.Status_DELETE_ONLY,
+			scpb.Status_PUBLIC,
+			true,
+			scop.StatementPhase,
+			&scop.MakeColumnAbsent{}))
+	}
+	// Neither dep edge forms a cycle on its own, only when taken together with
+	// the op edges of both targets.
+	first, second := state.Nodes[0].Target, state.Nodes[1].Target
+	require.NoError(t, graph.AddDepEdge(
+		"first public before second delete-only", scgraph.Precedence,
+		first, scpb.Status_PUBLIC, second, scpb.Status_DELETE_ONLY,
+	))
+	require.NoError(t, graph.Validate())
+	require.NoError(t, graph.AddDepEdge(
+		"second public before first delete-only", scgraph.Precedence,
+		second, scpb.Status_PUBLIC, first, scpb.Status_DELETE_ONLY,
+	))
+	err = graph.Validate()
+	require.Error(t, err)
+	require.Regexp(t, "graph is not acyclical, cycle of 4 edges", err)
+	require.Regexp(t, `rule "first public before second delete-only"`, err)
+	require.Regexp(t, `rule "second public before first delete-only"`, err)
+	require.Regexp(t, `\[Table:\{DescID: 1\}, DELETE_ONLY, ADD\] -op-`, err)
+}
@@ -20,7 +20,10 @@ type backfillOp struct{ baseOp }
 // Type implements the Op interface.
 func (backfillOp) Type() Type { return BackfillType }
 
-// BackfillIndex specifies an index backfill operation.
+// BackfillIndex specifies an index backfill operation. Column backfills are
+// performed as backfills of the new primary index. While the backfill runs,
+// the executor periodically writes its fraction completed and a checkpoint
+// to the job through scexec.BackfillTracker.
 type BackfillIndex struct {
 	backfillOp
 	TableID       descpb.ID
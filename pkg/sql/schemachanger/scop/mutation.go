@@ -11,6 +11,8 @@
 package scop
 
 import (
+	"fmt"
+
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
@@ -125,12 +127,40 @@ type DrainDescriptorName struct {
 	TableID descpb.ID
 }
 
+// UpdateParentSchema moves a relation into another schema of the same
+// database. Types are not supported, as their array type would have to be
+// moved along with them.
+type UpdateParentSchema struct {
+	mutationOp
+	DescID   descpb.ID
+	SchemaID descpb.ID
+}
+
+// AddNamespaceEntry adds the namespace entry of a descriptor.
+type AddNamespaceEntry struct {
+	mutationOp
+	DescID     descpb.ID
+	DatabaseID descpb.ID
+	SchemaID   descpb.ID
+	Name       string
+}
+
 // UpdateRelationDeps updates dependencies for a relation.
 type UpdateRelationDeps struct {
 	mutationOp
 	TableID descpb.ID
 }
 
+// AddColumnDefaultExpression sets the default expression on a given table
+// column.
+type AddColumnDefaultExpression struct {
+	mutationOp
+	TableID         descpb.ID
+	ColumnID        descpb.ColumnID
+	DefaultExpr     string
+	UsesSequenceIDs []descpb.ID
+}
+
 // RemoveColumnDefaultExpression removes the default expression on a given table column.
 type RemoveColumnDefaultExpression struct {
 	mutationOp
@@ -138,6 +168,40 @@ type RemoveColumnDefaultExpression struct {
 	ColumnID descpb.ColumnID
 }
 
+// AddColumnComputedExpression sets the computed expression of a given table
+// column.
+type AddColumnComputedExpression struct {
+	mutationOp
+	TableID  descpb.ID
+	ColumnID descpb.ColumnID
+	Expr     string
+}
+
+// RemoveColumnComputedExpression removes the computed expression of a given
+// table column.
+type RemoveColumnComputedExpression struct {
+	mutationOp
+	TableID  descpb.ID
+	ColumnID descpb.ColumnID
+}
+
+// AddColumnOnUpdateExpression sets the ON UPDATE expression of a given table
+// column.
+type AddColumnOnUpdateExpression struct {
+	mutationOp
+	TableID  descpb.ID
+	ColumnID descpb.ColumnID
+	Expr     string
+}
+
+// RemoveColumnOnUpdateExpression removes the ON UPDATE expression of a given
+// table column.
+type RemoveColumnOnUpdateExpression struct {
+	mutationOp
+	TableID  descpb.ID
+	ColumnID descpb.ColumnID
+}
+
 // AddTypeBackRef adds a type back references from a relation.
 type AddTypeBackRef struct {
 	mutationOp
@@ -159,6 +223,21 @@ type RemoveTypeBackRef struct {
 	TypeID descpb.ID
 }
 
+// AddEnumMember adds a member to an enum type, in the read-only capability.
+type AddEnumMember struct {
+	mutationOp
+	TypeID                 descpb.ID
+	LogicalRepresentation  string
+	PhysicalRepresentation []byte
+}
+
+// MakeAddedEnumMemberPublic makes an added enum member writable.
+type MakeAddedEnumMemberPublic struct {
+	mutationOp
+	TypeID                descpb.ID
+	LogicalRepresentation string
+}
+
 // MakeAddedColumnDeleteAndWriteOnly transitions a column addition mutation from
 // DELETE_ONLY to DELETE_AND_WRITE_ONLY.
 type MakeAddedColumnDeleteAndWriteOnly struct {
@@ -255,16 +334,66 @@ type AddCheckConstraint struct {
 	Hidden      bool
 }
 
+// MakeAddedCheckConstraintPublic marks a check constraint as validated, once
+// the existing rows have been checked against it.
+type MakeAddedCheckConstraintPublic struct {
+	mutationOp
+	TableID descpb.ID
+	Name    string
+}
+
+// RemoveCheckConstraint removes a check constraint from a table.
+type RemoveCheckConstraint struct {
+	mutationOp
+	TableID descpb.ID
+	Name    string
+}
+
+// AddUniqueWithoutIndexConstraint adds a unique constraint without an index
+// in the validating state.
+type AddUniqueWithoutIndexConstraint struct {
+	mutationOp
+	TableID   descpb.ID
+	Name      string
+	ColumnIDs descpb.ColumnIDs
+	Predicate string
+}
+
+// MakeAddedUniqueWithoutIndexConstraintPublic marks a unique constraint
+// without an index which was added and then validated as validated.
+type MakeAddedUniqueWithoutIndexConstraintPublic struct {
+	mutationOp
+	TableID descpb.ID
+	Name    string
+}
+
+// RemoveUniqueWithoutIndexConstraint removes a unique constraint without an
+// index from a table.
+type RemoveUniqueWithoutIndexConstraint struct {
+	mutationOp
+	TableID descpb.ID
+	Name    string
+}
+
 // AddColumnFamily adds a column family with the provided descriptor.
-//
-// TODO(ajwerner): Decide whether this should happen explicitly or should be a
-// side-effect of adding a column. My hunch is the latter.
 type AddColumnFamily struct {
 	mutationOp
 	TableID descpb.ID
 	Family  descpb.ColumnFamilyDescriptor
 }
 
+// AddForeignKeyRef adds a foreign key reference with
+// support for outbound/inbound keys. An outbound reference
+// is added to the origin table, an inbound one to the
+// referenced table. A reference with the same origin and
+// name is replaced, which is how its validity is updated.
+type AddForeignKeyRef struct {
+	mutationOp
+	TableID    descpb.ID
+	Outbound   bool
+	ForeignKey descpb.ForeignKeyConstraint
+}
+
 // DropForeignKeyRef drops a foreign key reference with
 // support for outbound/inbound keys.
 type DropForeignKeyRef struct {
@@ -274,6 +403,14 @@ type DropForeignKeyRef struct {
 	Outbound bool
 }
 
+// Description implements the Describer interface.
+func (op DropForeignKeyRef) Description() string {
+	if op.Outbound {
+		return fmt.Sprintf("removing foreign key %s from table #%d", op.Name, op.TableID)
+	}
+	return fmt.Sprintf("removing foreign key %s back-reference from table #%d", op.Name, op.TableID)
+}
+
 // RemoveSequenceOwnedBy removes a sequence owned by
 // reference.
 type RemoveSequenceOwnedBy struct {
@@ -281,6 +418,14 @@ type RemoveSequenceOwnedBy struct {
 	SequenceID descpb.ID
 }
 
+// UpdateSequenceOwnedBy makes a column the owner of a sequence.
+type UpdateSequenceOwnedBy struct {
+	mutationOp
+	SequenceID    descpb.ID
+	OwnerTableID  descpb.ID
+	OwnerColumnID descpb.ColumnID
+}
+
 // AddIndexPartitionInfo adds partitoning information into
 // an index
 type AddIndexPartitionInfo struct {
@@ -292,6 +437,22 @@ type AddIndexPartitionInfo struct {
 	RangePartitions []*scpb.RangePartitions
 }
 
+// RemoveIndexPartitionInfo removes the partitioning information from an
+// index.
+type RemoveIndexPartitionInfo struct {
+	mutationOp
+	TableID descpb.ID
+	IndexID descpb.IndexID
+}
+
+// SetTableLocality sets the locality config of a table, or clears it if the
+// locality is nil.
+type SetTableLocality struct {
+	mutationOp
+	TableID  descpb.ID
+	Locality *descpb.TableDescriptor_LocalityConfig
+}
+
 // LogEvent logs an event for a given descriptor.
 type LogEvent struct {
 	mutationOp
@@ -319,6 +480,40 @@ type SetIndexName struct {
 	Name    string
 }
 
+// SetConstraintName renames a constraint without an index, which is found by
+// its current name among the table's constraints of that type. Ordinals are
+// not used, as they shift when constraints are added or removed.
+type SetConstraintName struct {
+	mutationOp
+	TableID        descpb.ID
+	ConstraintType scpb.ConstraintType
+	OldName        string
+	Name           string
+}
+
+// UpdateOwner sets the owner of a descriptor.
+type UpdateOwner struct {
+	mutationOp
+	DescID descpb.ID
+	Owner  string
+}
+
+// UpsertUserPrivileges sets the privileges of a user on a descriptor,
+// replacing any which the user already had.
+type UpsertUserPrivileges struct {
+	mutationOp
+	DescID     descpb.ID
+	Username   string
+	Privileges uint32
+}
+
+// RemoveUserPrivileges removes all the privileges of a user on a descriptor.
+type RemoveUserPrivileges struct {
+	mutationOp
+	DescID   descpb.ID
+	Username string
+}
+
 // DeleteDescriptor deletes a descriptor.
 type DeleteDescriptor struct {
 	mutationOp
@@ -352,6 +547,9 @@ type CreateDeclarativeSchemaChangerJob struct {
 	mutationOp
 	JobID jobspb.JobID
 	State scpb.State
+	// RunningStatus summarizes the changes performed by the first stage of
+	// the job, see Describe.
+	RunningStatus string
 }
 
 // UpdateSchemaChangerJob is used to update the progress and payload of the
@@ -361,4 +559,26 @@ type UpdateSchemaChangerJob struct {
 	JobID           jobspb.JobID
 	Statuses        []scpb.Status
 	IsNonCancelable bool
+	// RunningStatus summarizes the changes performed by the next stage of the
+	// job, see Describe.
+	RunningStatus string
+}
+
+// UpsertComment sets the comment of an object in system.comments, replacing
+// any existing comment. SubID identifies the column or index for column and
+// index comments, and is zero otherwise.
+type UpsertComment struct {
+	mutationOp
+	CommentType int
+	ObjectID    descpb.ID
+	SubID       uint32
+	Comment     string
+}
+
+// RemoveComment deletes the comment of an object from system.comments.
+type RemoveComment struct {
+	mutationOp
+	CommentType int
+	ObjectID    descpb.ID
+	SubID       uint32
 }
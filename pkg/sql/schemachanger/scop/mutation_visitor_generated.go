@@ -34,11 +34,20 @@ type MutationVisitor interface {
 	MarkDescriptorAsDroppedSynthetically(context.Context, MarkDescriptorAsDroppedSynthetically) error
 	MarkDescriptorAsDropped(context.Context, MarkDescriptorAsDropped) error
 	DrainDescriptorName(context.Context, DrainDescriptorName) error
+	UpdateParentSchema(context.Context, UpdateParentSchema) error
+	AddNamespaceEntry(context.Context, AddNamespaceEntry) error
 	UpdateRelationDeps(context.Context, UpdateRelationDeps) error
+	AddColumnDefaultExpression(context.Context, AddColumnDefaultExpression) error
 	RemoveColumnDefaultExpression(context.Context, RemoveColumnDefaultExpression) error
+	AddColumnComputedExpression(context.Context, AddColumnComputedExpression) error
+	RemoveColumnComputedExpression(context.Context, RemoveColumnComputedExpression) error
+	AddColumnOnUpdateExpression(context.Context, AddColumnOnUpdateExpression) error
+	RemoveColumnOnUpdateExpression(context.Context, RemoveColumnOnUpdateExpression) error
 	AddTypeBackRef(context.Context, AddTypeBackRef) error
 	RemoveRelationDependedOnBy(context.Context, RemoveRelationDependedOnBy) error
 	RemoveTypeBackRef(context.Context, RemoveTypeBackRef) error
+	AddEnumMember(context.Context, AddEnumMember) error
+	MakeAddedEnumMemberPublic(context.Context, MakeAddedEnumMemberPublic) error
 	MakeAddedColumnDeleteAndWriteOnly(context.Context, MakeAddedColumnDeleteAndWriteOnly) error
 	MakeDroppedNonPrimaryIndexDeleteAndWriteOnly(context.Context, MakeDroppedNonPrimaryIndexDeleteAndWriteOnly) error
 	MakeDroppedIndexDeleteOnly(context.Context, MakeDroppedIndexDeleteOnly) error
@@ -49,19 +58,34 @@ type MutationVisitor interface {
 	MakeDroppedColumnDeleteOnly(context.Context, MakeDroppedColumnDeleteOnly) error
 	MakeColumnAbsent(context.Context, MakeColumnAbsent) error
 	AddCheckConstraint(context.Context, AddCheckConstraint) error
+	MakeAddedCheckConstraintPublic(context.Context, MakeAddedCheckConstraintPublic) error
+	RemoveCheckConstraint(context.Context, RemoveCheckConstraint) error
+	AddUniqueWithoutIndexConstraint(context.Context, AddUniqueWithoutIndexConstraint) error
+	MakeAddedUniqueWithoutIndexConstraintPublic(context.Context, MakeAddedUniqueWithoutIndexConstraintPublic) error
+	RemoveUniqueWithoutIndexConstraint(context.Context, RemoveUniqueWithoutIndexConstraint) error
 	AddColumnFamily(context.Context, AddColumnFamily) error
+	AddForeignKeyRef(context.Context, AddForeignKeyRef) error
 	DropForeignKeyRef(context.Context, DropForeignKeyRef) error
 	RemoveSequenceOwnedBy(context.Context, RemoveSequenceOwnedBy) error
+	UpdateSequenceOwnedBy(context.Context, UpdateSequenceOwnedBy) error
 	AddIndexPartitionInfo(context.Context, AddIndexPartitionInfo) error
+	RemoveIndexPartitionInfo(context.Context, RemoveIndexPartitionInfo) error
+	SetTableLocality(context.Context, SetTableLocality) error
 	LogEvent(context.Context, LogEvent) error
 	SetColumnName(context.Context, SetColumnName) error
 	SetIndexName(context.Context, SetIndexName) error
+	SetConstraintName(context.Context, SetConstraintName) error
+	UpdateOwner(context.Context, UpdateOwner) error
+	UpsertUserPrivileges(context.Context, UpsertUserPrivileges) error
+	RemoveUserPrivileges(context.Context, RemoveUserPrivileges) error
 	DeleteDescriptor(context.Context, DeleteDescriptor) error
 	DeleteDatabaseSchemaEntry(context.Context, DeleteDatabaseSchemaEntry) error
 	RemoveJobReference(context.Context, RemoveJobReference) error
 	AddJobReference(context.Context, AddJobReference) error
 	CreateDeclarativeSchemaChangerJob(context.Context, CreateDeclarativeSchemaChangerJob) error
 	UpdateSchemaChangerJob(context.Context, UpdateSchemaChangerJob) error
+	UpsertComment(context.Context, UpsertComment) error
+	RemoveComment(context.Context, RemoveComment) error
 }
 
 // Visit is part of the MutationOp interface.
@@ -124,16 +148,51 @@ func (op DrainDescriptorName) Visit(ctx context.Context, v MutationVisitor) erro
 	return v.DrainDescriptorName(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op UpdateParentSchema) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.UpdateParentSchema(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op AddNamespaceEntry) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddNamespaceEntry(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op UpdateRelationDeps) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.UpdateRelationDeps(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op AddColumnDefaultExpression) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddColumnDefaultExpression(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op RemoveColumnDefaultExpression) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.RemoveColumnDefaultExpression(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op AddColumnComputedExpression) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddColumnComputedExpression(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveColumnComputedExpression) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveColumnComputedExpression(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op AddColumnOnUpdateExpression) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddColumnOnUpdateExpression(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveColumnOnUpdateExpression) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveColumnOnUpdateExpression(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op AddTypeBackRef) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.AddTypeBackRef(ctx, op)
@@ -149,6 +208,16 @@ func (op RemoveTypeBackRef) Visit(ctx context.Context, v MutationVisitor) error
 	return v.RemoveTypeBackRef(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op AddEnumMember) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddEnumMember(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op MakeAddedEnumMemberPublic) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.MakeAddedEnumMemberPublic(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op MakeAddedColumnDeleteAndWriteOnly) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.MakeAddedColumnDeleteAndWriteOnly(ctx, op)
@@ -199,11 +268,41 @@ func (op AddCheckConstraint) Visit(ctx context.Context, v MutationVisitor) error
 	return v.AddCheckConstraint(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op MakeAddedCheckConstraintPublic) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.MakeAddedCheckConstraintPublic(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveCheckConstraint) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveCheckConstraint(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op AddUniqueWithoutIndexConstraint) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddUniqueWithoutIndexConstraint(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op MakeAddedUniqueWithoutIndexConstraintPublic) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.MakeAddedUniqueWithoutIndexConstraintPublic(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveUniqueWithoutIndexConstraint) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveUniqueWithoutIndexConstraint(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op AddColumnFamily) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.AddColumnFamily(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op AddForeignKeyRef) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.AddForeignKeyRef(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op DropForeignKeyRef) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.DropForeignKeyRef(ctx, op)
@@ -214,11 +313,26 @@ func (op RemoveSequenceOwnedBy) Visit(ctx context.Context, v MutationVisitor) er
 	return v.RemoveSequenceOwnedBy(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op UpdateSequenceOwnedBy) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.UpdateSequenceOwnedBy(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op AddIndexPartitionInfo) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.AddIndexPartitionInfo(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op RemoveIndexPartitionInfo) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveIndexPartitionInfo(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op SetTableLocality) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.SetTableLocality(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op LogEvent) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.LogEvent(ctx, op)
@@ -234,6 +348,26 @@ func (op SetIndexName) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.SetIndexName(ctx, op)
 }
 
+// Visit is part of the MutationOp interface.
+func (op SetConstraintName) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.SetConstraintName(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op UpdateOwner) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.UpdateOwner(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op UpsertUserPrivileges) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.UpsertUserPrivileges(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveUserPrivileges) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveUserPrivileges(ctx, op)
+}
+
 // Visit is part of the MutationOp interface.
 func (op DeleteDescriptor) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.DeleteDescriptor(ctx, op)
@@ -263,3 +397,13 @@ func (op CreateDeclarativeSchemaChangerJob) Visit(ctx context.Context, v Mutatio
 func (op UpdateSchemaChangerJob) Visit(ctx context.Context, v MutationVisitor) error {
 	return v.UpdateSchemaChangerJob(ctx, op)
 }
+
+// Visit is part of the MutationOp interface.
+func (op UpsertComment) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.UpsertComment(ctx, op)
+}
+
+// Visit is part of the MutationOp interface.
+func (op RemoveComment) Visit(ctx context.Context, v MutationVisitor) error {
+	return v.RemoveComment(ctx, op)
+}
@@ -10,6 +10,8 @@
 
 package scop
 
+import "strings"
+
 // Op represents an action to be taken on a single descriptor.
 type Op interface {
 	Type() Type
@@ -32,4 +34,35 @@ const (
 	ValidationType
 )
 
+// Describer is implemented by ops which can describe the change they perform
+// in a human-readable way.
+type Describer interface {
+	Op
+
+	// Description returns a short description of the change, in the present
+	// continuous tense, e.g. "removing foreign key fk from table #52".
+	Description() string
+}
+
+// Describe summarizes the changes performed by the given ops for display in
+// the running status of a schema change job. Identical descriptions are only
+// included once. The summary is empty if none of the ops implement Describer.
+func Describe(ops []Op) string {
+	var descriptions []string
+	seen := make(map[string]struct{})
+	for _, op := range ops {
+		d, ok := op.(Describer)
+		if !ok {
+			continue
+		}
+		desc := d.Description()
+		if _, found := seen[desc]; found || desc == "" {
+			continue
+		}
+		seen[desc] = struct{}{}
+		descriptions = append(descriptions, desc)
+	}
+	return strings.Join(descriptions, "; ")
+}
+
 type baseOp struct{}
@@ -0,0 +1,33 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package scop
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	outbound := &DropForeignKeyRef{TableID: 52, Name: "fk", Outbound: true}
+	inbound := &DropForeignKeyRef{TableID: 53, Name: "fk"}
+	require.Equal(t, "removing foreign key fk from table #52", outbound.Description())
+	require.Equal(t, "removing foreign key fk back-reference from table #53", inbound.Description())
+
+	// Ops which do not describe themselves are skipped, as are duplicates.
+	require.Equal(t,
+		"removing foreign key fk from table #52; "+
+			"removing foreign key fk back-reference from table #53",
+		Describe([]Op{outbound, &UpdateRelationDeps{TableID: 52}, inbound, outbound}),
+	)
+	require.Empty(t, Describe([]Op{&UpdateRelationDeps{TableID: 52}}))
+	require.Empty(t, Describe(nil))
+}
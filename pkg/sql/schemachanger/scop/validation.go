@@ -32,5 +32,21 @@ type ValidateCheckConstraint struct {
 	Name    string
 }
 
+// ValidateForeignKey validates that all the rows of a table have a match in
+// the table referenced by one of its outbound foreign keys.
+type ValidateForeignKey struct {
+	validationOp
+	TableID descpb.ID
+	Name    string
+}
+
+// ValidateUniqueConstraint validates that the rows of a table have unique
+// values for the columns of one of its unique constraints without an index.
+type ValidateUniqueConstraint struct {
+	validationOp
+	TableID descpb.ID
+	Name    string
+}
+
 // Make sure baseOp is used for linter.
 var _ = validationOp{baseOp: baseOp{}}
@@ -432,4 +432,108 @@ func ForEachCheckConstraintTypeReference (b NodeIterator, elementFunc func(statu
 		elementFunc(status, dir, e)
 	}
   })
+}
+func (e ComputedExpr) element() {}
+
+// ForEachComputedExpr iterates over nodes of type ComputedExpr.
+func ForEachComputedExpr (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *ComputedExpr) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*ComputedExpr)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e EnumMember) element() {}
+
+// ForEachEnumMember iterates over nodes of type EnumMember.
+func ForEachEnumMember (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *EnumMember) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*EnumMember)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e ColumnFamily) element() {}
+
+// ForEachColumnFamily iterates over nodes of type ColumnFamily.
+func ForEachColumnFamily (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *ColumnFamily) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*ColumnFamily)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e TableComment) element() {}
+
+// ForEachTableComment iterates over nodes of type TableComment.
+func ForEachTableComment (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *TableComment) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*TableComment)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e ColumnComment) element() {}
+
+// ForEachColumnComment iterates over nodes of type ColumnComment.
+func ForEachColumnComment (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *ColumnComment) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*ColumnComment)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e IndexComment) element() {}
+
+// ForEachIndexComment iterates over nodes of type IndexComment.
+func ForEachIndexComment (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *IndexComment) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*IndexComment)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e SchemaParent) element() {}
+
+// ForEachSchemaParent iterates over nodes of type SchemaParent.
+func ForEachSchemaParent (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *SchemaParent) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*SchemaParent)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
+}
+func (e OnUpdateExpr) element() {}
+
+// ForEachOnUpdateExpr iterates over nodes of type OnUpdateExpr.
+func ForEachOnUpdateExpr (b NodeIterator, elementFunc func(status Status,
+	dir Target_Direction,  
+	element *OnUpdateExpr) ) {
+	b.ForEachNode(func(status Status, dir Target_Direction, elem Element) {
+		e, ok := elem.(*OnUpdateExpr)
+		if ok {
+		elementFunc(status, dir, e)
+	}
+  })
 }
\ No newline at end of file
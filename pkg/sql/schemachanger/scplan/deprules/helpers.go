@@ -10,7 +10,11 @@
 
 package deprules
 
-import "github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
 
 func idInIDs(objects []descpb.ID, id descpb.ID) bool {
 	for _, other := range objects {
@@ -20,3 +24,39 @@ func idInIDs(objects []descpb.ID, id descpb.ID) bool {
 	}
 	return false
 }
+
+// referencesUniqueColumns returns whether the columns referenced by the
+// foreign key are exactly those of the unique index or constraint.
+func referencesUniqueColumns(fk *scpb.ForeignKey, unique scpb.Element) bool {
+	var columnIDs descpb.ColumnIDs
+	switch unique := unique.(type) {
+	case *scpb.PrimaryIndex:
+		columnIDs = unique.KeyColumnIDs
+	case *scpb.SecondaryIndex:
+		if !unique.Unique {
+			return false
+		}
+		columnIDs = unique.KeyColumnIDs
+	case *scpb.UniqueConstraint:
+		columnIDs = unique.ColumnIDs
+	}
+	return columnIDs.PermutationOf(fk.ReferenceColumns)
+}
+
+// isRegionalByRow returns whether the locality is REGIONAL BY ROW.
+func isRegionalByRow(locality *scpb.Locality) bool {
+	return locality.Locality.GetRegionalByRow() != nil
+}
+
+// isRegionColumnName returns whether the column is the one holding the region
+// of each row of a REGIONAL BY ROW table.
+func isRegionColumnName(locality *scpb.Locality, name *scpb.ColumnName) bool {
+	rbr := locality.Locality.GetRegionalByRow()
+	if rbr == nil {
+		return false
+	}
+	if rbr.As != nil {
+		return name.Name == *rbr.As
+	}
+	return name.Name == tree.RegionalByRowRegionDefaultCol
+}
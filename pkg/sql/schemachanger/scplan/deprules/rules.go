@@ -166,6 +166,32 @@ func init() {
 	)
 }
 
+func init() {
+	// Ensures that a column family exists before any column joins it.
+	family, familyTarget, familyNode := targetNodeVars("family")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	tableID := rel.Var("table-id")
+	columnInFamily := func(family *scpb.ColumnFamily, column *scpb.Column) bool {
+		return family.FamilyID == column.FamilyID
+	}
+
+	register(
+		"column family added before column joins it",
+		scgraph.Precedence,
+		familyNode, columnNode,
+		screl.MustQuery(
+			family.Type((*scpb.ColumnFamily)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tableID.Entities(screl.DescID, family, column),
+			rel.Filter("columnInFamily", family, column)(columnInFamily),
+
+			joinTargetNode(family, familyTarget, familyNode, add, public),
+			joinTargetNode(column, columnTarget, columnNode, add, deleteOnly),
+		),
+	)
+}
+
 func init() {
 	addIdx, addTarget, addNode := targetNodeVars("add-idx")
 	dropIdx, dropTarget, dropNode := targetNodeVars("drop-idx")
@@ -187,6 +213,10 @@ func init() {
 			drop, validated),
 	)
 
+	// The new primary index only becomes public once it has been backfilled
+	// and validated, as those are its preceding statuses. The old primary
+	// index stops being public in the same stage, so that the table always
+	// has exactly one public primary index.
 	register(
 		"primary index add depends on drop",
 		scgraph.SameStagePrecedence,
@@ -242,6 +272,30 @@ func init() {
 	)
 }
 
+func init() {
+	dropIdx, dropTarget, dropNode := targetNodeVars("drop-idx")
+	partitioning, partitioningTarget, partitioningNode := targetNodeVars("partitioning")
+	var id rel.Var = "id"
+	var indexID rel.Var = "index-id"
+
+	register(
+		"partitioning information removed before the index",
+		scgraph.Precedence,
+		partitioningNode, dropNode,
+		screl.MustQuery(
+			dropIdx.Type((*scpb.PrimaryIndex)(nil), (*scpb.SecondaryIndex)(nil)),
+			partitioning.Type((*scpb.Partitioning)(nil)),
+			id.Entities(screl.DescID, dropIdx, partitioning),
+			indexID.Entities(screl.IndexID, dropIdx, partitioning),
+
+			joinTargetNode(partitioning, partitioningTarget, partitioningNode,
+				drop, absent),
+			joinTargetNode(dropIdx, dropTarget, dropNode,
+				drop, absent),
+		),
+	)
+}
+
 func init() {
 	depNeedsRelationToExitSynthDrop := func(ruleName string, depTypes []interface{}, depDescIDMatch rel.Attr) {
 		// Before any parts of a relation/type can be dropped, the relation
@@ -319,6 +373,66 @@ func init() {
 			joinTargetNode(dep, depTarget, depNode, drop, absent),
 		),
 	)
+
+	// A new descriptor can only be resolved by name once its namespace entry
+	// has been added.
+	register(
+		"descriptor public after namespace entry added",
+		scgraph.Precedence,
+		nsNode, depNode,
+		screl.MustQuery(
+			ns.Type((*scpb.Namespace)(nil)),
+			dep.Type((*scpb.Table)(nil), (*scpb.View)(nil),
+				(*scpb.Sequence)(nil), (*scpb.Database)(nil), (*scpb.Schema)(nil),
+				(*scpb.Type)(nil)),
+
+			tabID.Entities(screl.DescID, dep, ns),
+
+			joinTargetNode(ns, nsTarget, nsNode, add, public),
+			joinTargetNode(dep, depTarget, depNode, add, public),
+		),
+	)
+}
+
+func init() {
+	// Ensures that an object moved to another schema is always resolvable by
+	// exactly one name: its previous namespace entry is removed, and its new
+	// one added, in the same stage as its parent schema is updated. The
+	// previous entry is removed first, while the descriptor still names the
+	// previous schema.
+	ns, nsTarget, nsNode := targetNodeVars("namespace")
+	parent, parentTarget, parentNode := targetNodeVars("schema-parent")
+	descID := rel.Var("desc-id")
+
+	register(
+		"previous namespace entry removed before parent schema updated",
+		scgraph.SameStagePrecedence,
+		nsNode, parentNode,
+		screl.MustQuery(
+			ns.Type((*scpb.Namespace)(nil)),
+			parent.Type((*scpb.SchemaParent)(nil)),
+
+			descID.Entities(screl.DescID, ns, parent),
+
+			joinTargetNode(ns, nsTarget, nsNode, drop, absent),
+			joinTargetNode(parent, parentTarget, parentNode, add, public),
+		),
+	)
+
+	register(
+		"new namespace entry added when parent schema updated",
+		scgraph.SameStagePrecedence,
+		parentNode, nsNode,
+		screl.MustQuery(
+			ns.Type((*scpb.Namespace)(nil)),
+			parent.Type((*scpb.SchemaParent)(nil)),
+
+			descID.Entities(screl.DescID, ns, parent),
+
+			joinTargetNode(parent, parentTarget, parentNode, add, public),
+			joinTargetNode(ns, nsTarget, nsNode, add, public),
+		),
+	)
 }
 
 func init() {
@@ -394,6 +508,183 @@ func init() {
 			joinTargetNode(column, columnTarget, columnNode, drop, absent),
 		),
 	)
+
+	// When a column is renamed, the old name is removed in the same stage as
+	// the new name is set, so that the column always has exactly one name.
+	oldName, oldNameTarget, oldNameNode := targetNodeVars("old-column-name")
+	newName, newNameTarget, newNameNode := targetNodeVars("new-column-name")
+	register(
+		"column renamed in the same stage as old name removed",
+		scgraph.SameStagePrecedence,
+		oldNameNode, newNameNode,
+		screl.MustQuery(
+
+			oldName.Type((*scpb.ColumnName)(nil)),
+			newName.Type((*scpb.ColumnName)(nil)),
+
+			tabID.Entities(screl.DescID, oldName, newName),
+			columnID.Entities(screl.ColumnID, oldName, newName),
+
+			joinTargetNode(oldName, oldNameTarget, oldNameNode, drop, absent),
+			joinTargetNode(newName, newNameTarget, newNameNode, add, public),
+		),
+	)
+}
+
+func init() {
+	computedExpr, computedExprTarget, computedExprNode := targetNodeVars("computed-expr")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	index, indexTarget, indexNode := targetNodeVars("index")
+	tabID := rel.Var("desc-id")
+	columnID := rel.Var("column-id")
+	isVirtual := func(virtual bool) func(*scpb.ComputedExpr) bool {
+		return func(computedExpr *scpb.ComputedExpr) bool {
+			return computedExpr.Virtual == virtual
+		}
+	}
+	referencesColumn := func(computedExpr *scpb.ComputedExpr, column *scpb.Column) bool {
+		for _, id := range computedExpr.ReferencedColumnIDs {
+			if id == column.ColumnID {
+				return true
+			}
+		}
+		return false
+	}
+
+	register(
+		"computed expression set after column existence",
+		scgraph.Precedence,
+		columnNode, computedExprNode,
+		screl.MustQuery(
+			computedExpr.Type((*scpb.ComputedExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, computedExpr),
+			columnID.Entities(screl.ColumnID, column, computedExpr),
+
+			joinTargetNode(column, columnTarget, columnNode, add, deleteOnly),
+			joinTargetNode(computedExpr, computedExprTarget, computedExprNode, add, public),
+		),
+	)
+
+	// The values of a stored computed column are written as soon as it becomes
+	// write-only, and backfilled afterwards, so its expression must be set
+	// first. Those of a virtual column are only ever computed when reading it.
+	register(
+		"stored column write-only after computed expression set",
+		scgraph.Precedence,
+		computedExprNode, columnNode,
+		screl.MustQuery(
+			computedExpr.Type((*scpb.ComputedExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, computedExpr),
+			columnID.Entities(screl.ColumnID, column, computedExpr),
+			rel.Filter("isStored", computedExpr)(isVirtual(false)),
+
+			joinTargetNode(computedExpr, computedExprTarget, computedExprNode, add, public),
+			joinTargetNode(column, columnTarget, columnNode, add, deleteAndWriteOnly),
+		),
+	)
+
+	// The values of a stored computed column are backfilled by the new primary
+	// index, by evaluating its expression. Any new column referenced by the
+	// expression must therefore be written to by then, lest the backfill reads
+	// it as NULL.
+	//
+	// TODO(schema): this rule is inert until the builder emits ComputedExpr
+	// elements with their ReferencedColumnIDs. For now computed expressions are
+	// set through the Column element, and may only reference existing columns.
+	register(
+		"primary index backfilled after columns referenced by computed expression write-only",
+		scgraph.Precedence,
+		columnNode, indexNode,
+		screl.MustQuery(
+			computedExpr.Type((*scpb.ComputedExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+			index.Type((*scpb.PrimaryIndex)(nil)),
+
+			tabID.Entities(screl.DescID, computedExpr, column, index),
+			rel.Filter("isStored", computedExpr)(isVirtual(false)),
+			rel.Filter("referencesColumn", computedExpr, column)(referencesColumn),
+
+			joinTargetNode(computedExpr, computedExprTarget, computedExprNode, add, public),
+			joinTargetNode(column, columnTarget, columnNode, add, deleteAndWriteOnly),
+			joinTargetNode(index, indexTarget, indexNode, add, scpb.Status_BACKFILLED),
+		),
+	)
+
+	register(
+		"virtual column public after computed expression set",
+		scgraph.Precedence,
+		computedExprNode, columnNode,
+		screl.MustQuery(
+			computedExpr.Type((*scpb.ComputedExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, computedExpr),
+			columnID.Entities(screl.ColumnID, column, computedExpr),
+			rel.Filter("isVirtual", computedExpr)(isVirtual(true)),
+
+			joinTargetNode(computedExpr, computedExprTarget, computedExprNode, add, public),
+			joinTargetNode(column, columnTarget, columnNode, add, public),
+		),
+	)
+
+	register(
+		"computed expression removed after column no longer writable",
+		scgraph.Precedence,
+		columnNode, computedExprNode,
+		screl.MustQuery(
+			computedExpr.Type((*scpb.ComputedExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, computedExpr),
+			columnID.Entities(screl.ColumnID, column, computedExpr),
+
+			joinTargetNode(column, columnTarget, columnNode, drop, deleteOnly),
+			joinTargetNode(computedExpr, computedExprTarget, computedExprNode, drop, absent),
+		),
+	)
+}
+
+func init() {
+	onUpdateExpr, onUpdateExprTarget, onUpdateExprNode := targetNodeVars("on-update-expr")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	tabID := rel.Var("desc-id")
+	columnID := rel.Var("column-id")
+
+	register(
+		"on update expression set after column existence",
+		scgraph.Precedence,
+		columnNode, onUpdateExprNode,
+		screl.MustQuery(
+			onUpdateExpr.Type((*scpb.OnUpdateExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, onUpdateExpr),
+			columnID.Entities(screl.ColumnID, column, onUpdateExpr),
+
+			joinTargetNode(column, columnTarget, columnNode, add, deleteOnly),
+			joinTargetNode(onUpdateExpr, onUpdateExprTarget, onUpdateExprNode, add, public),
+		),
+	)
+
+	register(
+		"on update expression removed after column no longer writable",
+		scgraph.Precedence,
+		columnNode, onUpdateExprNode,
+		screl.MustQuery(
+			onUpdateExpr.Type((*scpb.OnUpdateExpr)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tabID.Entities(screl.DescID, column, onUpdateExpr),
+			columnID.Entities(screl.ColumnID, column, onUpdateExpr),
+
+			joinTargetNode(column, columnTarget, columnNode, drop, deleteOnly),
+			joinTargetNode(onUpdateExpr, onUpdateExprTarget, onUpdateExprNode, drop, absent),
+		),
+	)
 }
 
 func init() {
@@ -467,6 +758,54 @@ func init() {
 			joinTargetNode(index, indexTarget, indexNode, drop, absent),
 		),
 	)
+
+	// When an index is renamed, the old name is removed in the same stage as
+	// the new name is set, so that the index always has exactly one name.
+	oldName, oldNameTarget, oldNameNode := targetNodeVars("old-index-name")
+	newName, newNameTarget, newNameNode := targetNodeVars("new-index-name")
+	register(
+		"index renamed in the same stage as old name removed",
+		scgraph.SameStagePrecedence,
+		oldNameNode, newNameNode,
+		screl.MustQuery(
+			oldName.Type((*scpb.IndexName)(nil)),
+			newName.Type((*scpb.IndexName)(nil)),
+
+			tabID.Entities(screl.DescID, oldName, newName),
+			indexID.Entities(screl.IndexID, oldName, newName),
+
+			joinTargetNode(oldName, oldNameTarget, oldNameNode, drop, absent),
+			joinTargetNode(newName, newNameTarget, newNameNode, add, public),
+		),
+	)
+}
+
+func init() {
+	// When a constraint is renamed, the old name is removed in the same stage
+	// as the new name is set, so that the constraint always has exactly one
+	// name.
+	oldName, oldNameTarget, oldNameNode := targetNodeVars("old-constraint-name")
+	newName, newNameTarget, newNameNode := targetNodeVars("new-constraint-name")
+	tabID := rel.Var("desc-id")
+	constraintType := rel.Var("constraint-type")
+	constraintOrdinal := rel.Var("constraint-ordinal")
+
+	register(
+		"constraint renamed in the same stage as old name removed",
+		scgraph.SameStagePrecedence,
+		oldNameNode, newNameNode,
+		screl.MustQuery(
+			oldName.Type((*scpb.ConstraintName)(nil)),
+			newName.Type((*scpb.ConstraintName)(nil)),
+
+			tabID.Entities(screl.DescID, oldName, newName),
+			constraintType.Entities(screl.ConstraintType, oldName, newName),
+			constraintOrdinal.Entities(screl.ConstraintOrdinal, oldName, newName),
+
+			joinTargetNode(oldName, oldNameTarget, oldNameNode, drop, absent),
+			joinTargetNode(newName, newNameTarget, newNameNode, add, public),
+		),
+	)
 }
 
 func init() {
@@ -498,6 +837,172 @@ func init() {
 	)
 }
 
+func init() {
+	// Ensures that the back-reference from a type to a column which uses it,
+	// either directly or in an expression, is only removed once the column is
+	// no longer public. The removal is not delayed further, until the column is
+	// absent, as that happens only after the type is dropped when both are.
+	typeRef, typeRefTarget, typeRefNode := targetNodeVars("type-ref")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	tableID := rel.Var("table-id")
+	columnID := rel.Var("column-id")
+
+	register(
+		"column type ref removed after column no longer public",
+		scgraph.Precedence,
+		columnNode, typeRefNode,
+		screl.MustQuery(
+			typeRef.Type((*scpb.ColumnTypeReference)(nil), (*scpb.DefaultExprTypeReference)(nil),
+				(*scpb.OnUpdateExprTypeReference)(nil), (*scpb.ComputedExprTypeReference)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			tableID.Entities(screl.DescID, column, typeRef),
+			columnID.Entities(screl.ColumnID, column, typeRef),
+
+			joinTargetNode(column, columnTarget, columnNode, drop, deleteAndWriteOnly),
+			joinTargetNode(typeRef, typeRefTarget, typeRefNode, drop, absent),
+		),
+	)
+}
+
+func init() {
+	ownedBy, ownedByTarget, ownedByNode := targetNodeVars("owned-by")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	tableID := rel.Var("table-id")
+	columnID := rel.Var("column-id")
+
+	register(
+		"sequence ownership removed before the owning column",
+		scgraph.Precedence,
+		ownedByNode, columnNode,
+		screl.MustQuery(
+			ownedBy.Type((*scpb.SequenceOwnedBy)(nil)),
+			column.Type((*scpb.Column)(nil)),
+
+			column.AttrEqVar(screl.DescID, tableID),
+			ownedBy.AttrEqVar(screl.ReferencedDescID, tableID),
+			columnID.Entities(screl.ColumnID, column, ownedBy),
+
+			joinTargetNode(ownedBy, ownedByTarget, ownedByNode, drop, absent),
+			joinTargetNode(column, columnTarget, columnNode, drop, absent),
+		),
+	)
+
+	// The removal of the ownership of a sequence resets it on the sequence
+	// itself, so it must happen before any new ownership is set.
+	oldOwnedBy, oldOwnedByTarget, oldOwnedByNode := targetNodeVars("old-owned-by")
+	newOwnedBy, newOwnedByTarget, newOwnedByNode := targetNodeVars("new-owned-by")
+	seqID := rel.Var("seq-id")
+
+	register(
+		"sequence ownership set after previous ownership removed",
+		scgraph.Precedence,
+		oldOwnedByNode, newOwnedByNode,
+		screl.MustQuery(
+			oldOwnedBy.Type((*scpb.SequenceOwnedBy)(nil)),
+			newOwnedBy.Type((*scpb.SequenceOwnedBy)(nil)),
+
+			seqID.Entities(screl.DescID, oldOwnedBy, newOwnedBy),
+
+			joinTargetNode(oldOwnedBy, oldOwnedByTarget, oldOwnedByNode, drop, absent),
+			joinTargetNode(newOwnedBy, newOwnedByTarget, newOwnedByNode, add, public),
+		),
+	)
+}
+
+func init() {
+	// Ensures that a foreign key is only validated once the indexes which the
+	// validation scans are ready: the unique index on the referenced columns,
+	// and any index being backfilled on the origin table.
+	fk, fkTarget, fkNode := targetNodeVars("fk")
+	unique, uniqueTarget, uniqueNode := targetNodeVars("unique")
+	index, indexTarget, indexNode := targetNodeVars("index")
+	referencedID := rel.Var("referenced-id")
+	originID := rel.Var("origin-id")
+
+	register(
+		"foreign key validated after referenced unique index public",
+		scgraph.Precedence,
+		uniqueNode, fkNode,
+		screl.MustQuery(
+			fk.Type((*scpb.ForeignKey)(nil)),
+			unique.Type((*scpb.PrimaryIndex)(nil), (*scpb.SecondaryIndex)(nil),
+				(*scpb.UniqueConstraint)(nil)),
+
+			fk.AttrEqVar(screl.ReferencedDescID, referencedID),
+			unique.AttrEqVar(screl.DescID, referencedID),
+			rel.Filter("referencesUniqueColumns", fk, unique)(referencesUniqueColumns),
+
+			joinTargetNode(unique, uniqueTarget, uniqueNode, add, public),
+			joinTargetNode(fk, fkTarget, fkNode, add, validated),
+		),
+	)
+
+	register(
+		"foreign key validated after origin index backfilled",
+		scgraph.Precedence,
+		indexNode, fkNode,
+		screl.MustQuery(
+			fk.Type((*scpb.ForeignKey)(nil)),
+			index.Type((*scpb.PrimaryIndex)(nil), (*scpb.SecondaryIndex)(nil)),
+
+			originID.Entities(screl.DescID, fk, index),
+
+			joinTargetNode(index, indexTarget, indexNode, add, scpb.Status_BACKFILLED),
+			joinTargetNode(fk, fkTarget, fkNode, add, validated),
+		),
+	)
+}
+
+func init() {
+	// Ensures that when a relation is dropped with CASCADE, the objects which
+	// depend on it are torn down first: foreign keys on other tables which
+	// reference its indexes, and views which select from it.
+	fk, fkTarget, fkNode := targetNodeVars("fk")
+	index, indexTarget, indexNode := targetNodeVars("index")
+	referencedID := rel.Var("referenced-id")
+
+	register(
+		"referencing foreign key removed before referenced index no longer written",
+		scgraph.Precedence,
+		fkNode, indexNode,
+		screl.MustQuery(
+			fk.Type((*scpb.ForeignKey)(nil)),
+			index.Type((*scpb.PrimaryIndex)(nil), (*scpb.SecondaryIndex)(nil)),
+
+			fk.AttrEqVar(screl.ReferencedDescID, referencedID),
+			index.AttrEqVar(screl.DescID, referencedID),
+			rel.Filter("referencesUniqueColumns", fk, index)(referencesUniqueColumns),
+
+			joinTargetNode(fk, fkTarget, fkNode, drop, absent),
+			joinTargetNode(index, indexTarget, indexNode, drop, deleteOnly),
+		),
+	)
+
+	view, viewTarget, viewNode := targetNodeVars("view")
+	relation, relationTarget, relationNode := targetNodeVars("relation")
+	dep := rel.Var("dep")
+	viewID, relationID := rel.Var("view-id"), rel.Var("relation-id")
+
+	register(
+		"dependent view dropped before the relation it depends on",
+		scgraph.Precedence,
+		viewNode, relationNode,
+		screl.MustQuery(
+			view.Type((*scpb.View)(nil)),
+			dep.Type((*scpb.RelationDependedOnBy)(nil)),
+			relation.Type((*scpb.Table)(nil), (*scpb.View)(nil), (*scpb.Sequence)(nil)),
+
+			view.AttrEqVar(screl.DescID, viewID),
+			dep.AttrEqVar(screl.ReferencedDescID, viewID),
+			relationID.Entities(screl.DescID, dep, relation),
+
+			joinTargetNode(view, viewTarget, viewNode, drop, dropped),
+			joinTargetNode(relation, relationTarget, relationNode, drop, dropped),
+		),
+	)
+}
+
 func init() {
 	// Ensure table dependencies drop after the table is marked as dropped.
 	dep, depTarget, depNode := targetNodeVars("dep-drop")
@@ -509,7 +1014,8 @@ func init() {
 		scgraph.Precedence,
 		tblNode, depNode,
 		screl.MustQuery(
-			dep.Type((*scpb.Owner)(nil), (*scpb.UserPrivileges)(nil), (*scpb.Locality)(nil)),
+			dep.Type((*scpb.Owner)(nil), (*scpb.UserPrivileges)(nil), (*scpb.Locality)(nil),
+				(*scpb.ColumnName)(nil), (*scpb.IndexName)(nil)),
 			tbl.Type((*scpb.Table)(nil), (*scpb.Sequence)(nil), (*scpb.View)(nil)),
 
 			tableID.Entities(screl.DescID, tbl, dep),
@@ -562,3 +1068,48 @@ func init() {
 		),
 	)
 }
+
+func init() {
+	// A REGIONAL BY ROW locality may only be set once the table has a region
+	// column and its indexes are partitioned by it. Other localities have no
+	// such requirement.
+	locality, localityTarget, localityNode := targetNodeVars("locality")
+	column, columnTarget, columnNode := targetNodeVars("column")
+	partitioning, partitioningTarget, partitioningNode := targetNodeVars("partitioning")
+	columnName := rel.Var("column-name")
+	tableID, columnID := rel.Var("table-id"), rel.Var("column-id")
+
+	register(
+		"regional by row locality set after region column public",
+		scgraph.Precedence,
+		columnNode, localityNode,
+		screl.MustQuery(
+			locality.Type((*scpb.Locality)(nil)),
+			column.Type((*scpb.Column)(nil)),
+			columnName.Type((*scpb.ColumnName)(nil)),
+
+			tableID.Entities(screl.DescID, locality, column, columnName),
+			columnID.Entities(screl.ColumnID, column, columnName),
+			rel.Filter("isRegionColumnName", locality, columnName)(isRegionColumnName),
+
+			joinTargetNode(column, columnTarget, columnNode, add, public),
+			joinTargetNode(locality, localityTarget, localityNode, add, public),
+		),
+	)
+
+	register(
+		"regional by row locality set after partitioning public",
+		scgraph.Precedence,
+		partitioningNode, localityNode,
+		screl.MustQuery(
+			locality.Type((*scpb.Locality)(nil)),
+			partitioning.Type((*scpb.Partitioning)(nil)),
+
+			tableID.Entities(screl.DescID, locality, partitioning),
+			rel.Filter("isRegionalByRow", locality)(isRegionalByRow),
+
+			joinTargetNode(partitioning, partitioningTarget, partitioningNode, add, public),
+			joinTargetNode(locality, localityTarget, localityNode, add, public),
+		),
+	)
+}
@@ -0,0 +1,98 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package scplan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/screl"
+)
+
+// Explanation is a printable representation of the stages of a plan, of the
+// transitions they perform and of their ops, in execution order.
+type Explanation struct {
+	Stages []StageExplanation
+}
+
+// StageExplanation is the printable representation of a stage.
+type StageExplanation struct {
+	// Stage is a short description of the stage, e.g.
+	// "PostCommitPhase stage 1 of 2 with 3 MutationType ops".
+	Stage string
+	Phase scop.Phase
+	// Transitions are the transitions of the targets which change status in
+	// this stage, e.g. "[ForeignKey:{DescID: 53, ...}, PUBLIC] -> ABSENT".
+	Transitions []string
+	// Ops are the ops of the stage, in execution order.
+	Ops []scop.Op
+}
+
+// ExplainPlan plans the schema change for the given initial state without
+// executing it, and returns an explanation of the plan.
+func ExplainPlan(initial scpb.State, params Params) (Explanation, error) {
+	p, err := MakePlan(initial, params)
+	if err != nil {
+		return Explanation{}, err
+	}
+	return p.Explain(), nil
+}
+
+// Explain returns an explanation of the plan.
+func (p Plan) Explain() Explanation {
+	e := Explanation{Stages: make([]StageExplanation, len(p.Stages))}
+	for i, s := range p.Stages {
+		se := StageExplanation{
+			Stage: s.String(),
+			Phase: s.Phase,
+			Ops:   s.Ops(),
+		}
+		for j, before := range s.Before.Nodes {
+			if after := s.After.Nodes[j]; before != after {
+				se.Transitions = append(se.Transitions,
+					fmt.Sprintf("%s -> %s", screl.NodeString(before), after.Status))
+			}
+		}
+		e.Stages[i] = se
+	}
+	return e
+}
+
+// String renders the explanation as indented text, one line per stage,
+// transition and op.
+func (e Explanation) String() string {
+	var sb strings.Builder
+	for _, s := range e.Stages {
+		sb.WriteString(s.Stage)
+		sb.WriteString("\n  transitions:\n")
+		for _, t := range s.Transitions {
+			fmt.Fprintf(&sb, "    %s\n", t)
+		}
+		if len(s.Ops) == 0 {
+			sb.WriteString("  no ops\n")
+			continue
+		}
+		sb.WriteString("  ops:\n")
+		for _, op := range s.Ops {
+			fmt.Fprintf(&sb, "    %T", op)
+			// Ops only hold plain values, which can always be marshaled. Should
+			// that ever fail, only the type of the op is rendered.
+			if data, err := json.Marshal(op); err == nil {
+				fmt.Fprintf(&sb, " %s", data)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -41,13 +41,20 @@ func makeOpsFunc(el scpb.Element, fns []interface{}) (opsFunc, error) {
 			} else {
 				out = fn.Call(inWithMeta)
 			}
-			ret = append(ret, out[0].Interface().(scop.Op))
+			if fn.Type().Out(0) == opSliceType {
+				ret = append(ret, out[0].Interface().([]scop.Op)...)
+			} else {
+				ret = append(ret, out[0].Interface().(scop.Op))
+			}
 		}
 		return ret
 	}, nil
 }
 
-var opType = reflect.TypeOf((*scop.Op)(nil)).Elem()
+var (
+	opType      = reflect.TypeOf((*scop.Op)(nil)).Elem()
+	opSliceType = reflect.TypeOf(([]scop.Op)(nil))
+)
 
 func checkOpFunc(el scpb.Element, fn interface{}) error {
 	fnV := reflect.ValueOf(fn)
@@ -65,9 +72,10 @@ func checkOpFunc(el scpb.Element, fn interface{}) error {
 			"expected %v to be a func with one argument of type %s", fnT, elType,
 		)
 	}
-	if fnT.NumOut() != 1 || !fnT.Out(0).Implements(opType) {
+	if fnT.NumOut() != 1 || !(fnT.Out(0).Implements(opType) || fnT.Out(0) == opSliceType) {
 		return errors.Errorf(
-			"expected %v to be a func with one return value of type %s", fnT, opType,
+			"expected %v to be a func with one return value of type %s or %s",
+			fnT, opType, opSliceType,
 		)
 	}
 	return nil
@@ -0,0 +1,69 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmitMultipleOps checks that a transition may mix functions emitting a
+// single op with functions emitting several, and that the ops are emitted in
+// order.
+func TestEmitMultipleOps(t *testing.T) {
+	fk := &scpb.ForeignKey{OriginID: 52, ReferenceID: 53, Name: "fk"}
+	spec := add(
+		to(scpb.Status_PUBLIC,
+			revertible(true),
+			emit(func(this *scpb.ForeignKey) []scop.Op {
+				return []scop.Op{
+					&scop.DropForeignKeyRef{TableID: this.OriginID, Name: this.Name, Outbound: true},
+					&scop.DropForeignKeyRef{TableID: this.ReferenceID, Name: this.Name},
+				}
+			}),
+			emit(func(this *scpb.ForeignKey) scop.Op {
+				return &scop.UpdateRelationDeps{TableID: this.OriginID}
+			}),
+			emit(func(this *scpb.ForeignKey) []scop.Op {
+				return nil
+			}),
+		),
+	)
+	transitions := makeTransitions(fk, spec.transitionSpecs)
+	require.Len(t, transitions, 1)
+	require.Equal(t, []scop.Op{
+		&scop.DropForeignKeyRef{TableID: descpb.ID(52), Name: "fk", Outbound: true},
+		&scop.DropForeignKeyRef{TableID: descpb.ID(53), Name: "fk"},
+		&scop.UpdateRelationDeps{TableID: descpb.ID(52)},
+	}, transitions[0].ops(fk, nil /* metadata */))
+}
+
+func TestCheckOpFunc(t *testing.T) {
+	fk := &scpb.ForeignKey{}
+	for _, fn := range []interface{}{
+		func(this *scpb.ForeignKey) scop.Op { return nil },
+		func(this *scpb.ForeignKey) []scop.Op { return nil },
+		func(this *scpb.ForeignKey, md *scpb.ElementMetadata) []scop.Op { return nil },
+	} {
+		require.NoError(t, checkOpFunc(fk, fn))
+	}
+	for _, fn := range []interface{}{
+		func(this *scpb.ForeignKey) []*scop.DropForeignKeyRef { return nil },
+		func(this *scpb.ForeignKey) (scop.Op, scop.Op) { return nil, nil },
+		func(this *scpb.Table) []scop.Op { return nil },
+	} {
+		require.Error(t, checkOpFunc(fk, fn))
+	}
+}
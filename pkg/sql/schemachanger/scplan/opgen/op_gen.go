@@ -17,6 +17,10 @@ import (
 
 type registry struct {
 	targets []target
+
+	// variantTargets apply to a subset of the elements matched by targets, and
+	// take precedence over them.
+	variantTargets []target
 }
 
 var opRegistry = &registry{}
@@ -40,9 +44,19 @@ func (r *registry) buildGraph(initial scpb.State) (*scgraph.Graph, error) {
 		n *scpb.Node
 	}
 	var edgesToAdd []toAdd
-	for _, t := range r.targets {
+	// Each scpb.Target is only ever matched by a single target, the first one
+	// to do so.
+	matched := make(map[*scpb.Target]struct{})
+	targets := make([]target, 0, len(r.variantTargets)+len(r.targets))
+	targets = append(targets, r.variantTargets...)
+	targets = append(targets, r.targets...)
+	for _, t := range targets {
 		edgesToAdd = edgesToAdd[:0]
 		if err := t.iterateFunc(g.Database(), func(n *scpb.Node) error {
+			if _, found := matched[n.Target]; found {
+				return nil
+			}
+			matched[n.Target] = struct{}{}
 			status := n.Status
 			for _, op := range t.transitions {
 				if op.from == status {
@@ -0,0 +1,124 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scgraph"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/datadriven"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// TestOpGenDataDriven renders the op edges which take a single target from
+// its initial to its final status. The element is specified as the yaml
+// representation of an scpb.ElementProto, for example:
+//
+//	ops direction=drop
+//	outForeignKey: {originId: 53, referenceId: 52, name: fk}
+func TestOpGenDataDriven(t *testing.T) {
+	datadriven.Walk(t, testutils.TestDataPath(t), func(t *testing.T, path string) {
+		datadriven.RunTest(t, path, func(t *testing.T, d *datadriven.TestData) string {
+			switch d.Cmd {
+			case "ops":
+				var dirStr string
+				d.ScanArgs(t, "direction", &dirStr)
+				dir, ok := scpb.Target_Direction_value[strings.ToUpper(dirStr)]
+				if !ok {
+					d.Fatalf(t, "unknown direction: %s", dirStr)
+				}
+				e := parseElement(t, d.Input)
+				return marshalOpEdges(t, opEdges(t, scpb.Target_Direction(dir), e))
+			default:
+				return fmt.Sprintf("unknown command: %s", d.Cmd)
+			}
+		})
+	})
+}
+
+// parseElement decodes the yaml representation of an scpb.ElementProto.
+func parseElement(t *testing.T, input string) scpb.Element {
+	var m map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(input), &m))
+	data, err := json.Marshal(m)
+	require.NoError(t, err)
+	var ep scpb.ElementProto
+	require.NoError(t, jsonpb.Unmarshal(bytes.NewReader(data), &ep))
+	e := ep.Element()
+	require.NotNil(t, e, "no element in %q", input)
+	return e
+}
+
+// marshalOpEdges renders each op edge as its transition, the earliest phase in
+// which it may be executed, its revertibility and its ops.
+func marshalOpEdges(t *testing.T, edges []*scgraph.OpEdge) string {
+	var sb strings.Builder
+	for _, oe := range edges {
+		fmt.Fprintf(&sb, "%s -> %s\n", oe.From().Status, oe.To().Status)
+		for p := scop.EarliestPhase; p <= scop.LatestPhase; p++ {
+			if oe.IsPhaseSatisfied(p) {
+				fmt.Fprintf(&sb, "  phase: %s\n", p)
+				break
+			}
+		}
+		fmt.Fprintf(&sb, "  type: %s\n", oe.Type())
+		fmt.Fprintf(&sb, "  revertible: %t\n", oe.Revertible())
+		sb.WriteString("  ops:\n")
+		for _, op := range oe.Op() {
+			fmt.Fprintf(&sb, "    %T\n", op)
+			data, err := json.Marshal(op)
+			require.NoError(t, err)
+			var m map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &m))
+			out, err := yaml.Marshal(m)
+			require.NoError(t, err)
+			for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+				fmt.Fprintf(&sb, "      %s\n", line)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// opEdges builds the graph of a single target for e in direction dir, and
+// returns the op edges which take it from its initial to its final status, in
+// order.
+func opEdges(t *testing.T, dir scpb.Target_Direction, e scpb.Element) []*scgraph.OpEdge {
+	t.Helper()
+	initial := scpb.Status_ABSENT
+	if dir == scpb.Target_DROP {
+		initial = scpb.Status_PUBLIC
+	}
+	n := &scpb.Node{Target: scpb.NewTarget(dir, e, nil /* metadata */), Status: initial}
+	g, err := BuildGraph(scpb.State{
+		Nodes:      []*scpb.Node{n},
+		Statements: []*scpb.Statement{{Statement: "test"}},
+	})
+	require.NoError(t, err)
+	var edges []*scgraph.OpEdge
+	for {
+		oe, ok := g.GetOpEdgeFrom(n)
+		if !ok {
+			return edges
+		}
+		edges = append(edges, oe)
+		n = oe.To()
+	}
+}
@@ -11,25 +11,141 @@
 package opgen
 
 import (
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/rel"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/screl"
 )
 
 func init() {
 	opRegistry.register((*scpb.CheckConstraint)(nil),
 		add(
+			// The constraint is added unvalidated, at which point it is
+			// enforced for writes but not yet trusted.
+			to(scpb.Status_DELETE_AND_WRITE_ONLY,
+				revertible(true),
+				emit(func(this *scpb.CheckConstraint) scop.Op {
+					return &scop.AddCheckConstraint{
+						TableID:   this.TableID,
+						Name:      this.Name,
+						Expr:      this.Expr,
+						ColumnIDs: this.ColumnIDs,
+					}
+				}),
+			),
+			// The existing rows are checked against the constraint once the
+			// schema change has committed. The schema change cannot be
+			// reverted past this point.
+			to(scpb.Status_VALIDATED,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.CheckConstraint) scop.Op {
+					return &scop.ValidateCheckConstraint{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
+				}),
+			),
 			to(scpb.Status_PUBLIC,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
 				emit(func(this *scpb.CheckConstraint) scop.Op {
-					return notImplemented(this)
+					return &scop.MakeAddedCheckConstraintPublic{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				minPhase(scop.PreCommitPhase),
+				revertible(true),
+				emit(func(this *scpb.CheckConstraint) scop.Op {
+					return &scop.RemoveCheckConstraint{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
+				}),
+			),
+		),
+	)
+
+	// A constraint added NOT VALID only applies to new writes, the existing
+	// rows are never checked against it. It becomes public, but unvalidated,
+	// straight away.
+	opRegistry.registerVariant((*scpb.CheckConstraint)(nil),
+		"unvalidated", unvalidatedCheckConstraintClauses,
+		add(
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.CheckConstraint) scop.Op {
+					return &scop.AddCheckConstraint{
+						TableID:     this.TableID,
+						Name:        this.Name,
+						Expr:        this.Expr,
+						ColumnIDs:   this.ColumnIDs,
+						Unvalidated: true,
+					}
+				}),
+			),
+		),
+	)
+
+	// VALIDATE CONSTRAINT replaces an unvalidated constraint by a validated one
+	// of the same name. The constraint is already enforced for writes, so only
+	// the existing rows remain to be checked against it, after which it is
+	// marked as validated. Dropping the unvalidated constraint is a no-op.
+	opRegistry.registerVariant((*scpb.CheckConstraint)(nil),
+		"validation", checkConstraintValidationClauses,
+		add(
+			to(scpb.Status_VALIDATED,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.CheckConstraint) scop.Op {
+					return &scop.ValidateCheckConstraint{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
+				}),
+			),
+			to(scpb.Status_PUBLIC,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
 				emit(func(this *scpb.CheckConstraint) scop.Op {
-					return notImplemented(this)
+					return &scop.MakeAddedCheckConstraintPublic{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
 				}),
 			),
 		),
 	)
 }
+
+func isCheckConstraintValidated(validated bool) func(*scpb.CheckConstraint) bool {
+	return func(ck *scpb.CheckConstraint) bool {
+		return ck.Validated == validated
+	}
+}
+
+// unvalidatedCheckConstraintClauses match check constraints added NOT VALID.
+func unvalidatedCheckConstraintClauses(element, _, _ rel.Var) rel.Clause {
+	return rel.Filter("isUnvalidated", element)(isCheckConstraintValidated(false))
+}
+
+// checkConstraintValidationClauses match validated check constraints which
+// replace an unvalidated check constraint of the same name.
+func checkConstraintValidationClauses(element, _, _ rel.Var) rel.Clause {
+	var old, oldTarget, oldNode rel.Var = "old", "old-target", "old-node"
+	var id, name rel.Var = "id", "name"
+	return rel.And(
+		old.Type((*scpb.CheckConstraint)(nil)),
+		id.Entities(screl.DescID, element, old),
+		name.Entities(screl.Name, element, old),
+		rel.Filter("isValidated", element)(isCheckConstraintValidated(true)),
+		rel.Filter("isUnvalidated", old)(isCheckConstraintValidated(false)),
+		screl.JoinTargetNode(old, oldTarget, oldNode),
+		oldTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+	)
+}
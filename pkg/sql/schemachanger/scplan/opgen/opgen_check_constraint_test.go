@@ -0,0 +1,136 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scgraph"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConstraintOpGen(t *testing.T) {
+	// ALTER TABLE t ADD CONSTRAINT ck CHECK (i > 0)
+	const tableID = descpb.ID(52)
+	ck := &scpb.CheckConstraint{
+		TableID:   tableID,
+		Name:      "ck",
+		Expr:      "i > 0:::INT8",
+		ColumnIDs: []descpb.ColumnID{1},
+		Validated: true,
+	}
+
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, ck)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_DELETE_AND_WRITE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddCheckConstraint{
+				TableID:   tableID,
+				Name:      "ck",
+				Expr:      "i > 0:::INT8",
+				ColumnIDs: []descpb.ColumnID{1},
+			},
+		}, edges[0].Op())
+
+		require.Equal(t, scpb.Status_VALIDATED, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.True(t, edges[1].IsPhaseSatisfied(scop.PostCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.ValidateCheckConstraint{TableID: tableID, Name: "ck"},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[2].To().Status)
+		require.False(t, edges[2].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeAddedCheckConstraintPublic{TableID: tableID, Name: "ck"},
+		}, edges[2].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, ck)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.Equal(t, []scop.Op{
+			&scop.RemoveCheckConstraint{TableID: tableID, Name: "ck"},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t ADD CONSTRAINT ck CHECK (i > 0) NOT VALID
+	t.Run("add not valid", func(t *testing.T) {
+		unvalidated := *ck
+		unvalidated.Validated = false
+		edges := opEdges(t, scpb.Target_ADD, &unvalidated)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddCheckConstraint{
+				TableID:     tableID,
+				Name:        "ck",
+				Expr:        "i > 0:::INT8",
+				ColumnIDs:   []descpb.ColumnID{1},
+				Unvalidated: true,
+			},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t VALIDATE CONSTRAINT ck
+	t.Run("validate", func(t *testing.T) {
+		unvalidated := *ck
+		unvalidated.Validated = false
+		oldNode := &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_DROP, &unvalidated, nil /* metadata */),
+			Status: scpb.Status_PUBLIC,
+		}
+		newNode := &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, ck, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		}
+		g, err := BuildGraph(scpb.State{
+			Nodes:      []*scpb.Node{oldNode, newNode},
+			Statements: []*scpb.Statement{{Statement: "test"}},
+		})
+		require.NoError(t, err)
+
+		var edges []*scgraph.OpEdge
+		for n := newNode; ; {
+			oe, ok := g.GetOpEdgeFrom(n)
+			if !ok {
+				break
+			}
+			edges = append(edges, oe)
+			n = oe.To()
+		}
+		require.Len(t, edges, 2)
+
+		require.Equal(t, scpb.Status_VALIDATED, edges[0].To().Status)
+		require.False(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PostCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.ValidateCheckConstraint{TableID: tableID, Name: "ck"},
+		}, edges[0].Op())
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeAddedCheckConstraintPublic{TableID: tableID, Name: "ck"},
+		}, edges[1].Op())
+	})
+}
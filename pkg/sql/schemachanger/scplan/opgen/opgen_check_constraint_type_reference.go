@@ -20,6 +20,7 @@ func init() {
 		add(
 			to(scpb.Status_PUBLIC,
 				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.CheckConstraintTypeReference) scop.Op {
 					return &scop.AddTypeBackRef{
 						TypeID: this.TypeID,
@@ -20,6 +20,7 @@ func init() {
 		add(
 			to(scpb.Status_DELETE_ONLY,
 				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.Column) scop.Op {
 					return &scop.MakeAddedColumnDeleteOnly{
 						TableID:                           this.TableID,
@@ -49,8 +50,13 @@ func init() {
 					}
 				}),
 			),
+			// There is no separate backfill of the column: its values,
+			// including those of its default or computed expression, are
+			// written by the backfill of the new primary index which
+			// stores it, which the column waits for while write-only.
 			to(scpb.Status_DELETE_AND_WRITE_ONLY,
 				minPhase(scop.PostCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.Column) scop.Op {
 					return &scop.MakeAddedColumnDeleteAndWriteOnly{
 						TableID:  this.TableID,
@@ -59,6 +65,7 @@ func init() {
 				}),
 			),
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Column) scop.Op {
 					return &scop.MakeColumnPublic{
 						TableID:  this.TableID,
@@ -69,6 +76,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_DELETE_AND_WRITE_ONLY,
+				revertible(true),
 				emit(func(this *scpb.Column) scop.Op {
 					return &scop.MakeDroppedColumnDeleteAndWriteOnly{
 						TableID:  this.TableID,
@@ -95,6 +103,7 @@ func init() {
 			),
 			to(scpb.Status_ABSENT,
 				minPhase(scop.PostCommitPhase),
+				revertible(false),
 				emit(func(this *scpb.Column) scop.Op {
 					return &scop.MakeColumnAbsent{
 						TableID:  this.TableID,
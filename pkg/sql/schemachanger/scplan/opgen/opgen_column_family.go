@@ -0,0 +1,49 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	opRegistry.register((*scpb.ColumnFamily)(nil),
+		add(
+			// The family only holds metadata until columns join it, so it can be
+			// added in the statement phase, and removed if the schema change is
+			// reverted.
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.ColumnFamily) scop.Op {
+					return &scop.AddColumnFamily{
+						TableID: this.TableID,
+						Family: descpb.ColumnFamilyDescriptor{
+							Name: this.Name,
+							ID:   this.FamilyID,
+						},
+					}
+				}),
+			),
+		),
+		drop(
+			// The family of a dropped column is removed along with it, once it
+			// no longer has any columns.
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.ColumnFamily) scop.Op {
+					return notImplemented(this)
+				}),
+			),
+		),
+	)
+}
@@ -0,0 +1,38 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnFamilyOpGen(t *testing.T) {
+	// ALTER TABLE t ADD COLUMN j INT CREATE FAMILY f
+	const tableID = descpb.ID(52)
+	family := &scpb.ColumnFamily{TableID: tableID, FamilyID: 1, Name: "f"}
+
+	edges := opEdges(t, scpb.Target_ADD, family)
+	require.Len(t, edges, 1)
+	require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+	require.True(t, edges[0].Revertible())
+	require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+	require.Equal(t, []scop.Op{
+		&scop.AddColumnFamily{
+			TableID: tableID,
+			Family:  descpb.ColumnFamilyDescriptor{Name: "f", ID: 1},
+		},
+	}, edges[0].Op())
+}
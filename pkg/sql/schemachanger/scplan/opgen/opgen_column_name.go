@@ -19,9 +19,11 @@ import (
 func init() {
 	opRegistry.register(
 		(*scpb.ColumnName)(nil),
+		// Column names only affect descriptor metadata, so they are set and
+		// unset as early as the statement phase, e.g. for RENAME COLUMN.
 		add(
 			to(scpb.Status_PUBLIC,
-				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.ColumnName) scop.Op {
 					return &scop.SetColumnName{
 						TableID:  this.TableID,
@@ -33,7 +35,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_ABSENT,
-				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.ColumnName) scop.Op {
 					return &scop.SetColumnName{
 						TableID:  this.TableID,
@@ -0,0 +1,52 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnNameOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+
+	// ALTER TABLE t RENAME COLUMN i TO j
+	t.Run("add", func(t *testing.T) {
+		name := &scpb.ColumnName{TableID: tableID, ColumnID: 1, Name: "j"}
+		edges := opEdges(t, scpb.Target_ADD, name)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.SetColumnName{TableID: tableID, ColumnID: 1, Name: "j"},
+		}, edges[0].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		name := &scpb.ColumnName{TableID: tableID, ColumnID: 1, Name: "i"}
+		edges := opEdges(t, scpb.Target_DROP, name)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.SetColumnName{
+				TableID:  tableID,
+				ColumnID: 1,
+				Name:     "crdb_internal_column_1_name_placeholder",
+			},
+		}, edges[0].Op())
+	})
+}
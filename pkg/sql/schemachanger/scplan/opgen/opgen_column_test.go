@@ -0,0 +1,104 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnOpGen(t *testing.T) {
+	// ALTER TABLE t ADD COLUMN j INT DEFAULT 42
+	const tableID = descpb.ID(52)
+	col := &scpb.Column{
+		TableID:        tableID,
+		ColumnID:       2,
+		FamilyName:     "primary",
+		Type:           types.Int,
+		Nullable:       true,
+		DefaultExpr:    "42:::INT8",
+		PgAttributeNum: 2,
+	}
+	requireLogEvent := func(t *testing.T, op scop.Op, dir scpb.Target_Direction) {
+		ev, ok := op.(*scop.LogEvent)
+		require.True(t, ok, "expected a LogEvent, got %T", op)
+		require.Equal(t, tableID, ev.DescID)
+		require.Equal(t, dir, ev.Direction)
+		require.Equal(t, col, ev.Element.Column)
+	}
+
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, col)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_DELETE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Len(t, edges[0].Op(), 2)
+		require.Equal(t, &scop.MakeAddedColumnDeleteOnly{
+			TableID:        tableID,
+			ColumnID:       2,
+			FamilyName:     "primary",
+			ColumnType:     types.Int,
+			Nullable:       true,
+			DefaultExpr:    "42:::INT8",
+			PgAttributeNum: 2,
+		}, edges[0].Op()[0])
+		requireLogEvent(t, edges[0].Op()[1], scpb.Target_ADD)
+
+		// The new column is backfilled along with the primary index which
+		// stores it, while it is write-only.
+		require.Equal(t, scpb.Status_DELETE_AND_WRITE_ONLY, edges[1].To().Status)
+		require.True(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeAddedColumnDeleteAndWriteOnly{TableID: tableID, ColumnID: 2},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[2].To().Status)
+		require.True(t, edges[2].Revertible())
+		require.Equal(t, []scop.Op{
+			&scop.MakeColumnPublic{TableID: tableID, ColumnID: 2},
+		}, edges[2].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, col)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_DELETE_AND_WRITE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Len(t, edges[0].Op(), 2)
+		require.Equal(t, &scop.MakeDroppedColumnDeleteAndWriteOnly{
+			TableID: tableID, ColumnID: 2,
+		}, edges[0].Op()[0])
+		requireLogEvent(t, edges[0].Op()[1], scpb.Target_DROP)
+
+		require.Equal(t, scpb.Status_DELETE_ONLY, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeDroppedColumnDeleteOnly{TableID: tableID, ColumnID: 2},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_ABSENT, edges[2].To().Status)
+		require.False(t, edges[2].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeColumnAbsent{TableID: tableID, ColumnID: 2},
+		}, edges[2].Op())
+	})
+}
@@ -0,0 +1,36 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnTypeReferenceOpGen(t *testing.T) {
+	const tableID, typeID = descpb.ID(52), descpb.ID(53)
+	typeRef := &scpb.ColumnTypeReference{TableID: tableID, ColumnID: 2, TypeID: typeID}
+
+	// ALTER TABLE t DROP COLUMN e, where e is of enum type typ.
+	edges := opEdges(t, scpb.Target_DROP, typeRef)
+	require.Len(t, edges, 1)
+	require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+	require.False(t, edges[0].Revertible())
+	require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+	require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+	require.Equal(t, []scop.Op{
+		&scop.RemoveTypeBackRef{TypeID: typeID, DescID: tableID},
+	}, edges[0].Op())
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	// The ordering of these transitions with respect to those of the column,
+	// and thus to its backfill, is governed by dependency rules.
+	opRegistry.register((*scpb.ComputedExpr)(nil),
+		add(
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.ComputedExpr) scop.Op {
+					return &scop.AddColumnComputedExpression{
+						TableID:  this.TableID,
+						ColumnID: this.ColumnID,
+						Expr:     this.Expr,
+					}
+				}),
+			),
+		),
+		drop(
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.ComputedExpr) scop.Op {
+					return &scop.RemoveColumnComputedExpression{
+						TableID:  this.TableID,
+						ColumnID: this.ColumnID,
+					}
+				}),
+			),
+		),
+	)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputedExprOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	for _, virtual := range []bool{false, true} {
+		computedExpr := &scpb.ComputedExpr{
+			TableID:  tableID,
+			ColumnID: 2,
+			Expr:     "i + 1:::INT8",
+			Virtual:  virtual,
+		}
+		name := "stored"
+		if virtual {
+			name = "virtual"
+		}
+		// The transitions are the same for stored and virtual columns, only the
+		// dependency rules which order them against those of the column differ.
+		t.Run(name, func(t *testing.T) {
+			t.Run("add", func(t *testing.T) {
+				edges := opEdges(t, scpb.Target_ADD, computedExpr)
+				require.Len(t, edges, 1)
+				require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+				require.True(t, edges[0].Revertible())
+				require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+				require.Equal(t, []scop.Op{
+					&scop.AddColumnComputedExpression{
+						TableID:  tableID,
+						ColumnID: 2,
+						Expr:     "i + 1:::INT8",
+					},
+				}, edges[0].Op())
+			})
+			t.Run("drop", func(t *testing.T) {
+				edges := opEdges(t, scpb.Target_DROP, computedExpr)
+				require.Len(t, edges, 1)
+				require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+				require.Equal(t, []scop.Op{
+					&scop.RemoveColumnComputedExpression{TableID: tableID, ColumnID: 2},
+				}, edges[0].Op())
+			})
+		})
+	}
+}
@@ -11,25 +11,51 @@
 package opgen
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
 )
 
 func init() {
 	opRegistry.register((*scpb.ConstraintName)(nil),
+		// Constraint names only affect descriptor metadata, so they are set and
+		// unset as early as the statement phase, e.g. for RENAME CONSTRAINT. The
+		// old name is replaced by a placeholder first, in the same stage, which
+		// is then replaced by the new name.
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.ConstraintName) scop.Op {
-					return notImplemented(this)
+					return &scop.SetConstraintName{
+						TableID:        this.TableID,
+						ConstraintType: this.ConstraintType,
+						OldName:        constraintNamePlaceholder(this),
+						Name:           this.Name,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				revertible(true),
 				emit(func(this *scpb.ConstraintName) scop.Op {
-					return notImplemented(this)
+					return &scop.SetConstraintName{
+						TableID:        this.TableID,
+						ConstraintType: this.ConstraintType,
+						OldName:        this.Name,
+						Name:           constraintNamePlaceholder(this),
+					}
 				}),
 			),
 		),
 	)
 }
+
+// constraintNamePlaceholder constructs a placeholder name for a constraint
+// based on its type and ordinal.
+func constraintNamePlaceholder(this *scpb.ConstraintName) string {
+	return fmt.Sprintf("crdb_internal_%s_constraint_%d_name_placeholder",
+		strings.ToLower(this.ConstraintType.String()), this.ConstraintOrdinal)
+}
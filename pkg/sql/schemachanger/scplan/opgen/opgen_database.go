@@ -19,6 +19,7 @@ func init() {
 	opRegistry.register((*scpb.Database)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Database) scop.Op {
 					return notImplemented(this)
 				}),
@@ -28,6 +29,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_TXN_DROPPED,
+				revertible(true),
 				emit(func(this *scpb.Database) scop.Op {
 					return &scop.MarkDescriptorAsDroppedSynthetically{
 						DescID: this.DatabaseID,
@@ -20,6 +20,7 @@ func init() {
 		(*scpb.DatabaseSchemaEntry)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.DatabaseSchemaEntry) scop.Op {
 					return notImplemented(this)
 				}),
@@ -19,13 +19,27 @@ func init() {
 	opRegistry.register((*scpb.DefaultExpression)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.DefaultExpression) scop.Op {
-					return notImplemented(this)
+					return &scop.AddColumnDefaultExpression{
+						TableID:         this.TableID,
+						ColumnID:        this.ColumnID,
+						DefaultExpr:     this.DefaultExpr,
+						UsesSequenceIDs: this.UsesSequenceIDs,
+					}
+				}),
+				emit(func(this *scpb.DefaultExpression) scop.Op {
+					return &scop.UpdateRelationDeps{
+						TableID: this.TableID,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				// The back-references held by the sequences are removed when they are
+				// dropped, which happens no earlier than the pre-commit phase, and the
+				// forward references must not be removed before them.
 				minPhase(scop.PreCommitPhase),
 				revertible(false),
 				emit(func(this *scpb.DefaultExpression) scop.Op {
@@ -0,0 +1,60 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultExpressionOpGen(t *testing.T) {
+	const tableID, seqID = descpb.ID(52), descpb.ID(53)
+	defExpr := &scpb.DefaultExpression{
+		TableID:         tableID,
+		ColumnID:        2,
+		UsesSequenceIDs: []descpb.ID{seqID},
+		DefaultExpr:     "nextval(53:::REGCLASS)",
+	}
+
+	// ALTER TABLE t ALTER COLUMN j SET DEFAULT nextval('sq')
+	t.Run("set default", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, defExpr)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddColumnDefaultExpression{
+				TableID:         tableID,
+				ColumnID:        2,
+				DefaultExpr:     "nextval(53:::REGCLASS)",
+				UsesSequenceIDs: []descpb.ID{seqID},
+			},
+			&scop.UpdateRelationDeps{TableID: tableID},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t ALTER COLUMN j DROP DEFAULT
+	t.Run("drop default", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, defExpr)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveColumnDefaultExpression{TableID: tableID, ColumnID: 2},
+			&scop.UpdateRelationDeps{TableID: tableID},
+		}, edges[0].Op())
+	})
+}
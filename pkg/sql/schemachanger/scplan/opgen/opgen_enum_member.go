@@ -0,0 +1,59 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	opRegistry.register((*scpb.EnumMember)(nil),
+		add(
+			// The member is first added read-only, which is what DELETE_ONLY
+			// stands for here: it can be decoded but not written, so it is not
+			// usable in the transaction which adds it.
+			to(scpb.Status_DELETE_ONLY,
+				revertible(true),
+				emit(func(this *scpb.EnumMember) scop.Op {
+					return &scop.AddEnumMember{
+						TypeID:                 this.TypeID,
+						LogicalRepresentation:  this.LogicalRepresentation,
+						PhysicalRepresentation: this.PhysicalRepresentation,
+					}
+				}),
+			),
+			// Once all nodes can decode it, the member becomes writable, after
+			// which values using it may exist and it can no longer be removed
+			// without validation.
+			to(scpb.Status_PUBLIC,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.EnumMember) scop.Op {
+					return &scop.MakeAddedEnumMemberPublic{
+						TypeID:                this.TypeID,
+						LogicalRepresentation: this.LogicalRepresentation,
+					}
+				}),
+			),
+		),
+		drop(
+			// Dropping a member requires validating that no value uses it, which
+			// is not supported yet.
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.EnumMember) scop.Op {
+					return notImplemented(this)
+				}),
+			),
+		),
+	)
+}
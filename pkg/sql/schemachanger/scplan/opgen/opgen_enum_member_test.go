@@ -0,0 +1,51 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumMemberOpGen(t *testing.T) {
+	// ALTER TYPE typ ADD VALUE 'b'
+	const typeID = descpb.ID(52)
+	member := &scpb.EnumMember{
+		TypeID:                 typeID,
+		LogicalRepresentation:  "b",
+		PhysicalRepresentation: []byte{0xc0},
+	}
+	edges := opEdges(t, scpb.Target_ADD, member)
+	require.Len(t, edges, 2)
+
+	require.Equal(t, scpb.Status_DELETE_ONLY, edges[0].To().Status)
+	require.True(t, edges[0].Revertible())
+	require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+	require.Equal(t, []scop.Op{
+		&scop.AddEnumMember{
+			TypeID:                 typeID,
+			LogicalRepresentation:  "b",
+			PhysicalRepresentation: []byte{0xc0},
+		},
+	}, edges[0].Op())
+
+	require.Equal(t, scpb.Status_PUBLIC, edges[1].To().Status)
+	require.False(t, edges[1].Revertible())
+	require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+	require.True(t, edges[1].IsPhaseSatisfied(scop.PostCommitPhase))
+	require.Equal(t, []scop.Op{
+		&scop.MakeAddedEnumMemberPublic{TypeID: typeID, LogicalRepresentation: "b"},
+	}, edges[1].Op())
+}
@@ -20,6 +20,7 @@ func init() {
 		(*scpb.ForeignKeyBackReference)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.ForeignKeyBackReference) scop.Op {
 					return notImplemented(this)
 				}),
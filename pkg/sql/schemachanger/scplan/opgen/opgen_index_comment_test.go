@@ -0,0 +1,58 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexCommentOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	comment := &scpb.IndexComment{TableID: tableID, IndexID: 2, Comment: "hello"}
+
+	// COMMENT ON INDEX t@idx IS 'hello'
+	t.Run("set", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, comment)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.UpsertComment{
+				CommentType: keys.IndexCommentType,
+				ObjectID:    tableID,
+				SubID:       2,
+				Comment:     "hello",
+			},
+		}, edges[0].Op())
+	})
+	// COMMENT ON INDEX t@idx IS NULL
+	t.Run("clear", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, comment)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveComment{
+				CommentType: keys.IndexCommentType,
+				ObjectID:    tableID,
+				SubID:       2,
+			},
+		}, edges[0].Op())
+	})
+}
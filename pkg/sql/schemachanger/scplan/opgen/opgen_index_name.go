@@ -19,9 +19,11 @@ import (
 func init() {
 	opRegistry.register(
 		(*scpb.IndexName)(nil),
+		// Index names only affect descriptor metadata, so they are set and
+		// unset as early as the statement phase, e.g. for RENAME INDEX.
 		add(
 			to(scpb.Status_PUBLIC,
-				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.IndexName) scop.Op {
 					return &scop.SetIndexName{
 						TableID: this.TableID,
@@ -33,7 +35,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_ABSENT,
-				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.IndexName) scop.Op {
 					return &scop.SetIndexName{
 						TableID: this.TableID,
@@ -18,14 +18,28 @@ import (
 func init() {
 	opRegistry.register((*scpb.Locality)(nil),
 		add(
+			// Setting a GLOBAL or REGIONAL BY TABLE locality only changes the
+			// table descriptor, and so is done by the statement itself. A
+			// REGIONAL BY ROW locality requires the region column and the
+			// partitioning of the table's indexes, which are built by
+			// backfilling a new primary index, and the dependency rules
+			// defer it until after those are public.
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Locality) scop.Op {
-					return notImplemented(this)
+					return &scop.SetTableLocality{
+						TableID:  this.DescriptorID,
+						Locality: this.Locality,
+					}
 				}),
 			),
 		),
 		drop(
+			// A table's locality is only ever dropped along with the table,
+			// or replaced by a new locality, and its op edge is a no-op in
+			// both cases.
 			to(scpb.Status_ABSENT,
+				revertible(true),
 				emit(func(this *scpb.Locality) scop.Op {
 					return notImplemented(this)
 				}),
@@ -0,0 +1,99 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalityOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	region := descpb.RegionName("us-east1")
+	as := "region"
+	for _, tc := range []struct {
+		stmt     string
+		locality *descpb.TableDescriptor_LocalityConfig
+	}{
+		{
+			stmt: "ALTER TABLE t SET LOCALITY GLOBAL",
+			locality: &descpb.TableDescriptor_LocalityConfig{
+				Locality: &descpb.TableDescriptor_LocalityConfig_Global_{
+					Global: &descpb.TableDescriptor_LocalityConfig_Global{},
+				},
+			},
+		},
+		{
+			stmt: "ALTER TABLE t SET LOCALITY REGIONAL BY TABLE IN PRIMARY REGION",
+			locality: &descpb.TableDescriptor_LocalityConfig{
+				Locality: &descpb.TableDescriptor_LocalityConfig_RegionalByTable_{
+					RegionalByTable: &descpb.TableDescriptor_LocalityConfig_RegionalByTable{},
+				},
+			},
+		},
+		{
+			stmt: "ALTER TABLE t SET LOCALITY REGIONAL BY TABLE IN \"us-east1\"",
+			locality: &descpb.TableDescriptor_LocalityConfig{
+				Locality: &descpb.TableDescriptor_LocalityConfig_RegionalByTable_{
+					RegionalByTable: &descpb.TableDescriptor_LocalityConfig_RegionalByTable{
+						Region: &region,
+					},
+				},
+			},
+		},
+		{
+			stmt: "ALTER TABLE t SET LOCALITY REGIONAL BY ROW",
+			locality: &descpb.TableDescriptor_LocalityConfig{
+				Locality: &descpb.TableDescriptor_LocalityConfig_RegionalByRow_{
+					RegionalByRow: &descpb.TableDescriptor_LocalityConfig_RegionalByRow{},
+				},
+			},
+		},
+		{
+			stmt: "ALTER TABLE t SET LOCALITY REGIONAL BY ROW AS region",
+			locality: &descpb.TableDescriptor_LocalityConfig{
+				Locality: &descpb.TableDescriptor_LocalityConfig_RegionalByRow_{
+					RegionalByRow: &descpb.TableDescriptor_LocalityConfig_RegionalByRow{
+						As: &as,
+					},
+				},
+			},
+		},
+	} {
+		t.Run(tc.stmt, func(t *testing.T) {
+			locality := &scpb.Locality{DescriptorID: tableID, Locality: tc.locality}
+
+			// Any column or partitioning needed by a REGIONAL BY ROW locality
+			// is ordered before it by the dependency rules rather than here.
+			edges := opEdges(t, scpb.Target_ADD, locality)
+			require.Len(t, edges, 1)
+			require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+			require.True(t, edges[0].Revertible())
+			require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+			require.Equal(t, []scop.Op{
+				&scop.SetTableLocality{TableID: tableID, Locality: tc.locality},
+			}, edges[0].Op())
+
+			// The previous locality is replaced by the new one.
+			edges = opEdges(t, scpb.Target_DROP, locality)
+			require.Len(t, edges, 1)
+			require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+			require.True(t, edges[0].Revertible())
+			require.Equal(t, []scop.Op{
+				&scop.NotImplemented{ElementType: "scpb.Locality"},
+			}, edges[0].Op())
+		})
+	}
+}
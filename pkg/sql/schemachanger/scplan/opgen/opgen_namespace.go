@@ -18,17 +18,24 @@ import (
 func init() {
 	opRegistry.register(
 		(*scpb.Namespace)(nil),
+		// Namespace entries are written in the user transaction, so that
+		// subsequent statements in it resolve the new names.
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Namespace) scop.Op {
-					return notImplemented(this)
+					return &scop.AddNamespaceEntry{
+						DescID:     this.DescriptorID,
+						DatabaseID: this.DatabaseID,
+						SchemaID:   this.SchemaID,
+						Name:       this.Name,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
-				minPhase(scop.PreCommitPhase),
-				revertible(false),
+				revertible(true),
 				emit(func(this *scpb.Namespace) scop.Op {
 					return &scop.DrainDescriptorName{
 						TableID: this.DescriptorID,
@@ -0,0 +1,58 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceOpGen(t *testing.T) {
+	const dbID, schemaID, tableID = descpb.ID(50), descpb.ID(51), descpb.ID(52)
+	ns := &scpb.Namespace{
+		DatabaseID:   dbID,
+		SchemaID:     schemaID,
+		DescriptorID: tableID,
+		Name:         "t",
+	}
+
+	// CREATE TABLE t (i INT PRIMARY KEY)
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, ns)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddNamespaceEntry{
+				DescID:     tableID,
+				DatabaseID: dbID,
+				SchemaID:   schemaID,
+				Name:       "t",
+			},
+		}, edges[0].Op())
+	})
+	// DROP TABLE t
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, ns)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.DrainDescriptorName{TableID: tableID},
+		}, edges[0].Op())
+	})
+}
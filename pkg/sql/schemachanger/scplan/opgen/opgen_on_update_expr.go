@@ -0,0 +1,47 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	// Setting or removing an ON UPDATE expression only changes the column
+	// descriptor. When the column itself is added or dropped, dependency rules
+	// ensure that it exists whenever the expression is changed.
+	opRegistry.register((*scpb.OnUpdateExpr)(nil),
+		add(
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.OnUpdateExpr) scop.Op {
+					return &scop.AddColumnOnUpdateExpression{
+						TableID:  this.TableID,
+						ColumnID: this.ColumnID,
+						Expr:     this.Expr,
+					}
+				}),
+			),
+		),
+		drop(
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.OnUpdateExpr) scop.Op {
+					return &scop.RemoveColumnOnUpdateExpression{
+						TableID:  this.TableID,
+						ColumnID: this.ColumnID,
+					}
+				}),
+			),
+		),
+	)
+}
@@ -0,0 +1,56 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnUpdateExprOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	onUpdateExpr := &scpb.OnUpdateExpr{
+		TableID:  tableID,
+		ColumnID: 2,
+		Expr:     "now():::TIMESTAMPTZ",
+	}
+
+	// ALTER TABLE t ALTER COLUMN c SET ON UPDATE now()
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, onUpdateExpr)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddColumnOnUpdateExpression{
+				TableID:  tableID,
+				ColumnID: 2,
+				Expr:     "now():::TIMESTAMPTZ",
+			},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t ALTER COLUMN c DROP ON UPDATE
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, onUpdateExpr)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveColumnOnUpdateExpression{TableID: tableID, ColumnID: 2},
+		}, edges[0].Op())
+	})
+}
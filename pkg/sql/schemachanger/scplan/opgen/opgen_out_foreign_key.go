@@ -11,6 +11,7 @@
 package opgen
 
 import (
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
 )
@@ -18,9 +19,56 @@ import (
 func init() {
 	opRegistry.register((*scpb.ForeignKey)(nil),
 		add(
+			to(scpb.Status_DELETE_AND_WRITE_ONLY,
+				// Installing the references is a cheap descriptor change, so
+				// like the legacy schema changer it is done by the statement
+				// itself. They are enforced for writes but not yet trusted,
+				// and can still be removed if the schema change is reverted.
+				revertible(true),
+				emit(func(this *scpb.ForeignKey) scop.Op {
+					return &scop.AddForeignKeyRef{
+						TableID:    this.OriginID,
+						Outbound:   true,
+						ForeignKey: foreignKeyConstraint(this, descpb.ConstraintValidity_Validating),
+					}
+				}),
+				emit(func(this *scpb.ForeignKey) scop.Op {
+					return &scop.AddForeignKeyRef{
+						TableID:    this.ReferenceID,
+						Outbound:   false,
+						ForeignKey: foreignKeyConstraint(this, descpb.ConstraintValidity_Validating),
+					}
+				}),
+			),
+			// The existing rows of the origin table are checked against the
+			// referenced table. This scan is expensive, and so is deferred
+			// until after the user transaction has committed.
+			to(scpb.Status_VALIDATED,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.ForeignKey) scop.Op {
+					return &scop.ValidateForeignKey{
+						TableID: this.OriginID,
+						Name:    this.Name,
+					}
+				}),
+			),
 			to(scpb.Status_PUBLIC,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.ForeignKey) scop.Op {
+					return &scop.AddForeignKeyRef{
+						TableID:    this.OriginID,
+						Outbound:   true,
+						ForeignKey: foreignKeyConstraint(this, descpb.ConstraintValidity_Validated),
+					}
+				}),
 				emit(func(this *scpb.ForeignKey) scop.Op {
-					return notImplemented(this)
+					return &scop.AddForeignKeyRef{
+						TableID:    this.ReferenceID,
+						Outbound:   false,
+						ForeignKey: foreignKeyConstraint(this, descpb.ConstraintValidity_Validated),
+					}
 				}),
 			),
 		),
@@ -36,7 +84,33 @@ func init() {
 						Outbound: true,
 					}
 				}),
+				// The back-reference is dropped along with the reference, as
+				// there may not be a ForeignKeyBackReference element to do so.
+				emit(func(this *scpb.ForeignKey) scop.Op {
+					return &scop.DropForeignKeyRef{
+						TableID:  this.ReferenceID,
+						Name:     this.Name,
+						Outbound: false,
+					}
+				}),
 			),
 		),
 	)
 }
+
+// foreignKeyConstraint returns the reference described by a ForeignKey
+// element, as stored on both the origin and the referenced tables.
+func foreignKeyConstraint(
+	this *scpb.ForeignKey, validity descpb.ConstraintValidity,
+) descpb.ForeignKeyConstraint {
+	return descpb.ForeignKeyConstraint{
+		OriginTableID:       this.OriginID,
+		OriginColumnIDs:     this.OriginColumns,
+		ReferencedTableID:   this.ReferenceID,
+		ReferencedColumnIDs: this.ReferenceColumns,
+		Name:                this.Name,
+		Validity:            validity,
+		OnDelete:            this.OnDelete,
+		OnUpdate:            this.OnUpdate,
+	}
+}
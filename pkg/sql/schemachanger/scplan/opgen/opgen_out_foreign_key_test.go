@@ -0,0 +1,87 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForeignKeyOpGen(t *testing.T) {
+	// ALTER TABLE child ADD CONSTRAINT fk FOREIGN KEY (parent_id)
+	//   REFERENCES parent (id) ON DELETE CASCADE
+	const childID, parentID = descpb.ID(53), descpb.ID(52)
+	fk := &scpb.ForeignKey{
+		OriginID:         childID,
+		OriginColumns:    []descpb.ColumnID{2},
+		ReferenceID:      parentID,
+		ReferenceColumns: []descpb.ColumnID{1},
+		OnDelete:         descpb.ForeignKeyReference_CASCADE,
+		Name:             "fk",
+	}
+
+	t.Run("add", func(t *testing.T) {
+		ref := func(validity descpb.ConstraintValidity) descpb.ForeignKeyConstraint {
+			return descpb.ForeignKeyConstraint{
+				OriginTableID:       childID,
+				OriginColumnIDs:     []descpb.ColumnID{2},
+				ReferencedTableID:   parentID,
+				ReferencedColumnIDs: []descpb.ColumnID{1},
+				Name:                "fk",
+				Validity:            validity,
+				OnDelete:            descpb.ForeignKeyReference_CASCADE,
+			}
+		}
+		addRefs := func(validity descpb.ConstraintValidity) []scop.Op {
+			return []scop.Op{
+				&scop.AddForeignKeyRef{TableID: childID, Outbound: true, ForeignKey: ref(validity)},
+				&scop.AddForeignKeyRef{TableID: parentID, Outbound: false, ForeignKey: ref(validity)},
+			}
+		}
+		edges := opEdges(t, scpb.Target_ADD, fk)
+		require.Len(t, edges, 3)
+
+		// The references are added by the statement, and everything else
+		// happens after the user transaction has committed.
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		for _, edge := range edges[1:] {
+			require.False(t, edge.IsPhaseSatisfied(scop.PreCommitPhase))
+			require.True(t, edge.IsPhaseSatisfied(scop.PostCommitPhase))
+		}
+
+		require.Equal(t, scpb.Status_DELETE_AND_WRITE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.Equal(t, addRefs(descpb.ConstraintValidity_Validating), edges[0].Op())
+
+		require.Equal(t, scpb.Status_VALIDATED, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.Equal(t, scop.ValidationType, edges[1].Type())
+		require.Equal(t, []scop.Op{
+			&scop.ValidateForeignKey{TableID: childID, Name: "fk"},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[2].To().Status)
+		require.Equal(t, addRefs(descpb.ConstraintValidity_Validated), edges[2].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, fk)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.Equal(t, []scop.Op{
+			&scop.DropForeignKeyRef{TableID: childID, Name: "fk", Outbound: true},
+			&scop.DropForeignKeyRef{TableID: parentID, Name: "fk", Outbound: false},
+		}, edges[0].Op())
+	})
+}
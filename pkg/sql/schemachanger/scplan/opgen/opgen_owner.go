@@ -19,13 +19,21 @@ func init() {
 	opRegistry.register((*scpb.Owner)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Owner) scop.Op {
-					return notImplemented(this)
+					return &scop.UpdateOwner{
+						DescID: this.DescriptorID,
+						Owner:  this.Owner,
+					}
 				}),
 			),
 		),
 		drop(
+			// A descriptor always has an owner: the previous owner is only ever
+			// dropped along with the descriptor, or replaced by a new owner, and
+			// its op edge is a no-op in both cases.
 			to(scpb.Status_ABSENT,
+				revertible(true),
 				emit(func(this *scpb.Owner) scop.Op {
 					return notImplemented(this)
 				}),
@@ -20,6 +20,7 @@ func init() {
 		add(
 			to(scpb.Status_PUBLIC,
 				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.Partitioning) scop.Op {
 					return &scop.AddIndexPartitionInfo{
 						TableID:         this.TableID,
@@ -31,10 +32,19 @@ func init() {
 				}),
 			),
 		),
+		// Partitioning without implicit columns does not change how the rows of
+		// the index are encoded, so neither a backfill nor a validation is
+		// needed and the removal remains revertible. Implicitly partitioned
+		// indexes are instead repartitioned by building a new index.
 		drop(
 			to(scpb.Status_ABSENT,
+				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.Partitioning) scop.Op {
-					return notImplemented(this)
+					return &scop.RemoveIndexPartitionInfo{
+						TableID: this.TableID,
+						IndexID: this.IndexID,
+					}
 				}),
 			),
 		),
@@ -0,0 +1,62 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitioningOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	partitioning := &scpb.Partitioning{
+		TableID: tableID,
+		IndexID: 1,
+		Fields:  []string{"i"},
+		ListPartitions: []*scpb.ListPartition{
+			{Name: "p1", Expr: []string{"1"}},
+		},
+	}
+
+	// ALTER TABLE t PARTITION BY LIST (i) (PARTITION p1 VALUES IN (1))
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, partitioning)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddIndexPartitionInfo{
+				TableID:         tableID,
+				IndexID:         1,
+				PartitionFields: partitioning.Fields,
+				ListPartitions:  partitioning.ListPartitions,
+			},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t PARTITION BY NOTHING
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, partitioning)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveIndexPartitionInfo{TableID: tableID, IndexID: 1},
+		}, edges[0].Op())
+	})
+}
@@ -32,30 +32,34 @@ func convertPrimaryIndexColumnDir(
 	return convertedColumnDirs
 }
 
+func makeAddedPrimaryIndexDeleteOnly(this *scpb.PrimaryIndex) scop.Op {
+	return &scop.MakeAddedIndexDeleteOnly{
+		TableID:             this.TableID,
+		IndexID:             this.IndexID,
+		Unique:              this.Unique,
+		KeyColumnIDs:        this.KeyColumnIDs,
+		KeyColumnDirections: convertPrimaryIndexColumnDir(this),
+		KeySuffixColumnIDs:  this.KeySuffixColumnIDs,
+		StoreColumnIDs:      this.StoringColumnIDs,
+		CompositeColumnIDs:  this.CompositeColumnIDs,
+		ShardedDescriptor:   this.ShardedDescriptor,
+		Inverted:            this.Inverted,
+		Concurrently:        this.Concurrently,
+		SecondaryIndex:      false,
+	}
+}
+
 func init() {
 	opRegistry.register((*scpb.PrimaryIndex)(nil),
 		add(
 			to(scpb.Status_DELETE_ONLY,
 				minPhase(scop.PreCommitPhase),
-				emit(func(this *scpb.PrimaryIndex) scop.Op {
-					return &scop.MakeAddedIndexDeleteOnly{
-						TableID:             this.TableID,
-						IndexID:             this.IndexID,
-						Unique:              this.Unique,
-						KeyColumnIDs:        this.KeyColumnIDs,
-						KeyColumnDirections: convertPrimaryIndexColumnDir(this),
-						KeySuffixColumnIDs:  this.KeySuffixColumnIDs,
-						StoreColumnIDs:      this.StoringColumnIDs,
-						CompositeColumnIDs:  this.CompositeColumnIDs,
-						ShardedDescriptor:   this.ShardedDescriptor,
-						Inverted:            this.Inverted,
-						Concurrently:        this.Concurrently,
-						SecondaryIndex:      false,
-					}
-				}),
+				revertible(true),
+				emit(makeAddedPrimaryIndexDeleteOnly),
 			),
 			to(scpb.Status_DELETE_AND_WRITE_ONLY,
 				minPhase(scop.PostCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.PrimaryIndex) scop.Op {
 					return &scop.MakeAddedIndexDeleteAndWriteOnly{
 						TableID: this.TableID,
@@ -64,6 +68,7 @@ func init() {
 				}),
 			),
 			to(scpb.Status_BACKFILLED,
+				revertible(true),
 				emit(func(this *scpb.PrimaryIndex) scop.Op {
 					return &scop.BackfillIndex{
 						TableID:       this.TableID,
@@ -78,6 +83,7 @@ func init() {
 			//
 			// TODO(ajwerner): Rationalize this and hook up the optimization.
 			to(scpb.Status_VALIDATED,
+				revertible(true),
 				emit(func(this *scpb.PrimaryIndex) scop.Op {
 					return &scop.ValidateUniqueIndex{
 						TableID: this.TableID,
@@ -86,6 +92,7 @@ func init() {
 				}),
 			),
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.PrimaryIndex) scop.Op {
 					return &scop.MakeAddedPrimaryIndexPublic{
 						TableID: this.TableID,
@@ -96,6 +103,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_VALIDATED,
+				revertible(true),
 				emit(func(this *scpb.PrimaryIndex) scop.Op {
 					// Most of this logic is taken from MakeMutationComplete().
 					return &scop.MakeDroppedPrimaryIndexDeleteAndWriteOnly{
@@ -134,4 +142,31 @@ func init() {
 		),
 	)
 
+	// The primary index of a new table holds no data, so it doesn't need to be
+	// backfilled nor validated, and can become public straight away once it
+	// exists. It still transits through DELETE_ONLY, so that it's ordered
+	// against the columns it features.
+	opRegistry.registerNewTable((*scpb.PrimaryIndex)(nil),
+		add(
+			to(scpb.Status_DELETE_ONLY,
+				revertible(true),
+				emit(makeAddedPrimaryIndexDeleteOnly),
+			),
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.PrimaryIndex) []scop.Op {
+					return []scop.Op{
+						&scop.MakeAddedIndexDeleteAndWriteOnly{
+							TableID: this.TableID,
+							IndexID: this.IndexID,
+						},
+						&scop.MakeAddedPrimaryIndexPublic{
+							TableID: this.TableID,
+							IndexID: this.IndexID,
+						},
+					}
+				}),
+			),
+		),
+	)
 }
@@ -0,0 +1,86 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scgraph"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrimaryIndexOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	index := &scpb.PrimaryIndex{
+		TableID:             tableID,
+		IndexID:             1,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{1},
+		KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+	}
+
+	// ALTER TABLE t ALTER PRIMARY KEY USING COLUMNS (i)
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, index)
+		var statuses []scpb.Status
+		for _, e := range edges {
+			statuses = append(statuses, e.To().Status)
+		}
+		require.Equal(t, []scpb.Status{
+			scpb.Status_DELETE_ONLY,
+			scpb.Status_DELETE_AND_WRITE_ONLY,
+			scpb.Status_BACKFILLED,
+			scpb.Status_VALIDATED,
+			scpb.Status_PUBLIC,
+		}, statuses)
+	})
+
+	// CREATE TABLE t (i INT PRIMARY KEY)
+	t.Run("add to new table", func(t *testing.T) {
+		n := &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, index, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		}
+		g, err := BuildGraph(scpb.State{
+			Nodes: []*scpb.Node{
+				{
+					Target: scpb.NewTarget(scpb.Target_ADD, &scpb.Table{TableID: tableID}, nil /* metadata */),
+					Status: scpb.Status_ABSENT,
+				},
+				n,
+			},
+			Statements: []*scpb.Statement{{Statement: "CREATE TABLE t (i INT PRIMARY KEY)"}},
+		})
+		require.NoError(t, err)
+		var edges []*scgraph.OpEdge
+		for oe, ok := g.GetOpEdgeFrom(n); ok; oe, ok = g.GetOpEdgeFrom(oe.To()) {
+			edges = append(edges, oe)
+		}
+		require.Len(t, edges, 2)
+
+		require.Equal(t, scpb.Status_DELETE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Len(t, edges[0].Op(), 1)
+		require.IsType(t, (*scop.MakeAddedIndexDeleteOnly)(nil), edges[0].Op()[0])
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[1].To().Status)
+		require.True(t, edges[1].Revertible())
+		require.True(t, edges[1].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeAddedIndexDeleteAndWriteOnly{TableID: tableID, IndexID: 1},
+			&scop.MakeAddedPrimaryIndexPublic{TableID: tableID, IndexID: 1},
+		}, edges[1].Op())
+	})
+}
@@ -19,6 +19,7 @@ func init() {
 	opRegistry.register((*scpb.RelationDependedOnBy)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.RelationDependedOnBy) scop.Op {
 					return notImplemented(this)
 				}),
@@ -0,0 +1,46 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	opRegistry.register((*scpb.SchemaParent)(nil),
+		add(
+			// Moving an object to another schema only changes its descriptor
+			// and its namespace entry, which the dependency rules ensure are
+			// updated in the same stage.
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.SchemaParent) scop.Op {
+					return &scop.UpdateParentSchema{
+						DescID:   this.DescriptorID,
+						SchemaID: this.SchemaID,
+					}
+				}),
+			),
+		),
+		drop(
+			// An object always has a parent schema: the previous one is only
+			// ever dropped along with the object, or replaced by a new one, and
+			// its op edge is marked as a no-op in both cases.
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.SchemaParent) scop.Op {
+					return notImplemented(this)
+				}),
+			),
+		),
+	)
+}
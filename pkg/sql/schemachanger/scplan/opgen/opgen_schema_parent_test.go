@@ -0,0 +1,45 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaParentOpGen(t *testing.T) {
+	// ALTER TABLE sc1.t SET SCHEMA sc2
+	const tableID, sc1ID, sc2ID = descpb.ID(54), descpb.ID(52), descpb.ID(53)
+
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, &scpb.SchemaParent{DescriptorID: tableID, SchemaID: sc2ID})
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.UpdateParentSchema{DescID: tableID, SchemaID: sc2ID},
+		}, edges[0].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, &scpb.SchemaParent{DescriptorID: tableID, SchemaID: sc1ID})
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.Equal(t, []scop.Op{
+			&scop.NotImplemented{ElementType: "scpb.SchemaParent"},
+		}, edges[0].Op())
+	})
+}
@@ -37,6 +37,7 @@ func init() {
 		add(
 			to(scpb.Status_DELETE_ONLY,
 				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					return &scop.MakeAddedIndexDeleteOnly{
 						TableID:             this.TableID,
@@ -56,6 +57,7 @@ func init() {
 			),
 			to(scpb.Status_DELETE_AND_WRITE_ONLY,
 				minPhase(scop.PostCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					return &scop.MakeAddedIndexDeleteAndWriteOnly{
 						TableID: this.TableID,
@@ -63,7 +65,11 @@ func init() {
 					}
 				}),
 			),
+			// The backfill remains revertible: if it, or the validation
+			// which follows it, fails, the index is dropped again.
 			to(scpb.Status_BACKFILLED,
+				minPhase(scop.PostCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					return &scop.BackfillIndex{
 						TableID:       this.TableID,
@@ -78,6 +84,7 @@ func init() {
 			//
 			// TODO(ajwerner): Rationalize this and hook up the optimization.
 			to(scpb.Status_VALIDATED,
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					return &scop.ValidateUniqueIndex{
 						TableID: this.TableID,
@@ -86,6 +93,7 @@ func init() {
 				}),
 			),
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					return &scop.MakeAddedSecondaryIndexPublic{
 						TableID: this.TableID,
@@ -96,6 +104,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_DELETE_AND_WRITE_ONLY,
+				revertible(true),
 				emit(func(this *scpb.SecondaryIndex) scop.Op {
 					// Most of this logic is taken from MakeMutationComplete().
 					return &scop.MakeDroppedNonPrimaryIndexDeleteAndWriteOnly{
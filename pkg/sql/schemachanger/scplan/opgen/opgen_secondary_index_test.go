@@ -0,0 +1,142 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecondaryIndexOpGen(t *testing.T) {
+	// CREATE UNIQUE INDEX idx ON t (j) STORING (k)
+	const tableID = descpb.ID(52)
+	idx := &scpb.SecondaryIndex{
+		TableID:             tableID,
+		IndexID:             2,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{2},
+		KeyColumnDirections: []scpb.SecondaryIndex_Direction{scpb.SecondaryIndex_ASC},
+		KeySuffixColumnIDs:  []descpb.ColumnID{1},
+		StoringColumnIDs:    []descpb.ColumnID{3},
+		SourceIndexID:       1,
+	}
+	type expectedEdge struct {
+		status     scpb.Status
+		typ        scop.Type
+		revertible bool
+		minPhase   scop.Phase
+		ops        []scop.Op
+	}
+	requireEdges := func(t *testing.T, dir scpb.Target_Direction, expected []expectedEdge) {
+		edges := opEdges(t, dir, idx)
+		require.Len(t, edges, len(expected))
+		for i, exp := range expected {
+			edge := edges[i]
+			require.Equal(t, exp.status, edge.To().Status, "edge %d", i)
+			require.Equal(t, exp.typ, edge.Type(), "edge %d", i)
+			require.Equal(t, exp.revertible, edge.Revertible(), "edge %d", i)
+			require.True(t, edge.IsPhaseSatisfied(exp.minPhase), "edge %d", i)
+			if exp.minPhase > scop.EarliestPhase {
+				require.False(t, edge.IsPhaseSatisfied(exp.minPhase-1), "edge %d", i)
+			}
+			require.Equal(t, exp.ops, edge.Op(), "edge %d", i)
+		}
+	}
+
+	t.Run("add", func(t *testing.T) {
+		requireEdges(t, scpb.Target_ADD, []expectedEdge{
+			{
+				status:     scpb.Status_DELETE_ONLY,
+				typ:        scop.MutationType,
+				revertible: true,
+				minPhase:   scop.PreCommitPhase,
+				ops: []scop.Op{&scop.MakeAddedIndexDeleteOnly{
+					TableID:             tableID,
+					IndexID:             2,
+					Unique:              true,
+					KeyColumnIDs:        []descpb.ColumnID{2},
+					KeyColumnDirections: []descpb.IndexDescriptor_Direction{descpb.IndexDescriptor_ASC},
+					KeySuffixColumnIDs:  []descpb.ColumnID{1},
+					StoreColumnIDs:      []descpb.ColumnID{3},
+					SecondaryIndex:      true,
+				}},
+			},
+			{
+				status:     scpb.Status_DELETE_AND_WRITE_ONLY,
+				typ:        scop.MutationType,
+				revertible: true,
+				minPhase:   scop.PostCommitPhase,
+				ops: []scop.Op{
+					&scop.MakeAddedIndexDeleteAndWriteOnly{TableID: tableID, IndexID: 2},
+				},
+			},
+			{
+				status:     scpb.Status_BACKFILLED,
+				typ:        scop.BackfillType,
+				revertible: true,
+				minPhase:   scop.PostCommitPhase,
+				ops: []scop.Op{
+					&scop.BackfillIndex{TableID: tableID, SourceIndexID: 1, IndexID: 2},
+				},
+			},
+			{
+				status:     scpb.Status_VALIDATED,
+				typ:        scop.ValidationType,
+				revertible: true,
+				ops: []scop.Op{
+					&scop.ValidateUniqueIndex{TableID: tableID, IndexID: 2},
+				},
+			},
+			{
+				status:     scpb.Status_PUBLIC,
+				typ:        scop.MutationType,
+				revertible: true,
+				ops: []scop.Op{
+					&scop.MakeAddedSecondaryIndexPublic{TableID: tableID, IndexID: 2},
+				},
+			},
+		})
+	})
+	t.Run("drop", func(t *testing.T) {
+		requireEdges(t, scpb.Target_DROP, []expectedEdge{
+			{
+				status:     scpb.Status_DELETE_AND_WRITE_ONLY,
+				typ:        scop.MutationType,
+				revertible: true,
+				ops: []scop.Op{
+					&scop.MakeDroppedNonPrimaryIndexDeleteAndWriteOnly{TableID: tableID, IndexID: 2},
+				},
+			},
+			{
+				status:     scpb.Status_DELETE_ONLY,
+				typ:        scop.MutationType,
+				revertible: false,
+				minPhase:   scop.PostCommitPhase,
+				ops: []scop.Op{
+					&scop.MakeDroppedIndexDeleteOnly{TableID: tableID, IndexID: 2},
+				},
+			},
+			{
+				status:     scpb.Status_ABSENT,
+				typ:        scop.MutationType,
+				revertible: false,
+				ops: []scop.Op{
+					&scop.MakeIndexAbsent{TableID: tableID, IndexID: 2},
+					&scop.CreateGcJobForIndex{TableID: tableID, IndexID: 2},
+				},
+			},
+		})
+	})
+}
@@ -20,7 +20,10 @@ func init() {
 	// TODO(ajwerner): This needs more steps.
 	opRegistry.register((*scpb.Sequence)(nil),
 		add(
+			// Like for the other descriptors, the executor cannot yet create a
+			// new descriptor, which this would require.
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Sequence) scop.Op {
 					return notImplemented(this)
 				}),
@@ -30,6 +33,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_TXN_DROPPED,
+				revertible(true),
 				emit(func(this *scpb.Sequence) scop.Op {
 					return &scop.MarkDescriptorAsDroppedSynthetically{
 						DescID: this.SequenceID,
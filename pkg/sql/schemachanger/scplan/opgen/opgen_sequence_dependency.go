@@ -19,6 +19,7 @@ func init() {
 	opRegistry.register((*scpb.SequenceDependency)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.SequenceDependency) scop.Op {
 					return notImplemented(this)
 				}),
@@ -26,6 +27,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				revertible(true),
 				emit(func(this *scpb.SequenceDependency) scop.Op {
 					return notImplemented(this)
 				}),
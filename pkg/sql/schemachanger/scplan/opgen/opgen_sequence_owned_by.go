@@ -18,9 +18,17 @@ import (
 func init() {
 	opRegistry.register((*scpb.SequenceOwnedBy)(nil),
 		add(
+			// The ownership is set no earlier than pre-commit, when any previous
+			// ownership of the sequence has been removed.
 			to(scpb.Status_PUBLIC,
+				minPhase(scop.PreCommitPhase),
+				revertible(true),
 				emit(func(this *scpb.SequenceOwnedBy) scop.Op {
-					return notImplemented(this)
+					return &scop.UpdateSequenceOwnedBy{
+						SequenceID:    this.SequenceID,
+						OwnerTableID:  this.OwnerTableID,
+						OwnerColumnID: this.OwnerColumnID,
+					}
 				}),
 			),
 		),
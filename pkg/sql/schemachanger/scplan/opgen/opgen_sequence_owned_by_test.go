@@ -0,0 +1,58 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceOwnedByOpGen(t *testing.T) {
+	const seqID, tableID = descpb.ID(52), descpb.ID(53)
+	ownedBy := &scpb.SequenceOwnedBy{
+		SequenceID:    seqID,
+		OwnerTableID:  tableID,
+		OwnerColumnID: 2,
+	}
+
+	// ALTER SEQUENCE sq OWNED BY t.j
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, ownedBy)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.UpdateSequenceOwnedBy{
+				SequenceID:    seqID,
+				OwnerTableID:  tableID,
+				OwnerColumnID: 2,
+			},
+		}, edges[0].Op())
+	})
+	// ALTER TABLE t DROP COLUMN j, where j is a SERIAL column owning sq.
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, ownedBy)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.False(t, edges[0].Revertible())
+		require.False(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[0].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveSequenceOwnedBy{SequenceID: seqID},
+		}, edges[0].Op())
+	})
+}
@@ -20,6 +20,7 @@ func init() {
 	opRegistry.register((*scpb.Table)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Table) scop.Op {
 					return notImplemented(this)
 				}),
@@ -29,6 +30,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_TXN_DROPPED,
+				revertible(true),
 				emit(func(this *scpb.Table) scop.Op {
 					return &scop.MarkDescriptorAsDroppedSynthetically{
 						DescID: this.TableID,
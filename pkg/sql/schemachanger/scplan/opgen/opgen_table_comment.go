@@ -0,0 +1,48 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+)
+
+func init() {
+	opRegistry.register((*scpb.TableComment)(nil),
+		add(
+			// Comments live in system.comments rather than in the descriptor, so
+			// they can be set in the statement phase and removed again if the
+			// schema change is reverted.
+			to(scpb.Status_PUBLIC,
+				revertible(true),
+				emit(func(this *scpb.TableComment) scop.Op {
+					return &scop.UpsertComment{
+						CommentType: keys.TableCommentType,
+						ObjectID:    this.TableID,
+						Comment:     this.Comment,
+					}
+				}),
+			),
+		),
+		drop(
+			to(scpb.Status_ABSENT,
+				revertible(true),
+				emit(func(this *scpb.TableComment) scop.Op {
+					return &scop.RemoveComment{
+						CommentType: keys.TableCommentType,
+						ObjectID:    this.TableID,
+					}
+				}),
+			),
+		),
+	)
+}
@@ -18,7 +18,11 @@ import (
 func init() {
 	opRegistry.register((*scpb.Type)(nil),
 		add(
+			// Like for the other descriptors, the executor cannot yet create a
+			// new descriptor, which this would require. Members are added to
+			// existing enums through EnumMember elements.
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.Type) scop.Op {
 					return notImplemented(this)
 				}),
@@ -28,6 +32,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_TXN_DROPPED,
+				revertible(true),
 				emit(func(this *scpb.Type) scop.Op {
 					return &scop.MarkDescriptorAsDroppedSynthetically{
 						DescID: this.TypeID,
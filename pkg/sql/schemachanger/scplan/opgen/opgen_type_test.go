@@ -0,0 +1,63 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeOpGen(t *testing.T) {
+	const typeID = descpb.ID(52)
+	typ := &scpb.Type{TypeID: typeID}
+
+	// CREATE TYPE typ AS ENUM ('a')
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, typ)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.Equal(t, []scop.Op{
+			&scop.NotImplemented{ElementType: "scpb.Type"},
+		}, edges[0].Op())
+	})
+	// DROP TYPE typ
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, typ)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_TXN_DROPPED, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MarkDescriptorAsDroppedSynthetically{DescID: typeID},
+		}, edges[0].Op())
+
+		require.Equal(t, scpb.Status_DROPPED, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MarkDescriptorAsDropped{DescID: typeID},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_ABSENT, edges[2].To().Status)
+		require.False(t, edges[2].Revertible())
+		require.False(t, edges[2].IsPhaseSatisfied(scop.PreCommitPhase))
+		ops := edges[2].Op()
+		require.Len(t, ops, 2)
+		require.IsType(t, (*scop.LogEvent)(nil), ops[0])
+		require.Equal(t, &scop.DeleteDescriptor{DescriptorID: typeID}, ops[1])
+	})
+}
@@ -15,19 +15,57 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
 )
 
+// The UniqueConstraint element only describes unique constraints without an
+// index: the uniqueness of unique indexes is described by the index elements.
 func init() {
 	opRegistry.register((*scpb.UniqueConstraint)(nil),
 		add(
+			// The constraint is added unvalidated, at which point it is
+			// enforced for writes but not yet trusted.
+			to(scpb.Status_DELETE_AND_WRITE_ONLY,
+				revertible(true),
+				emit(func(this *scpb.UniqueConstraint) scop.Op {
+					return &scop.AddUniqueWithoutIndexConstraint{
+						TableID:   this.TableID,
+						Name:      this.Name,
+						ColumnIDs: this.ColumnIDs,
+						Predicate: this.Predicate,
+					}
+				}),
+			),
+			// The existing rows are checked for duplicates once the schema
+			// change has committed. The schema change cannot be reverted past
+			// this point.
+			to(scpb.Status_VALIDATED,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
+				emit(func(this *scpb.UniqueConstraint) scop.Op {
+					return &scop.ValidateUniqueConstraint{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
+				}),
+			),
 			to(scpb.Status_PUBLIC,
+				minPhase(scop.PostCommitPhase),
+				revertible(false),
 				emit(func(this *scpb.UniqueConstraint) scop.Op {
-					return notImplemented(this)
+					return &scop.MakeAddedUniqueWithoutIndexConstraintPublic{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				minPhase(scop.PreCommitPhase),
+				revertible(false),
 				emit(func(this *scpb.UniqueConstraint) scop.Op {
-					return notImplemented(this)
+					return &scop.RemoveUniqueWithoutIndexConstraint{
+						TableID: this.TableID,
+						Name:    this.Name,
+					}
 				}),
 			),
 		),
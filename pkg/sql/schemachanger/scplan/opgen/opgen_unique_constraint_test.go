@@ -0,0 +1,72 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUniqueConstraintOpGen(t *testing.T) {
+	// ALTER TABLE t ADD CONSTRAINT uq UNIQUE WITHOUT INDEX (j) WHERE k > 0
+	const tableID = descpb.ID(52)
+	uc := &scpb.UniqueConstraint{
+		ConstraintType: scpb.ConstraintType_UniqueWithoutIndex,
+		TableID:        tableID,
+		ColumnIDs:      []descpb.ColumnID{2},
+		Name:           "uq",
+		Predicate:      "k > 0:::INT8",
+	}
+
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, uc)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_DELETE_AND_WRITE_ONLY, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.AddUniqueWithoutIndexConstraint{
+				TableID:   tableID,
+				Name:      "uq",
+				ColumnIDs: []descpb.ColumnID{2},
+				Predicate: "k > 0:::INT8",
+			},
+		}, edges[0].Op())
+
+		require.Equal(t, scpb.Status_VALIDATED, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.True(t, edges[1].IsPhaseSatisfied(scop.PostCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.ValidateUniqueConstraint{TableID: tableID, Name: "uq"},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_PUBLIC, edges[2].To().Status)
+		require.False(t, edges[2].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MakeAddedUniqueWithoutIndexConstraintPublic{TableID: tableID, Name: "uq"},
+		}, edges[2].Op())
+	})
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, uc)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.False(t, edges[0].Revertible())
+		require.Equal(t, []scop.Op{
+			&scop.RemoveUniqueWithoutIndexConstraint{TableID: tableID, Name: "uq"},
+		}, edges[0].Op())
+	})
+}
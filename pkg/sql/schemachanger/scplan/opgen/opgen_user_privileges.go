@@ -19,15 +19,24 @@ func init() {
 	opRegistry.register((*scpb.UserPrivileges)(nil),
 		add(
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.UserPrivileges) scop.Op {
-					return notImplemented(this)
+					return &scop.UpsertUserPrivileges{
+						DescID:     this.DescriptorID,
+						Username:   this.Username,
+						Privileges: this.Privileges,
+					}
 				}),
 			),
 		),
 		drop(
 			to(scpb.Status_ABSENT,
+				revertible(true),
 				emit(func(this *scpb.UserPrivileges) scop.Op {
-					return notImplemented(this)
+					return &scop.RemoveUserPrivileges{
+						DescID:   this.DescriptorID,
+						Username: this.Username,
+					}
 				}),
 			),
 		),
@@ -0,0 +1,53 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserPrivilegesOpGen(t *testing.T) {
+	const tableID = descpb.ID(52)
+	privs := &scpb.UserPrivileges{
+		DescriptorID: tableID,
+		Username:     "foo",
+		Privileges:   privilege.SELECT.Mask(),
+	}
+
+	// GRANT SELECT ON TABLE t TO foo
+	t.Run("grant", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, privs)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.UpsertUserPrivileges{DescID: tableID, Username: "foo", Privileges: privilege.SELECT.Mask()},
+		}, edges[0].Op())
+	})
+	// REVOKE ALL ON TABLE t FROM foo
+	t.Run("revoke", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, privs)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_ABSENT, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.RemoveUserPrivileges{DescID: tableID, Username: "foo"},
+		}, edges[0].Op())
+	})
+}
@@ -19,7 +19,10 @@ func init() {
 	// TODO(ajwerner): This needs more steps.
 	opRegistry.register((*scpb.View)(nil),
 		add(
+			// Like for the other descriptors, the executor cannot yet create a
+			// new descriptor, which this would require.
 			to(scpb.Status_PUBLIC,
+				revertible(true),
 				emit(func(this *scpb.View) scop.Op {
 					return notImplemented(this)
 				}),
@@ -29,6 +32,7 @@ func init() {
 		),
 		drop(
 			to(scpb.Status_TXN_DROPPED,
+				revertible(true),
 				emit(func(this *scpb.View) scop.Op {
 					return &scop.MarkDescriptorAsDroppedSynthetically{
 						DescID: this.TableID,
@@ -0,0 +1,63 @@
+// Copyright 2021 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package opgen
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestViewOpGen(t *testing.T) {
+	const viewID = descpb.ID(52)
+	view := &scpb.View{TableID: viewID}
+
+	// CREATE VIEW v AS SELECT 1
+	t.Run("add", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_ADD, view)
+		require.Len(t, edges, 1)
+		require.Equal(t, scpb.Status_PUBLIC, edges[0].To().Status)
+		require.Equal(t, []scop.Op{
+			&scop.NotImplemented{ElementType: "scpb.View"},
+		}, edges[0].Op())
+	})
+	// DROP VIEW v
+	t.Run("drop", func(t *testing.T) {
+		edges := opEdges(t, scpb.Target_DROP, view)
+		require.Len(t, edges, 3)
+
+		require.Equal(t, scpb.Status_TXN_DROPPED, edges[0].To().Status)
+		require.True(t, edges[0].Revertible())
+		require.True(t, edges[0].IsPhaseSatisfied(scop.StatementPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MarkDescriptorAsDroppedSynthetically{DescID: viewID},
+		}, edges[0].Op())
+
+		require.Equal(t, scpb.Status_DROPPED, edges[1].To().Status)
+		require.False(t, edges[1].Revertible())
+		require.False(t, edges[1].IsPhaseSatisfied(scop.StatementPhase))
+		require.True(t, edges[1].IsPhaseSatisfied(scop.PreCommitPhase))
+		require.Equal(t, []scop.Op{
+			&scop.MarkDescriptorAsDropped{DescID: viewID},
+		}, edges[1].Op())
+
+		require.Equal(t, scpb.Status_ABSENT, edges[2].To().Status)
+		require.False(t, edges[2].Revertible())
+		require.False(t, edges[2].IsPhaseSatisfied(scop.PreCommitPhase))
+		ops := edges[2].Op()
+		require.Len(t, ops, 2)
+		require.IsType(t, (*scop.LogEvent)(nil), ops[0])
+		require.Equal(t, &scop.CreateGcJobForTable{TableID: viewID}, ops[1])
+	})
+}
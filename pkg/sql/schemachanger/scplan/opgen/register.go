@@ -69,8 +69,8 @@ func buildTransitionSpecs(initial, final scpb.Status, specs []transitionSpec) []
 			}
 			// Check that the minimum phase is monotonically increasing.
 			if s.minPhase > 0 && s.minPhase < currentMinPhase {
-				panic(errors.Errorf("invalid transition to %s: minimum phase %s is less than inherited mininum phase %s",
-					s.to, s.minPhase.String(), currentMinPhase.String()))
+				panic(errors.Errorf("invalid transition %s -> %s: minimum phase %s is less than inherited minimum phase %s",
+					from, s.to, s.minPhase.String(), currentMinPhase.String()))
 			}
 
 			isRevertible = isRevertible && s.revertible
@@ -178,3 +178,44 @@ func (r *registry) register(e scpb.Element, add addSpec, drop dropSpec) {
 		makeTarget(e, scpb.Target_DROP, drop.transitionSpecs...),
 	)
 }
+
+// registerNewTable constructs the add operation edges for a given element of
+// a table which is itself being added, in place of those registered using
+// register. Such an element has no existing data to backfill or validate, so
+// its add spec may skip statuses of the regular one. Intended to be called
+// during init, after register, and panics on any error.
+func (r *registry) registerNewTable(e scpb.Element, add addSpec) {
+	r.registerVariant(e, "new table", newTableClauses, add)
+}
+
+// registerVariant constructs the add operation edges for those targets adding
+// a given element which also satisfy the given clauses, in place of those
+// registered using register. The variant add spec may skip statuses of the
+// regular one, but may not feature others. Intended to be called during init,
+// after register, and panics on any error.
+func (r *registry) registerVariant(
+	e scpb.Element, variant string, clauses variantClauses, add addSpec,
+) {
+	regularStatuses := map[scpb.Status]bool{}
+	var found bool
+	for _, t := range r.targets {
+		if t.dir != scpb.Target_ADD || reflect.TypeOf(t.e) != reflect.TypeOf(e) {
+			continue
+		}
+		found = true
+		for _, tr := range t.transitions {
+			regularStatuses[tr.from] = true
+			regularStatuses[tr.to] = true
+		}
+	}
+	if !found {
+		panic(errors.Errorf("%T has no registered add spec", e))
+	}
+	for _, ts := range add.transitionSpecs {
+		if !regularStatuses[ts.to] {
+			panic(errors.Errorf("status %s is featured in %s add spec but not in add spec", ts.to, variant))
+		}
+	}
+	r.variantTargets = append(r.variantTargets,
+		makeVariantTarget(e, variant, clauses, add.transitionSpecs...))
+}
@@ -11,11 +11,14 @@
 package opgen
 
 import (
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOpGen(t *testing.T) {
@@ -51,3 +54,73 @@ func TestOpGen(t *testing.T) {
 		})
 	}
 }
+
+// TestOpGenRevertibility checks that every transition of every registered
+// element explicitly specifies whether it is revertible, rather than relying
+// on the default.
+func TestOpGenRevertibility(t *testing.T) {
+	for _, tg := range allTargets() {
+		name := fmt.Sprintf("%T/%s", tg.e, tg.dir)
+		t.Run(name, func(t *testing.T) {
+			for _, tr := range tg.transitions {
+				if !tr.revertibilitySet {
+					t.Errorf("transition %s -> %s does not specify its revertibility", tr.from, tr.to)
+				}
+			}
+		})
+	}
+}
+
+// TestOpGenTerminalStatus checks that the transitions of every registered
+// element reach PUBLIC when adding it and ABSENT when dropping it, so that no
+// target is left stranded in an intermediate status.
+func TestOpGenTerminalStatus(t *testing.T) {
+	for _, tg := range allTargets() {
+		name := fmt.Sprintf("%T/%s", tg.e, tg.dir)
+		t.Run(name, func(t *testing.T) {
+			expected := scpb.Status_PUBLIC
+			if tg.dir == scpb.Target_DROP {
+				expected = scpb.Status_ABSENT
+			}
+			var reached bool
+			for _, tr := range tg.transitions {
+				if tr.to == expected {
+					reached = true
+				}
+			}
+			if !reached {
+				t.Errorf("%T has no %s transition to %s", tg.e, tg.dir, expected)
+			}
+		})
+	}
+}
+
+// TestOpGenMinPhaseDecreasing checks that a spec whose minimum phases decrease
+// is rejected.
+func TestOpGenMinPhaseDecreasing(t *testing.T) {
+	require.PanicsWithError(t,
+		"invalid transition DELETE_ONLY -> PUBLIC: "+
+			"minimum phase PreCommitPhase is less than inherited minimum phase PostCommitPhase",
+		func() {
+			add(
+				to(scpb.Status_DELETE_ONLY,
+					minPhase(scop.PostCommitPhase),
+					revertible(true),
+					emit(func(this *scpb.Table) scop.Op { return notImplemented(this) }),
+				),
+				to(scpb.Status_PUBLIC,
+					minPhase(scop.PreCommitPhase),
+					revertible(true),
+					emit(func(this *scpb.Table) scop.Op { return notImplemented(this) }),
+				),
+			)
+		},
+	)
+}
+
+// allTargets returns the registered targets, including the variant ones.
+func allTargets() []target {
+	ret := make([]target, 0, len(opRegistry.targets)+len(opRegistry.variantTargets))
+	ret = append(ret, opRegistry.targets...)
+	return append(ret, opRegistry.variantTargets...)
+}
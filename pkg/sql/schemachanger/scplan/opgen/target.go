@@ -28,10 +28,11 @@ type target struct {
 
 // transition represents a transition of a target to a new status.
 type transition struct {
-	from, to   scpb.Status
-	revertible bool
-	ops        opsFunc
-	minPhase   scop.Phase
+	from, to         scpb.Status
+	revertible       bool
+	revertibilitySet bool
+	ops              opsFunc
+	minPhase         scop.Phase
 }
 
 func makeTarget(e scpb.Element, dir scpb.Target_Direction, specs ...transitionSpec) target {
@@ -46,6 +47,26 @@ func makeTarget(e scpb.Element, dir scpb.Target_Direction, specs ...transitionSp
 	}
 }
 
+// variantClauses constrain the elements matched by a variant target, given
+// the variables bound to the element, its target and its node.
+type variantClauses func(element, target, node rel.Var) rel.Clause
+
+// makeVariantTarget is like makeTarget for adding an element, but the target
+// only applies to those elements which also satisfy the given clauses.
+func makeVariantTarget(
+	e scpb.Element, variant string, clauses variantClauses, specs ...transitionSpec,
+) target {
+	defer decoratePanickedError(func(err error) error {
+		return errors.Wrapf(err, "making %s target %T", variant, e)
+	})()
+	return target{
+		e:           e,
+		dir:         scpb.Target_ADD,
+		transitions: makeTransitions(e, specs),
+		iterateFunc: makeVariantQuery(e, clauses),
+	}
+}
+
 func makeTransitions(e scpb.Element, specs []transitionSpec) []transition {
 	transitions := make([]transition, 0, len(specs))
 	for _, s := range specs {
@@ -54,11 +75,12 @@ func makeTransitions(e scpb.Element, specs []transitionSpec) []transition {
 			panic(errors.Wrapf(err, "building transition from %v->%v", s.from, s.to))
 		}
 		transitions = append(transitions, transition{
-			from:       s.from,
-			to:         s.to,
-			revertible: s.revertible,
-			ops:        fn,
-			minPhase:   s.minPhase,
+			from:             s.from,
+			to:               s.to,
+			revertible:       s.revertible,
+			revertibilitySet: s.revertibilitySet,
+			ops:              fn,
+			minPhase:         s.minPhase,
 		})
 	}
 	return transitions
@@ -85,6 +107,39 @@ func makeQuery(
 	}
 }
 
+func makeVariantQuery(
+	e scpb.Element, clauses variantClauses,
+) func(*rel.Database, func(*scpb.Node) error) error {
+	var element, target, node rel.Var = "element", "target", "node"
+	q, err := rel.NewQuery(screl.Schema,
+		element.Type(e),
+		screl.JoinTargetNode(element, target, node),
+		target.AttrEq(screl.Direction, scpb.Target_ADD),
+		clauses(element, target, node),
+	)
+	if err != nil {
+		panic(errors.NewAssertionErrorWithWrappedErrf(err,
+			"failed to construct query"))
+	}
+	return func(database *rel.Database, f func(*scpb.Node) error) error {
+		return q.Iterate(database, func(r rel.Result) error {
+			return f(r.Var(node).(*scpb.Node))
+		})
+	}
+}
+
+// newTableClauses match elements of tables which are themselves being added.
+func newTableClauses(element, _, _ rel.Var) rel.Clause {
+	var table, tableTarget, tableNode rel.Var = "table", "table-target", "table-node"
+	var id rel.Var = "id"
+	return rel.And(
+		table.Type((*scpb.Table)(nil)),
+		id.Entities(screl.DescID, element, table),
+		screl.JoinTargetNode(table, tableTarget, tableNode),
+		tableTarget.AttrEq(screl.Direction, scpb.Target_ADD),
+	)
+}
+
 func decoratePanickedError(f func(error) error) func() {
 	return func() {
 		var err error
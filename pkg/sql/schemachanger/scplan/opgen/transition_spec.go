@@ -23,6 +23,10 @@ type transitionSpec struct {
 	revertible bool
 	minPhase   scop.Phase
 	emitFns    []interface{}
+
+	// revertibilitySet is true iff the revertibility of the transition was
+	// explicitly specified using revertible, rather than defaulted.
+	revertibilitySet bool
 }
 
 type transitionProperty interface {
@@ -48,6 +52,11 @@ func minPhase(p scop.Phase) transitionProperty {
 	return phaseProperty(p)
 }
 
+// emit adds a function which emits the ops of the transition, given the
+// element and optionally its metadata. It returns either a single scop.Op or a
+// []scop.Op, when the transition naturally consists of several ops. The ops
+// of all the functions of a transition are emitted in the order in which they
+// were added.
 func emit(fn interface{}) transitionProperty {
 	return emitFnSpec{fn}
 }
@@ -62,6 +71,7 @@ type revertibleProperty bool
 
 func (r revertibleProperty) apply(spec *transitionSpec) {
 	spec.revertible = bool(r)
+	spec.revertibilitySet = true
 }
 
 var _ transitionProperty = revertibleProperty(true)
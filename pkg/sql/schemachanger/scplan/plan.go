@@ -12,6 +12,9 @@ package scplan
 
 import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scgraph"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scpb"
@@ -22,6 +25,18 @@ import (
 	"github.com/cockroachdb/errors"
 )
 
+// MaxTargets is the maximum number of targets of a schema change planned in
+// the user transaction. The cost of planning grows with the number of targets,
+// so very large schema changes are rejected rather than planned.
+var MaxTargets = settings.RegisterIntSetting(
+	settings.TenantWritable,
+	"sql.schema.declarative_schema_changer.max_targets",
+	"the maximum number of elements targeted by a schema change "+
+		"using the declarative schema changer; 0 disables the limit",
+	10000,
+	settings.NonNegativeInt,
+)
+
 // Params holds the arguments for planning.
 type Params struct {
 	// ExecutionPhase indicates the phase that the plan should be constructed for.
@@ -30,6 +45,10 @@ type Params struct {
 	// SchemaChangerJobIDSupplier is used to return the JobID for a
 	// job if one should exist.
 	SchemaChangerJobIDSupplier func() jobspb.JobID
+
+	// MaxTargets, if positive, is the maximum number of targets for which a
+	// plan may be built.
+	MaxTargets int
 }
 
 // A Plan is a schema change plan, primarily containing ops to be executed that
@@ -62,6 +81,14 @@ func MakePlan(initial scpb.State, params Params) (p Plan, err error) {
 		Initial: initial,
 		Params:  params,
 	}
+	if n := len(initial.Nodes); params.MaxTargets > 0 && n > params.MaxTargets {
+		return p, errors.WithHintf(
+			pgerror.Newf(pgcode.ProgramLimitExceeded,
+				"schema change targets %d elements, more than the maximum of %d", n, params.MaxTargets),
+			"split the schema change into several transactions, or raise the %s cluster setting",
+			MaxTargets.Key(),
+		)
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			rAsErr, ok := r.(error)
@@ -15,13 +15,19 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/typedesc"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scbuild"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scdeps/sctestutils"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scerrors"
@@ -33,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scplan/scstage"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/screl"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -102,6 +109,16 @@ func TestPlanDataDriven(t *testing.T) {
 
 					plan = sctestutils.MakePlan(t, state, scop.EarliestPhase)
 					validatePlan(t, &plan)
+
+					// Planning the same statements again must yield the same ops, in
+					// the same order, for the output to be stable.
+					var again scpb.State
+					for i := range stmts {
+						again, err = scbuild.Build(ctx, deps, again, stmts[i].AST)
+						require.NoError(t, err)
+					}
+					replanned := sctestutils.MakePlan(t, again, scop.EarliestPhase)
+					require.Equal(t, marshalOps(t, plan.Stages), marshalOps(t, replanned.Stages))
 				})
 
 				if d.Cmd == "ops" {
@@ -130,6 +147,1359 @@ func TestPlanDataDriven(t *testing.T) {
 	})
 }
 
+// TestPlanPrimaryKeySwap checks that when a primary index is swapped for
+// another, the new index only replaces the old one once it has been backfilled
+// and validated, and that the old index is then removed and garbage collected.
+func TestPlanPrimaryKeySwap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the primary indexes of:
+	//
+	//  ALTER TABLE t ALTER PRIMARY KEY USING COLUMNS (j)
+	//
+	// where t has columns i and j, and i is its primary key.
+	const tableID = descpb.ID(52)
+	oldIndex := &scpb.PrimaryIndex{
+		TableID:             tableID,
+		IndexID:             1,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{1},
+		KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+		StoringColumnIDs:    []descpb.ColumnID{2},
+	}
+	newIndex := &scpb.PrimaryIndex{
+		TableID:             tableID,
+		IndexID:             2,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{2},
+		KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+		StoringColumnIDs:    []descpb.ColumnID{1},
+		SourceIndexID:       1,
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, oldIndex, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, newIndex, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t ALTER PRIMARY KEY USING COLUMNS (j)"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	backfill := findStage(&scop.BackfillIndex{TableID: tableID, SourceIndexID: 1, IndexID: 2})
+	validate := findStage(&scop.ValidateUniqueIndex{TableID: tableID, IndexID: 2})
+	swapIn := findStage(&scop.MakeAddedPrimaryIndexPublic{TableID: tableID, IndexID: 2})
+	swapOut := findStage(&scop.MakeDroppedPrimaryIndexDeleteAndWriteOnly{TableID: tableID, IndexID: 1})
+	deleteOnly := findStage(&scop.MakeDroppedIndexDeleteOnly{TableID: tableID, IndexID: 1})
+	absent := findStage(&scop.MakeIndexAbsent{TableID: tableID, IndexID: 1})
+	gc := findStage(&scop.CreateGcJobForIndex{TableID: tableID, IndexID: 1})
+
+	require.Less(t, backfill, validate)
+	require.Less(t, validate, swapIn)
+	require.Equal(t, swapIn, swapOut, "the primary indexes must be swapped atomically")
+	require.Less(t, swapOut, deleteOnly)
+	require.Less(t, deleteOnly, absent)
+	require.Equal(t, absent, gc)
+	require.Equal(t, scop.PostCommitPhase, plan.Stages[swapIn].Phase)
+}
+
+func TestPlanComputedColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column and computed expression of:
+	//
+	//  ALTER TABLE t ADD COLUMN j INT AS (i + 1) [STORED | VIRTUAL]
+	//
+	// where t has column i. The new primary index which a stored column
+	// requires is left out, as the backfill it performs is only ordered against
+	// the column itself.
+	const tableID = descpb.ID(52)
+	for _, virtual := range []bool{false, true} {
+		column := &scpb.Column{
+			TableID:      tableID,
+			ColumnID:     2,
+			FamilyName:   "primary",
+			Type:         types.Int,
+			Nullable:     true,
+			ComputerExpr: "i + 1:::INT8",
+			Virtual:      virtual,
+		}
+		computedExpr := &scpb.ComputedExpr{
+			TableID:  tableID,
+			ColumnID: 2,
+			Expr:     "i + 1:::INT8",
+			Virtual:  virtual,
+		}
+		state := scpb.State{
+			Nodes: []*scpb.Node{
+				{
+					Target: scpb.NewTarget(scpb.Target_ADD, column, nil /* metadata */),
+					Status: scpb.Status_ABSENT,
+				},
+				{
+					Target: scpb.NewTarget(scpb.Target_ADD, computedExpr, nil /* metadata */),
+					Status: scpb.Status_ABSENT,
+				},
+			},
+			Statements: []*scpb.Statement{
+				{Statement: "ALTER TABLE t ADD COLUMN j INT AS (i + 1)"},
+			},
+		}
+		plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+		// findStage returns the ordinal of the first stage which contains an op
+		// of the same type as op.
+		findStage := func(op scop.Op) int {
+			for i, s := range plan.Stages {
+				for _, o := range s.EdgeOps {
+					if reflect.TypeOf(o) == reflect.TypeOf(op) {
+						return i
+					}
+				}
+			}
+			t.Fatalf("no stage contains %T", op)
+			return -1
+		}
+		deleteOnly := findStage(&scop.MakeAddedColumnDeleteOnly{})
+		setExpr := findStage(&scop.AddColumnComputedExpression{})
+		writeOnly := findStage(&scop.MakeAddedColumnDeleteAndWriteOnly{})
+		public := findStage(&scop.MakeColumnPublic{})
+
+		require.LessOrEqual(t, deleteOnly, setExpr)
+		if virtual {
+			require.LessOrEqual(t, setExpr, public)
+		} else {
+			require.LessOrEqual(t, setExpr, writeOnly,
+				"the expression of a stored column must be set before its values are written")
+		}
+	}
+}
+
+// TestPlanComputedColumnReferencingNewColumn checks that the backfill of a
+// stored computed column which references another new column only takes
+// place once the latter is written to.
+func TestPlanComputedColumnReferencingNewColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the columns, computed expression and new primary index of:
+	//
+	//  ALTER TABLE t ADD COLUMN j INT DEFAULT 1, ADD COLUMN k INT AS (j + 1) STORED
+	//
+	// where t has column i.
+	const tableID = descpb.ID(52)
+	var nodes []*scpb.Node
+	for _, e := range []scpb.Element{
+		&scpb.Column{
+			TableID:     tableID,
+			ColumnID:    2,
+			FamilyName:  "primary",
+			Type:        types.Int,
+			Nullable:    true,
+			DefaultExpr: "1:::INT8",
+		},
+		&scpb.Column{
+			TableID:      tableID,
+			ColumnID:     3,
+			FamilyName:   "primary",
+			Type:         types.Int,
+			Nullable:     true,
+			ComputerExpr: "j + 1:::INT8",
+		},
+		&scpb.ComputedExpr{
+			TableID:             tableID,
+			ColumnID:            3,
+			Expr:                "j + 1:::INT8",
+			ReferencedColumnIDs: []descpb.ColumnID{2},
+		},
+		&scpb.PrimaryIndex{
+			TableID:             tableID,
+			IndexID:             2,
+			Unique:              true,
+			KeyColumnIDs:        []descpb.ColumnID{1},
+			KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+			StoringColumnIDs:    []descpb.ColumnID{2, 3},
+			SourceIndexID:       1,
+		},
+	} {
+		nodes = append(nodes, &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, e, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		})
+	}
+	plan := sctestutils.MakePlan(t, scpb.State{
+		Nodes: nodes,
+		Statements: []*scpb.Statement{{
+			Statement: "ALTER TABLE t ADD COLUMN j INT DEFAULT 1, ADD COLUMN k INT AS (j + 1) STORED",
+		}},
+	}, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	writeOnly, backfill := -1, -1
+	for i, s := range plan.Stages {
+		for _, op := range s.EdgeOps {
+			switch op := op.(type) {
+			case *scop.MakeAddedColumnDeleteAndWriteOnly:
+				if op.ColumnID == 2 {
+					writeOnly = i
+				}
+			case *scop.BackfillIndex:
+				backfill = i
+			}
+		}
+	}
+	require.NotEqual(t, -1, writeOnly)
+	require.NotEqual(t, -1, backfill)
+	require.Less(t, writeOnly, backfill,
+		"the referenced column must be written to before the computed column is backfilled")
+
+	// The ordering stems from a dependency edge from the referenced column.
+	var rules []string
+	require.NoError(t, plan.Graph.ForEachNode(func(n *scpb.Node) error {
+		return plan.Graph.ForEachDepEdgeFrom(n, func(de *scgraph.DepEdge) error {
+			if c, ok := de.From().Element().(*scpb.Column); ok && c.ColumnID == 2 {
+				if _, ok := de.To().Element().(*scpb.PrimaryIndex); ok {
+					rules = append(rules, de.Name())
+				}
+			}
+			return nil
+		})
+	}))
+	require.Contains(t, rules,
+		"primary index backfilled after columns referenced by computed expression write-only")
+}
+
+// TestPlanOnUpdateColumn checks that the ON UPDATE expression of a column is
+// only set once the column exists, and only removed once a dropped column is
+// no longer writable.
+func TestPlanOnUpdateColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column and ON UPDATE expression of:
+	//
+	//  ALTER TABLE t ADD COLUMN j TIMESTAMPTZ ON UPDATE now()
+	//  ALTER TABLE t DROP COLUMN j
+	//
+	// where t has column i.
+	const tableID = descpb.ID(52)
+	column := &scpb.Column{
+		TableID:      tableID,
+		ColumnID:     2,
+		FamilyName:   "primary",
+		Type:         types.TimestampTZ,
+		Nullable:     true,
+		OnUpdateExpr: "now():::TIMESTAMPTZ",
+	}
+	onUpdateExpr := &scpb.OnUpdateExpr{
+		TableID:  tableID,
+		ColumnID: 2,
+		Expr:     "now():::TIMESTAMPTZ",
+	}
+	makePlan := func(t *testing.T, dir scpb.Target_Direction, stmt string) scplan.Plan {
+		initial := scpb.Status_ABSENT
+		if dir == scpb.Target_DROP {
+			initial = scpb.Status_PUBLIC
+		}
+		var nodes []*scpb.Node
+		for _, e := range []scpb.Element{column, onUpdateExpr} {
+			nodes = append(nodes, &scpb.Node{
+				Target: scpb.NewTarget(dir, e, nil /* metadata */),
+				Status: initial,
+			})
+		}
+		return sctestutils.MakePlan(t, scpb.State{
+			Nodes:      nodes,
+			Statements: []*scpb.Statement{{Statement: stmt}},
+		}, scop.EarliestPhase)
+	}
+	// findStage returns the ordinal of the first stage of the plan which
+	// contains an op of the same type as op.
+	findStage := func(t *testing.T, plan scplan.Plan, op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.TypeOf(o) == reflect.TypeOf(op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T", op)
+		return -1
+	}
+
+	t.Run("add", func(t *testing.T) {
+		plan := makePlan(t, scpb.Target_ADD, "ALTER TABLE t ADD COLUMN j TIMESTAMPTZ ON UPDATE now()")
+		deleteOnly := findStage(t, plan, &scop.MakeAddedColumnDeleteOnly{})
+		setExpr := findStage(t, plan, &scop.AddColumnOnUpdateExpression{})
+		require.LessOrEqual(t, deleteOnly, setExpr)
+	})
+	t.Run("drop", func(t *testing.T) {
+		plan := makePlan(t, scpb.Target_DROP, "ALTER TABLE t DROP COLUMN j")
+		deleteOnly := findStage(t, plan, &scop.MakeDroppedColumnDeleteOnly{})
+		removeExpr := findStage(t, plan, &scop.RemoveColumnOnUpdateExpression{})
+		require.LessOrEqual(t, deleteOnly, removeExpr)
+	})
+}
+
+// TestPlanValidateCheckConstraint checks that validating a check constraint
+// which was added NOT VALID scans the table after the schema change has
+// committed, without removing and adding back the constraint.
+func TestPlanValidateCheckConstraint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the check constraint of:
+	//
+	//  ALTER TABLE t VALIDATE CONSTRAINT ck
+	//
+	// where ck was added by:
+	//
+	//  ALTER TABLE t ADD CONSTRAINT ck CHECK (i > 0) NOT VALID
+	const tableID = descpb.ID(52)
+	unvalidated := &scpb.CheckConstraint{
+		TableID:   tableID,
+		Name:      "ck",
+		Expr:      "i > 0:::INT8",
+		ColumnIDs: []descpb.ColumnID{1},
+	}
+	validated := *unvalidated
+	validated.Validated = true
+	plan := sctestutils.MakePlan(t, scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, unvalidated, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, &validated, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{{Statement: "ALTER TABLE t VALIDATE CONSTRAINT ck"}},
+	}, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	stageOf := make(map[reflect.Type]int)
+	for i, s := range plan.Stages {
+		for _, op := range s.EdgeOps {
+			require.NotContains(t, []reflect.Type{
+				reflect.TypeOf((*scop.AddCheckConstraint)(nil)),
+				reflect.TypeOf((*scop.RemoveCheckConstraint)(nil)),
+			}, reflect.TypeOf(op), "the constraint already exists")
+			if _, found := stageOf[reflect.TypeOf(op)]; !found {
+				stageOf[reflect.TypeOf(op)] = i
+			}
+		}
+	}
+	validate, ok := stageOf[reflect.TypeOf((*scop.ValidateCheckConstraint)(nil))]
+	require.True(t, ok)
+	require.Equal(t, scop.PostCommitPhase, plan.Stages[validate].Phase)
+	public, ok := stageOf[reflect.TypeOf((*scop.MakeAddedCheckConstraintPublic)(nil))]
+	require.True(t, ok)
+	require.Less(t, validate, public)
+}
+
+// TestPlanCreateTablePrimaryIndex checks that the primary index of a new table
+// is made public without being backfilled nor validated, once the columns it
+// features exist.
+func TestPlanCreateTablePrimaryIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the table, column and primary index of:
+	//
+	//  CREATE TABLE t (i INT PRIMARY KEY)
+	const tableID = descpb.ID(52)
+	var nodes []*scpb.Node
+	for _, e := range []scpb.Element{
+		&scpb.Table{TableID: tableID},
+		&scpb.Column{
+			TableID:    tableID,
+			ColumnID:   1,
+			FamilyName: "primary",
+			Type:       types.Int,
+		},
+		&scpb.PrimaryIndex{
+			TableID:             tableID,
+			IndexID:             1,
+			Unique:              true,
+			KeyColumnIDs:        []descpb.ColumnID{1},
+			KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+		},
+	} {
+		nodes = append(nodes, &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, e, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		})
+	}
+	plan := sctestutils.MakePlan(t, scpb.State{
+		Nodes:      nodes,
+		Statements: []*scpb.Statement{{Statement: "CREATE TABLE t (i INT PRIMARY KEY)"}},
+	}, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	stageOf := make(map[reflect.Type]int)
+	for i, s := range plan.Stages {
+		for _, op := range s.EdgeOps {
+			require.NotContains(t, []reflect.Type{
+				reflect.TypeOf((*scop.BackfillIndex)(nil)),
+				reflect.TypeOf((*scop.ValidateUniqueIndex)(nil)),
+			}, reflect.TypeOf(op), "the index of a new table holds no data")
+			if _, found := stageOf[reflect.TypeOf(op)]; !found {
+				stageOf[reflect.TypeOf(op)] = i
+			}
+		}
+	}
+	columnDeleteOnly, ok := stageOf[reflect.TypeOf((*scop.MakeAddedColumnDeleteOnly)(nil))]
+	require.True(t, ok)
+	indexDeleteOnly, ok := stageOf[reflect.TypeOf((*scop.MakeAddedIndexDeleteOnly)(nil))]
+	require.True(t, ok)
+	indexPublic, ok := stageOf[reflect.TypeOf((*scop.MakeAddedPrimaryIndexPublic)(nil))]
+	require.True(t, ok)
+	require.LessOrEqual(t, columnDeleteOnly, indexDeleteOnly)
+	require.Less(t, indexDeleteOnly, indexPublic)
+}
+
+func TestPlanDropEnumColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column and type reference of:
+	//
+	//  ALTER TABLE t DROP COLUMN e
+	//
+	// where e is of enum type typ.
+	const tableID, typeID = descpb.ID(52), descpb.ID(53)
+	column := &scpb.Column{
+		TableID:    tableID,
+		ColumnID:   2,
+		FamilyName: "primary",
+		Type:       types.MakeEnum(typedesc.TypeIDToOID(typeID), typedesc.TypeIDToOID(typeID+1)),
+		Nullable:   true,
+	}
+	typeRef := &scpb.ColumnTypeReference{
+		TableID:  tableID,
+		ColumnID: 2,
+		TypeID:   typeID,
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, column, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, typeRef, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t DROP COLUMN e"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	writeOnly := findStage(&scop.MakeDroppedColumnDeleteAndWriteOnly{TableID: tableID, ColumnID: 2})
+	removeBackRef := findStage(&scop.RemoveTypeBackRef{TypeID: typeID, DescID: tableID})
+
+	require.LessOrEqual(t, writeOnly, removeBackRef,
+		"the back-reference must outlive the column being public")
+}
+
+// TestPlanRenameColumn checks that when a column is renamed, its old name is
+// removed and its new name is set in the same statement phase stage.
+func TestPlanRenameColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column names of:
+	//
+	//  ALTER TABLE t RENAME COLUMN i TO j
+	//
+	const tableID = descpb.ID(52)
+	oldName := &scpb.ColumnName{TableID: tableID, ColumnID: 1, Name: "i"}
+	newName := &scpb.ColumnName{TableID: tableID, ColumnID: 1, Name: "j"}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, oldName, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, newName, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t RENAME COLUMN i TO j"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	require.NotEmpty(t, plan.Stages)
+	require.Equal(t, scop.StatementPhase, plan.Stages[0].Phase)
+	require.Equal(t, []scop.Op{
+		&scop.SetColumnName{
+			TableID:  tableID,
+			ColumnID: 1,
+			Name:     tabledesc.ColumnNamePlaceholder(1),
+		},
+		&scop.SetColumnName{TableID: tableID, ColumnID: 1, Name: "j"},
+	}, plan.Stages[0].EdgeOps)
+}
+
+// TestPlanDropViewDependency checks that when a view is dropped, the back-reference
+// to it from the table it depends on is removed in the same stage as the view
+// is marked as dropped.
+func TestPlanDropViewDependency(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the view and back-reference of:
+	//
+	//  DROP VIEW v
+	//
+	// where v is defined as SELECT i FROM t.
+	const tableID, viewID = descpb.ID(52), descpb.ID(53)
+	view := &scpb.View{TableID: viewID}
+	dependedOnBy := &scpb.RelationDependedOnBy{TableID: tableID, DependedOnBy: viewID}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, view, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, dependedOnBy, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "DROP VIEW v"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	dropped := findStage(&scop.MarkDescriptorAsDropped{DescID: viewID})
+	removeBackRef := findStage(&scop.RemoveRelationDependedOnBy{
+		TableID:      tableID,
+		DependedOnBy: viewID,
+	})
+
+	require.Equal(t, dropped, removeBackRef)
+	require.Equal(t, scop.PreCommitPhase, plan.Stages[removeBackRef].Phase)
+}
+
+// TestPlanDropSerialColumn checks that when a SERIAL column is dropped, the
+// ownership of its sequence is removed before the column itself.
+func TestPlanDropSerialColumn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column and sequence ownership of:
+	//
+	//  ALTER TABLE t DROP COLUMN s
+	//
+	// where s is a SERIAL column which owns the sequence sq.
+	const tableID, seqID = descpb.ID(52), descpb.ID(53)
+	column := &scpb.Column{
+		TableID:    tableID,
+		ColumnID:   2,
+		FamilyName: "primary",
+		Type:       types.Int,
+	}
+	ownedBy := &scpb.SequenceOwnedBy{
+		SequenceID:    seqID,
+		OwnerTableID:  tableID,
+		OwnerColumnID: 2,
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, column, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, ownedBy, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t DROP COLUMN s"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	removeOwnedBy := findStage(&scop.RemoveSequenceOwnedBy{SequenceID: seqID})
+	absent := findStage(&scop.MakeColumnAbsent{TableID: tableID, ColumnID: 2})
+
+	require.Less(t, removeOwnedBy, absent,
+		"the sequence ownership must be removed before the column")
+	require.Equal(t, scop.PreCommitPhase, plan.Stages[removeOwnedBy].Phase)
+}
+
+// TestPlanChangeSequenceOwnedBy checks that when the owner of a sequence
+// changes, the previous ownership is removed before the new one is set.
+func TestPlanChangeSequenceOwnedBy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the sequence ownership of:
+	//
+	//  ALTER SEQUENCE sq OWNED BY t.j
+	//
+	// where sq is owned by t.i.
+	const tableID, seqID = descpb.ID(52), descpb.ID(53)
+	oldOwnedBy := &scpb.SequenceOwnedBy{
+		SequenceID:    seqID,
+		OwnerTableID:  tableID,
+		OwnerColumnID: 1,
+	}
+	newOwnedBy := &scpb.SequenceOwnedBy{
+		SequenceID:    seqID,
+		OwnerTableID:  tableID,
+		OwnerColumnID: 2,
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_DROP, oldOwnedBy, nil /* metadata */),
+				Status: scpb.Status_PUBLIC,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, newOwnedBy, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER SEQUENCE sq OWNED BY t.j"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	var ops []scop.Op
+	for _, s := range plan.Stages {
+		ops = append(ops, s.EdgeOps...)
+	}
+	require.Equal(t, []scop.Op{
+		&scop.RemoveSequenceOwnedBy{SequenceID: seqID},
+		&scop.UpdateSequenceOwnedBy{
+			SequenceID:    seqID,
+			OwnerTableID:  tableID,
+			OwnerColumnID: 2,
+		},
+	}, ops)
+}
+
+// TestPlanForeignKeyValidationDeps checks that when a foreign key is added in
+// the same schema change as the indexes it relies on, its validation waits for
+// both the unique index on the referenced columns and the index being
+// backfilled on the origin table.
+func TestPlanForeignKeyValidationDeps(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the indexes and foreign key of:
+	//
+	//  CREATE UNIQUE INDEX ON p (j);
+	//  CREATE INDEX ON c (p_j);
+	//  ALTER TABLE c ADD CONSTRAINT fk FOREIGN KEY (p_j) REFERENCES p (j);
+	//
+	const referencedID, originID = descpb.ID(52), descpb.ID(53)
+	referencedIdx := &scpb.SecondaryIndex{
+		TableID:             referencedID,
+		IndexID:             2,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{2},
+		KeyColumnDirections: []scpb.SecondaryIndex_Direction{scpb.SecondaryIndex_ASC},
+		KeySuffixColumnIDs:  []descpb.ColumnID{1},
+		SourceIndexID:       1,
+	}
+	originIdx := &scpb.SecondaryIndex{
+		TableID:             originID,
+		IndexID:             2,
+		KeyColumnIDs:        []descpb.ColumnID{2},
+		KeyColumnDirections: []scpb.SecondaryIndex_Direction{scpb.SecondaryIndex_ASC},
+		KeySuffixColumnIDs:  []descpb.ColumnID{1},
+		SourceIndexID:       1,
+	}
+	fk := &scpb.ForeignKey{
+		OriginID:         originID,
+		OriginColumns:    []descpb.ColumnID{2},
+		ReferenceID:      referencedID,
+		ReferenceColumns: []descpb.ColumnID{2},
+		Name:             "fk",
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, referencedIdx, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, originIdx, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, fk, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "CREATE UNIQUE INDEX ON p (j)"},
+			{Statement: "CREATE INDEX ON c (p_j)"},
+			{Statement: "ALTER TABLE c ADD CONSTRAINT fk FOREIGN KEY (p_j) REFERENCES p (j)"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+
+	// hasDep returns whether the graph has the dependency edge of the given rule
+	// from the element at the given status to the foreign key being validated.
+	hasDep := func(rule string, from scpb.Element, fromStatus scpb.Status) bool {
+		var found bool
+		require.NoError(t, plan.Graph.ForEachNode(func(n *scpb.Node) error {
+			return plan.Graph.ForEachDepEdgeFrom(n, func(de *scgraph.DepEdge) error {
+				if de.Name() == rule && de.Kind() == scgraph.Precedence &&
+					de.From().Element() == from && de.From().Status == fromStatus &&
+					de.To().Element() == fk && de.To().Status == scpb.Status_VALIDATED {
+					found = true
+				}
+				return nil
+			})
+		}))
+		return found
+	}
+	require.True(t, hasDep(
+		"foreign key validated after referenced unique index public",
+		referencedIdx, scpb.Status_PUBLIC,
+	))
+	require.True(t, hasDep(
+		"foreign key validated after origin index backfilled",
+		originIdx, scpb.Status_BACKFILLED,
+	))
+	require.False(t, hasDep(
+		"foreign key validated after referenced unique index public",
+		originIdx, scpb.Status_PUBLIC,
+	))
+}
+
+// TestPlanDropGcJob checks that the GC job which deletes the data of a dropped
+// index or table is created in the final stage of the plan, once the data is
+// no longer accessible.
+func TestPlanDropGcJob(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const tableID = descpb.ID(52)
+	for _, tc := range []struct {
+		stmt    string
+		element scpb.Element
+		gc      scop.Op
+	}{
+		{
+			stmt: "DROP INDEX t@idx",
+			element: &scpb.SecondaryIndex{
+				TableID:             tableID,
+				IndexID:             2,
+				KeyColumnIDs:        []descpb.ColumnID{2},
+				KeyColumnDirections: []scpb.SecondaryIndex_Direction{scpb.SecondaryIndex_ASC},
+				KeySuffixColumnIDs:  []descpb.ColumnID{1},
+			},
+			gc: &scop.CreateGcJobForIndex{TableID: tableID, IndexID: 2},
+		},
+		{
+			stmt:    "DROP TABLE t",
+			element: &scpb.Table{TableID: tableID},
+			gc:      &scop.CreateGcJobForTable{TableID: tableID},
+		},
+	} {
+		t.Run(tc.stmt, func(t *testing.T) {
+			state := scpb.State{
+				Nodes: []*scpb.Node{
+					{
+						Target: scpb.NewTarget(scpb.Target_DROP, tc.element, nil /* metadata */),
+						Status: scpb.Status_PUBLIC,
+					},
+				},
+				Statements: []*scpb.Statement{
+					{Statement: tc.stmt},
+				},
+			}
+			plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+			validatePlan(t, &plan)
+
+			require.NotEmpty(t, plan.Stages)
+			for i, s := range plan.Stages {
+				var hasGc bool
+				for _, o := range s.EdgeOps {
+					if reflect.DeepEqual(o, tc.gc) {
+						hasGc = true
+					}
+				}
+				if i < len(plan.Stages)-1 {
+					require.Falsef(t, hasGc, "stage %d of %d creates the GC job", i+1, len(plan.Stages))
+				} else {
+					require.Truef(t, hasGc, "final stage does not create the GC job")
+					require.Equal(t, scop.PostCommitPhase, s.Phase)
+				}
+			}
+		})
+	}
+}
+
+// TestPlanAddColumnCreateFamily checks that when a column is added into a new
+// column family, the family is added before the column joins it.
+func TestPlanAddColumnCreateFamily(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the column and column family of:
+	//
+	//  ALTER TABLE t ADD COLUMN j INT CREATE FAMILY f
+	//
+	const tableID = descpb.ID(52)
+	family := &scpb.ColumnFamily{TableID: tableID, FamilyID: 1, Name: "f"}
+	column := &scpb.Column{
+		TableID:    tableID,
+		ColumnID:   2,
+		FamilyID:   1,
+		FamilyName: "f",
+		Type:       types.Int,
+		Nullable:   true,
+	}
+	state := scpb.State{
+		Nodes: []*scpb.Node{
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, column, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+			{
+				Target: scpb.NewTarget(scpb.Target_ADD, family, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			},
+		},
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t ADD COLUMN j INT CREATE FAMILY f"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	// findStage returns the ordinal of the first stage which contains an op of
+	// the same type as op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.TypeOf(o) == reflect.TypeOf(op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T", op)
+		return -1
+	}
+	addFamily := findStage((*scop.AddColumnFamily)(nil))
+	deleteOnly := findStage((*scop.MakeAddedColumnDeleteOnly)(nil))
+
+	require.Less(t, addFamily, deleteOnly, "the family must exist before the column joins it")
+	require.Equal(t, scop.StatementPhase, plan.Stages[addFamily].Phase)
+}
+
+// TestPlanDropTableCascade checks that when a table is dropped with CASCADE,
+// the objects which depend on it are dropped before it.
+func TestPlanDropTableCascade(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to some of the elements of:
+	//
+	//  DROP TABLE p CASCADE
+	//
+	// where c has a foreign key referencing the primary key of p, and v is
+	// defined as SELECT j FROM p.
+	const tableID, originID, viewID = descpb.ID(52), descpb.ID(53), descpb.ID(54)
+	table := &scpb.Table{TableID: tableID}
+	primaryIdx := &scpb.PrimaryIndex{
+		TableID:             tableID,
+		IndexID:             1,
+		Unique:              true,
+		KeyColumnIDs:        []descpb.ColumnID{1},
+		KeyColumnDirections: []scpb.PrimaryIndex_Direction{scpb.PrimaryIndex_ASC},
+		StoringColumnIDs:    []descpb.ColumnID{2},
+	}
+	fk := &scpb.ForeignKey{
+		OriginID:         originID,
+		OriginColumns:    []descpb.ColumnID{2},
+		ReferenceID:      tableID,
+		ReferenceColumns: []descpb.ColumnID{1},
+		Name:             "fk",
+	}
+	backRef := &scpb.ForeignKeyBackReference{
+		OriginID:         tableID,
+		OriginColumns:    []descpb.ColumnID{1},
+		ReferenceID:      originID,
+		ReferenceColumns: []descpb.ColumnID{2},
+		Name:             "fk",
+	}
+	view := &scpb.View{TableID: viewID}
+	dependedOnBy := &scpb.RelationDependedOnBy{TableID: tableID, DependedOnBy: viewID}
+	var nodes []*scpb.Node
+	for _, e := range []scpb.Element{table, primaryIdx, fk, backRef, view, dependedOnBy} {
+		nodes = append(nodes, &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_DROP, e, nil /* metadata */),
+			Status: scpb.Status_PUBLIC,
+		})
+	}
+	state := scpb.State{
+		Nodes: nodes,
+		Statements: []*scpb.Statement{
+			{Statement: "DROP TABLE p CASCADE"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	// hasDep returns whether the graph has the dependency edge of the given rule
+	// between the given elements at the given statuses.
+	hasDep := func(
+		rule string, from scpb.Element, fromStatus scpb.Status, to scpb.Element, toStatus scpb.Status,
+	) bool {
+		var found bool
+		require.NoError(t, plan.Graph.ForEachNode(func(n *scpb.Node) error {
+			return plan.Graph.ForEachDepEdgeFrom(n, func(de *scgraph.DepEdge) error {
+				if de.Name() == rule &&
+					de.From().Element() == from && de.From().Status == fromStatus &&
+					de.To().Element() == to && de.To().Status == toStatus {
+					found = true
+				}
+				return nil
+			})
+		}))
+		return found
+	}
+	require.True(t, hasDep(
+		"referencing foreign key removed before referenced index no longer written",
+		fk, scpb.Status_ABSENT, primaryIdx, scpb.Status_DELETE_ONLY,
+	))
+	require.True(t, hasDep(
+		"dependent view dropped before the relation it depends on",
+		view, scpb.Status_DROPPED, table, scpb.Status_DROPPED,
+	))
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	fkDropped := findStage(&scop.DropForeignKeyRef{TableID: originID, Name: "fk", Outbound: true})
+	indexDeleteOnly := findStage(&scop.MakeDroppedIndexDeleteOnly{TableID: tableID, IndexID: 1})
+	require.Less(t, fkDropped, indexDeleteOnly)
+	viewDropped := findStage(&scop.MarkDescriptorAsDropped{DescID: viewID})
+	tableDropped := findStage(&scop.MarkDescriptorAsDropped{DescID: tableID})
+	require.LessOrEqual(t, viewDropped, tableDropped)
+}
+
+// TestPlanMaxTargets checks that a schema change with more targets than the
+// configured maximum is rejected before any planning is attempted.
+func TestPlanMaxTargets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to the columns of:
+	//
+	//  ALTER TABLE t ADD COLUMN c1 INT, ADD COLUMN c2 INT, ...
+	//
+	const tableID, numColumns = descpb.ID(52), 1000
+	var nodes []*scpb.Node
+	for i := 0; i < numColumns; i++ {
+		columnID := descpb.ColumnID(i + 2)
+		for _, e := range []scpb.Element{
+			&scpb.Column{TableID: tableID, ColumnID: columnID, Type: types.Int, Nullable: true},
+			&scpb.ColumnName{TableID: tableID, ColumnID: columnID, Name: fmt.Sprintf("c%d", i+1)},
+		} {
+			nodes = append(nodes, &scpb.Node{
+				Target: scpb.NewTarget(scpb.Target_ADD, e, nil /* metadata */),
+				Status: scpb.Status_ABSENT,
+			})
+		}
+	}
+	state := scpb.State{
+		Nodes:      nodes,
+		Statements: []*scpb.Statement{{Statement: "ALTER TABLE t ADD COLUMN c1 INT, ..."}},
+	}
+	plan, err := scplan.MakePlan(state, scplan.Params{
+		ExecutionPhase:             scop.StatementPhase,
+		SchemaChangerJobIDSupplier: func() jobspb.JobID { return 1 },
+		MaxTargets:                 2*numColumns - 1,
+	})
+	require.Error(t, err)
+	require.Equal(t, pgcode.ProgramLimitExceeded, pgerror.GetPGCode(err))
+	require.Regexp(t, "schema change targets 2000 elements, more than the maximum of 1999", err)
+	require.Nil(t, plan.Graph, "no graph should have been built")
+}
+
+// TestPlanTableNamespace checks that the namespace entry of a table is added
+// before the table becomes public, and removed only after it is dropped.
+func TestPlanTableNamespace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const dbID, schemaID, tableID = descpb.ID(50), descpb.ID(51), descpb.ID(52)
+	table := &scpb.Table{TableID: tableID}
+	ns := &scpb.Namespace{
+		DatabaseID:   dbID,
+		SchemaID:     schemaID,
+		DescriptorID: tableID,
+		Name:         "t",
+	}
+	makePlan := func(t *testing.T, dir scpb.Target_Direction, stmt string) scplan.Plan {
+		initial := scpb.Status_ABSENT
+		if dir == scpb.Target_DROP {
+			initial = scpb.Status_PUBLIC
+		}
+		var nodes []*scpb.Node
+		for _, e := range []scpb.Element{table, ns} {
+			nodes = append(nodes, &scpb.Node{
+				Target: scpb.NewTarget(dir, e, nil /* metadata */),
+				Status: initial,
+			})
+		}
+		plan := sctestutils.MakePlan(t, scpb.State{
+			Nodes:      nodes,
+			Statements: []*scpb.Statement{{Statement: stmt}},
+		}, scop.EarliestPhase)
+		validatePlan(t, &plan)
+		return plan
+	}
+	// findStage returns the ordinal of the first stage of the plan which
+	// contains op.
+	findStage := func(t *testing.T, plan scplan.Plan, op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+
+	t.Run("create", func(t *testing.T) {
+		plan := makePlan(t, scpb.Target_ADD, "CREATE TABLE t (i INT PRIMARY KEY)")
+		var found bool
+		require.NoError(t, plan.Graph.ForEachNode(func(n *scpb.Node) error {
+			return plan.Graph.ForEachDepEdgeFrom(n, func(de *scgraph.DepEdge) error {
+				if de.Name() == "descriptor public after namespace entry added" &&
+					de.From().Element() == ns && de.From().Status == scpb.Status_PUBLIC &&
+					de.To().Element() == table && de.To().Status == scpb.Status_PUBLIC {
+					found = true
+				}
+				return nil
+			})
+		}))
+		require.True(t, found)
+		added := findStage(t, plan, &scop.AddNamespaceEntry{
+			DescID:     tableID,
+			DatabaseID: dbID,
+			SchemaID:   schemaID,
+			Name:       "t",
+		})
+		require.Equal(t, scop.StatementPhase, plan.Stages[added].Phase)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		plan := makePlan(t, scpb.Target_DROP, "DROP TABLE t")
+		dropped := findStage(t, plan, &scop.MarkDescriptorAsDropped{DescID: tableID})
+		drained := findStage(t, plan, &scop.DrainDescriptorName{TableID: tableID})
+		gc := findStage(t, plan, &scop.CreateGcJobForTable{TableID: tableID})
+		require.LessOrEqual(t, dropped, drained)
+		require.Less(t, drained, gc)
+	})
+}
+
+// TestExplainPlan checks the explanation of the plan dropping a foreign key.
+func TestExplainPlan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// ALTER TABLE child DROP CONSTRAINT fk, where child (53) references
+	// parent (52).
+	fk := &scpb.ForeignKey{
+		OriginID:         53,
+		OriginColumns:    []descpb.ColumnID{2},
+		ReferenceID:      52,
+		ReferenceColumns: []descpb.ColumnID{1},
+		Name:             "fk",
+	}
+	e, err := scplan.ExplainPlan(scpb.State{
+		Nodes: []*scpb.Node{{
+			Target: scpb.NewTarget(scpb.Target_DROP, fk, nil /* metadata */),
+			Status: scpb.Status_PUBLIC,
+		}},
+		Statements: []*scpb.Statement{{Statement: "ALTER TABLE child DROP CONSTRAINT fk"}},
+	}, scplan.Params{
+		ExecutionPhase:             scop.StatementPhase,
+		SchemaChangerJobIDSupplier: func() jobspb.JobID { return 1 },
+	})
+	require.NoError(t, err)
+
+	// The references are removed in a single pre-commit stage, which requires
+	// no schema changer job.
+	require.Len(t, e.Stages, 1)
+	s := e.Stages[0]
+	require.Equal(t, scop.PreCommitPhase, s.Phase)
+	require.Equal(t, "PreCommitPhase stage 1 of 1 with 2 MutationType ops", s.Stage)
+	require.Len(t, s.Transitions, 1)
+	require.True(t, strings.HasSuffix(s.Transitions[0], "-> ABSENT"), s.Transitions[0])
+	require.Equal(t, []scop.Op{
+		&scop.DropForeignKeyRef{TableID: 53, Name: "fk", Outbound: true},
+		&scop.DropForeignKeyRef{TableID: 52, Name: "fk", Outbound: false},
+	}, s.Ops)
+
+	require.Equal(t, strings.Join([]string{
+		"PreCommitPhase stage 1 of 1 with 2 MutationType ops",
+		"  transitions:",
+		"    " + s.Transitions[0],
+		"  ops:",
+		`    *scop.DropForeignKeyRef {"TableID":53,"Name":"fk","Outbound":true}`,
+		`    *scop.DropForeignKeyRef {"TableID":52,"Name":"fk","Outbound":false}`,
+		"",
+	}, "\n"), e.String())
+}
+
+// TestPlanSetSchema checks that moving a table to another schema replaces its
+// namespace entry in the same stage as it updates its parent schema, so that
+// the previous entry is drained before the descriptor is modified.
+func TestPlanSetSchema(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const dbID, sc1ID, sc2ID, tableID = descpb.ID(50), descpb.ID(51), descpb.ID(52), descpb.ID(53)
+	var nodes []*scpb.Node
+	for _, tc := range []struct {
+		dir      scpb.Target_Direction
+		schemaID descpb.ID
+	}{
+		{scpb.Target_DROP, sc1ID},
+		{scpb.Target_ADD, sc2ID},
+	} {
+		initial := scpb.Status_ABSENT
+		if tc.dir == scpb.Target_DROP {
+			initial = scpb.Status_PUBLIC
+		}
+		for _, e := range []scpb.Element{
+			&scpb.Namespace{
+				DatabaseID:   dbID,
+				SchemaID:     tc.schemaID,
+				DescriptorID: tableID,
+				Name:         "t",
+			},
+			&scpb.SchemaParent{DescriptorID: tableID, SchemaID: tc.schemaID},
+		} {
+			nodes = append(nodes, &scpb.Node{
+				Target: scpb.NewTarget(tc.dir, e, nil /* metadata */),
+				Status: initial,
+			})
+		}
+	}
+	plan := sctestutils.MakePlan(t, scpb.State{
+		Nodes:      nodes,
+		Statements: []*scpb.Statement{{Statement: "ALTER TABLE sc1.t SET SCHEMA sc2"}},
+	}, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	require.NotEmpty(t, plan.Stages)
+	stage := plan.Stages[0]
+	require.Equal(t, scop.StatementPhase, stage.Phase)
+	position := func(op scop.Op) int {
+		for i, o := range stage.EdgeOps {
+			if reflect.DeepEqual(o, op) {
+				return i
+			}
+		}
+		t.Fatalf("first stage does not contain %T %+v", op, op)
+		return -1
+	}
+	drained := position(&scop.DrainDescriptorName{TableID: tableID})
+	updated := position(&scop.UpdateParentSchema{DescID: tableID, SchemaID: sc2ID})
+	added := position(&scop.AddNamespaceEntry{
+		DescID:     tableID,
+		DatabaseID: dbID,
+		SchemaID:   sc2ID,
+		Name:       "t",
+	})
+	require.Less(t, drained, updated)
+	require.Less(t, updated, added)
+	// Dropping the previous parent schema is a no-op.
+	for _, s := range plan.Stages {
+		for _, op := range s.EdgeOps {
+			require.NotEqual(t, reflect.TypeOf((*scop.NotImplemented)(nil)), reflect.TypeOf(op))
+		}
+	}
+}
+
+// TestPlanRegionalByRowLocality checks that a REGIONAL BY ROW locality is only
+// set once the region column and the partitioning of the table are public,
+// whereas a GLOBAL locality is set by the statement itself.
+func TestPlanRegionalByRowLocality(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Corresponds to some of the elements of:
+	//
+	//  ALTER TABLE t SET LOCALITY REGIONAL BY ROW;
+	//  ALTER TABLE g SET LOCALITY GLOBAL;
+	//
+	const tableID, globalID = descpb.ID(52), descpb.ID(53)
+	column := &scpb.Column{
+		TableID:  tableID,
+		ColumnID: 2,
+		Type:     types.String,
+		Hidden:   true,
+	}
+	columnName := &scpb.ColumnName{TableID: tableID, ColumnID: 2, Name: "crdb_region"}
+	partitioning := &scpb.Partitioning{
+		TableID: tableID,
+		IndexID: 2,
+		Fields:  []string{"crdb_region"},
+	}
+	rbrLocality := &descpb.TableDescriptor_LocalityConfig{
+		Locality: &descpb.TableDescriptor_LocalityConfig_RegionalByRow_{
+			RegionalByRow: &descpb.TableDescriptor_LocalityConfig_RegionalByRow{},
+		},
+	}
+	rbr := &scpb.Locality{DescriptorID: tableID, Locality: rbrLocality}
+	globalLocality := &descpb.TableDescriptor_LocalityConfig{
+		Locality: &descpb.TableDescriptor_LocalityConfig_Global_{
+			Global: &descpb.TableDescriptor_LocalityConfig_Global{},
+		},
+	}
+	global := &scpb.Locality{DescriptorID: globalID, Locality: globalLocality}
+	var nodes []*scpb.Node
+	for _, e := range []scpb.Element{column, columnName, partitioning, rbr, global} {
+		nodes = append(nodes, &scpb.Node{
+			Target: scpb.NewTarget(scpb.Target_ADD, e, nil /* metadata */),
+			Status: scpb.Status_ABSENT,
+		})
+	}
+	state := scpb.State{
+		Nodes: nodes,
+		Statements: []*scpb.Statement{
+			{Statement: "ALTER TABLE t SET LOCALITY REGIONAL BY ROW"},
+			{Statement: "ALTER TABLE g SET LOCALITY GLOBAL"},
+		},
+	}
+	plan := sctestutils.MakePlan(t, state, scop.EarliestPhase)
+	validatePlan(t, &plan)
+
+	// depsTo returns the names of the rules of the dependency edges from the
+	// given element to the given locality being set.
+	depsTo := func(from scpb.Element, to *scpb.Locality) []string {
+		var rules []string
+		require.NoError(t, plan.Graph.ForEachNode(func(n *scpb.Node) error {
+			return plan.Graph.ForEachDepEdgeFrom(n, func(de *scgraph.DepEdge) error {
+				if de.From().Element() == from && de.To().Element() == to {
+					rules = append(rules, de.Name())
+				}
+				return nil
+			})
+		}))
+		return rules
+	}
+	require.Equal(t, []string{"regional by row locality set after region column public"},
+		depsTo(column, rbr))
+	require.Equal(t, []string{"regional by row locality set after partitioning public"},
+		depsTo(partitioning, rbr))
+	require.Empty(t, depsTo(column, global))
+	require.Empty(t, depsTo(partitioning, global))
+
+	// findStage returns the ordinal of the first stage which contains op.
+	findStage := func(op scop.Op) int {
+		for i, s := range plan.Stages {
+			for _, o := range s.EdgeOps {
+				if reflect.DeepEqual(o, op) {
+					return i
+				}
+			}
+		}
+		t.Fatalf("no stage contains %T %+v", op, op)
+		return -1
+	}
+	columnPublic := findStage(&scop.MakeColumnPublic{TableID: tableID, ColumnID: 2})
+	rbrSet := findStage(&scop.SetTableLocality{TableID: tableID, Locality: rbrLocality})
+	globalSet := findStage(&scop.SetTableLocality{TableID: globalID, Locality: globalLocality})
+	require.LessOrEqual(t, columnPublic, rbrSet)
+	require.Equal(t, scop.PostCommitPhase, plan.Stages[rbrSet].Phase)
+	require.Equal(t, scop.StatementPhase, plan.Stages[globalSet].Phase)
+}
+
 // validatePlan takes an existing plan and re-plans using the starting state of
 // an arbitrary stage in the existing plan: the results should be the same as in
 // the original plan, minus the stages prior to the selected stage.
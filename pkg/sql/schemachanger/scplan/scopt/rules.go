@@ -33,7 +33,8 @@ func init() {
 				(*scpb.ForeignKeyBackReference)(nil), (*scpb.ForeignKey)(nil),
 				(*scpb.CheckConstraint)(nil), (*scpb.UniqueConstraint)(nil),
 				(*scpb.ConstraintName)(nil), (*scpb.Owner)(nil),
-				(*scpb.Locality)(nil), (*scpb.UserPrivileges)(nil)),
+				(*scpb.Locality)(nil), (*scpb.UserPrivileges)(nil),
+				(*scpb.SchemaParent)(nil)),
 			id.Entities(screl.DescID, relation, dep),
 
 			// If the relation is in any drop state in the current phase,
@@ -95,6 +96,102 @@ func init() {
 	)
 }
 
+// When dropping an index we need to mark the DROP op edge for its partitioning
+// element as no-op, since the partitioning goes away with the index.
+func init() {
+	partitioning, partitioningTarget, partitioningNode := targetNodeVars("dep")
+	idx, idxTarget, idxNode := targetNodeVars("idx")
+	var id rel.Var = "id"
+	var indexID rel.Var = "index-id"
+	registerNoOpEdges(
+		partitioningNode,
+		screl.MustQuery(
+			idx.Type((*scpb.PrimaryIndex)(nil), (*scpb.SecondaryIndex)(nil)),
+			partitioning.Type((*scpb.Partitioning)(nil)),
+			id.Entities(screl.DescID, idx, partitioning),
+			indexID.Entities(screl.IndexID, idx, partitioning),
+
+			screl.JoinTargetNode(partitioning, partitioningTarget, partitioningNode),
+			partitioningTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+
+			screl.JoinTargetNode(idx, idxTarget, idxNode),
+			idxTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+		),
+	)
+}
+
+// When the owner of a descriptor changes, we need to mark the DROP op edge for
+// its previous owner as no-op, since setting the new owner replaces it.
+func init() {
+	oldOwner, oldOwnerTarget, oldOwnerNode := targetNodeVars("old-owner")
+	newOwner, newOwnerTarget, newOwnerNode := targetNodeVars("new-owner")
+	var id rel.Var = "id"
+	registerNoOpEdges(
+		oldOwnerNode,
+		screl.MustQuery(
+			oldOwner.Type((*scpb.Owner)(nil)),
+			newOwner.Type((*scpb.Owner)(nil)),
+			id.Entities(screl.DescID, oldOwner, newOwner),
+
+			screl.JoinTargetNode(oldOwner, oldOwnerTarget, oldOwnerNode),
+			oldOwnerTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+
+			screl.JoinTargetNode(newOwner, newOwnerTarget, newOwnerNode),
+			newOwnerTarget.AttrEq(screl.Direction, scpb.Target_ADD),
+		),
+	)
+}
+
+// When an object moves to another schema, we need to mark the DROP op edge for
+// its previous parent schema as no-op, since setting the new one replaces it.
+func init() {
+	oldParent, oldParentTarget, oldParentNode := targetNodeVars("old-parent")
+	newParent, newParentTarget, newParentNode := targetNodeVars("new-parent")
+	var id rel.Var = "id"
+	registerNoOpEdges(
+		oldParentNode,
+		screl.MustQuery(
+			oldParent.Type((*scpb.SchemaParent)(nil)),
+			newParent.Type((*scpb.SchemaParent)(nil)),
+			id.Entities(screl.DescID, oldParent, newParent),
+
+			screl.JoinTargetNode(oldParent, oldParentTarget, oldParentNode),
+			oldParentTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+
+			screl.JoinTargetNode(newParent, newParentTarget, newParentNode),
+			newParentTarget.AttrEq(screl.Direction, scpb.Target_ADD),
+		),
+	)
+}
+
+// When validating a check constraint, we need to mark the DROP op edge for its
+// unvalidated counterpart as no-op, since the constraint itself is retained.
+func init() {
+	oldCheck, oldCheckTarget, oldCheckNode := targetNodeVars("old-check")
+	newCheck, newCheckTarget, newCheckNode := targetNodeVars("new-check")
+	var id, name rel.Var = "id", "name"
+	registerNoOpEdges(
+		oldCheckNode,
+		screl.MustQuery(
+			oldCheck.Type((*scpb.CheckConstraint)(nil)),
+			newCheck.Type((*scpb.CheckConstraint)(nil)),
+			id.Entities(screl.DescID, oldCheck, newCheck),
+			name.Entities(screl.Name, oldCheck, newCheck),
+			rel.Filter("isValidation", oldCheck, newCheck)(
+				func(oldCheck, newCheck *scpb.CheckConstraint) bool {
+					return !oldCheck.Validated && newCheck.Validated
+				},
+			),
+
+			screl.JoinTargetNode(oldCheck, oldCheckTarget, oldCheckNode),
+			oldCheckTarget.AttrEq(screl.Direction, scpb.Target_DROP),
+
+			screl.JoinTargetNode(newCheck, newCheckTarget, newCheckNode),
+			newCheckTarget.AttrEq(screl.Direction, scpb.Target_ADD),
+		),
+	)
+}
+
 // TODO(fqazi): For create operations we will need to have the ability
 // to have transformations that will combine transitions into a single
 // stage for execution. For example, a newly CREATE TABLE will be represented
@@ -474,5 +474,18 @@ func decorateStages(stages []Stage) []Stage {
 			s.StagesInPhase = len(indexes)
 		}
 	}
+	// The job's running status is set at the end of each stage which creates
+	// or updates it, and shows for as long as the next stage is executing.
+	for i := 0; i+1 < len(stages); i++ {
+		runningStatus := scop.Describe(stages[i+1].EdgeOps)
+		for _, op := range stages[i].ExtraOps {
+			switch op := op.(type) {
+			case *scop.CreateDeclarativeSchemaChangerJob:
+				op.RunningStatus = runningStatus
+			case *scop.UpdateSchemaChangerJob:
+				op.RunningStatus = runningStatus
+			}
+		}
+	}
 	return stages
 }
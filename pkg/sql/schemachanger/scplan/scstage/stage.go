@@ -37,6 +37,10 @@ type Stage struct {
 	//   required for the execution of the schema changer framework itself. This
 	//   includes, notably, creating the schema changer job, updating its
 	//   progress, and so forth.
+	// The order of the ops is significant: ops which fulfill a node come after
+	// those which fulfill its dependencies in the same stage. It is
+	// deterministic, as op-edges are collected by iterating over the nodes of
+	// the Before state in order, so the ops are not sorted any further.
 	EdgeOps, ExtraOps []scop.Op
 
 	// Phase describes the context in which the stage is to be executed: is it
@@ -123,6 +123,14 @@ var Schema = rel.MustSchema("screl",
 		rel.EntityAttr(DescID, "TableID"),
 		rel.EntityAttr(ColumnID, "ColumnID"),
 	),
+	rel.EntityMapping(t((*scpb.ComputedExpr)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+		rel.EntityAttr(ColumnID, "ColumnID"),
+	),
+	rel.EntityMapping(t((*scpb.OnUpdateExpr)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+		rel.EntityAttr(ColumnID, "ColumnID"),
+	),
 	rel.EntityMapping(t((*scpb.View)(nil)),
 		rel.EntityAttr(DescID, "TableID"),
 	),
@@ -175,10 +183,19 @@ var Schema = rel.MustSchema("screl",
 	rel.EntityMapping(t((*scpb.SequenceOwnedBy)(nil)),
 		rel.EntityAttr(DescID, "SequenceID"),
 		rel.EntityAttr(ReferencedDescID, "OwnerTableID"),
+		rel.EntityAttr(ColumnID, "OwnerColumnID"),
 	),
 	rel.EntityMapping(t((*scpb.Type)(nil)),
 		rel.EntityAttr(DescID, "TypeID"),
 	),
+	rel.EntityMapping(t((*scpb.EnumMember)(nil)),
+		rel.EntityAttr(DescID, "TypeID"),
+		rel.EntityAttr(Name, "LogicalRepresentation"),
+	),
+	rel.EntityMapping(t((*scpb.ColumnFamily)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+		rel.EntityAttr(Name, "Name"),
+	),
 	rel.EntityMapping(t((*scpb.Schema)(nil)),
 		rel.EntityAttr(DescID, "SchemaID"),
 	),
@@ -219,6 +236,21 @@ var Schema = rel.MustSchema("screl",
 		rel.EntityAttr(ConstraintType, "ConstraintType"),
 		rel.EntityAttr(ConstraintOrdinal, "ConstraintOrdinal"),
 	),
+	rel.EntityMapping(t((*scpb.TableComment)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+	),
+	rel.EntityMapping(t((*scpb.ColumnComment)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+		rel.EntityAttr(ColumnID, "ColumnID"),
+	),
+	rel.EntityMapping(t((*scpb.IndexComment)(nil)),
+		rel.EntityAttr(DescID, "TableID"),
+		rel.EntityAttr(IndexID, "IndexID"),
+	),
+	rel.EntityMapping(t((*scpb.SchemaParent)(nil)),
+		rel.EntityAttr(DescID, "DescriptorID"),
+		rel.EntityAttr(ReferencedDescID, "SchemaID"),
+	),
 	rel.EntityMapping(t((*scpb.DatabaseSchemaEntry)(nil)),
 		rel.EntityAttr(DescID, "DatabaseID"),
 		rel.EntityAttr(ReferencedDescID, "SchemaID"),
@@ -56,6 +56,14 @@ func BenchmarkCompareElements(b *testing.B) {
 		&scpb.Sequence{},
 		&scpb.DefaultExpression{},
 		&scpb.DefaultExprTypeReference{},
+		&scpb.ComputedExpr{},
+		&scpb.EnumMember{},
+		&scpb.ColumnFamily{},
+		&scpb.TableComment{},
+		&scpb.ColumnComment{},
+		&scpb.IndexComment{},
+		&scpb.SchemaParent{},
+		&scpb.OnUpdateExpr{},
 		&scpb.ComputedExprTypeReference{},
 		&scpb.OnUpdateExprTypeReference{},
 		&scpb.View{},
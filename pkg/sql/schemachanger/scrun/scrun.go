@@ -16,6 +16,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scexec"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scgraphviz"
 	"github.com/cockroachdb/cockroach/pkg/sql/schemachanger/scop"
@@ -30,9 +32,13 @@ import (
 // state. These are the immediate changes which take place at DDL statement
 // execution time (scop.StatementPhase).
 func RunStatementPhase(
-	ctx context.Context, knobs *TestingKnobs, deps scexec.Dependencies, state scpb.State,
+	ctx context.Context,
+	knobs *TestingKnobs,
+	settings *cluster.Settings,
+	deps scexec.Dependencies,
+	state scpb.State,
 ) (scpb.State, jobspb.JobID, error) {
-	return runTransactionPhase(ctx, knobs, deps, state, scop.StatementPhase)
+	return runTransactionPhase(ctx, knobs, settings, deps, state, scop.StatementPhase)
 }
 
 // RunPreCommitPhase executes in-transaction schema changes for the targeted
@@ -40,14 +46,19 @@ func RunStatementPhase(
 // than the asynchronous changes which are done by the schema changer job
 // after the transaction commits.
 func RunPreCommitPhase(
-	ctx context.Context, knobs *TestingKnobs, deps scexec.Dependencies, state scpb.State,
+	ctx context.Context,
+	knobs *TestingKnobs,
+	settings *cluster.Settings,
+	deps scexec.Dependencies,
+	state scpb.State,
 ) (scpb.State, jobspb.JobID, error) {
-	return runTransactionPhase(ctx, knobs, deps, state, scop.PreCommitPhase)
+	return runTransactionPhase(ctx, knobs, settings, deps, state, scop.PreCommitPhase)
 }
 
 func runTransactionPhase(
 	ctx context.Context,
 	knobs *TestingKnobs,
+	settings *cluster.Settings,
 	deps scexec.Dependencies,
 	state scpb.State,
 	phase scop.Phase,
@@ -58,8 +69,14 @@ func runTransactionPhase(
 	sc, err := scplan.MakePlan(state, scplan.Params{
 		ExecutionPhase:             phase,
 		SchemaChangerJobIDSupplier: deps.TransactionalJobRegistry().SchemaChangerJobID,
+		MaxTargets:                 int(scplan.MaxTargets.Get(&settings.SV)),
 	})
 	if err != nil {
+		// A schema change which is too large to be planned is rejected with a
+		// user-facing error, any other planning error is an assertion failure.
+		if pgerror.GetPGCode(err) == pgcode.ProgramLimitExceeded {
+			return scpb.State{}, jobspb.InvalidJobID, err
+		}
 		return scpb.State{}, jobspb.InvalidJobID, scgraphviz.DecorateErrorWithPlanDetails(err, sc)
 	}
 	after := state
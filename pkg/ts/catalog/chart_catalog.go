@@ -2450,6 +2450,72 @@ var charts = []sectionDescription{
 			},
 		},
 	},
+	{
+		Organization: [][]string{{DistributionLayer, "Bulk", "Blob Service"}},
+		Charts: []chartDescription{
+			{
+				Title: "Bytes",
+				Metrics: []string{
+					"blobs.bytes.read",
+					"blobs.bytes.written",
+				},
+			},
+			{
+				Title: "Operations",
+				Metrics: []string{
+					"blobs.get.count",
+					"blobs.put.count",
+					"blobs.list.count",
+					"blobs.delete.count",
+					"blobs.stat.count",
+					"blobs.append.count",
+					"blobs.copy.count",
+					"blobs.move.count",
+					"blobs.mkdir.count",
+					"blobs.truncate.count",
+					"blobs.checksum.count",
+					"blobs.exists.count",
+					"blobs.batch_get.count",
+					"blobs.batch_delete.count",
+					"blobs.disk_usage.count",
+					"blobs.walk.count",
+					"blobs.lock.count",
+					"blobs.unlock.count",
+					"blobs.writeat.count",
+					"blobs.touch.count",
+					"blobs.read.count",
+					"blobs.write.count",
+				},
+			},
+			{
+				Title: "Latency",
+				Metrics: []string{
+					"blobs.get.latency",
+					"blobs.put.latency",
+					"blobs.list.latency",
+					"blobs.delete.latency",
+					"blobs.stat.latency",
+					"blobs.append.latency",
+					"blobs.copy.latency",
+					"blobs.move.latency",
+					"blobs.mkdir.latency",
+					"blobs.truncate.latency",
+					"blobs.checksum.latency",
+					"blobs.exists.latency",
+					"blobs.batch_get.latency",
+					"blobs.batch_delete.latency",
+					"blobs.disk_usage.latency",
+					"blobs.walk.latency",
+					"blobs.lock.latency",
+					"blobs.unlock.latency",
+					"blobs.writeat.latency",
+					"blobs.touch.latency",
+					"blobs.read.latency",
+					"blobs.write.latency",
+				},
+			},
+		},
+	},
 	{
 		Organization: [][]string{{DistributionLayer, "Bulk", "Egress"}},
 		Charts: []chartDescription{
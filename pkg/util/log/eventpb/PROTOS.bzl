@@ -18,6 +18,7 @@ EVENTPB_PROTOS = [
     "job_events.proto",
     "health_events.proto",
     "telemetry.proto",
+    "blob_events.proto",
 ]
 
 # The same list as above, but formatted such that outside Bazel rules can depend
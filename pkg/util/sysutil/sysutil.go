@@ -70,3 +70,8 @@ func IsErrConnectionReset(err error) bool {
 func IsErrConnectionRefused(err error) bool {
 	return errors.Is(err, syscall.ECONNREFUSED)
 }
+
+// IsErrNoSpace returns true if an error is a "no space left on device" error.
+func IsErrNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
@@ -35,3 +35,20 @@ func ProcessIdentity() string {
 func IsCrossDeviceLinkErrno(errno error) bool {
 	return errno == syscall.EXDEV
 }
+
+// IsDirNotEmptyErrno checks whether the given error object (as extracted
+// from an *os.PathError) reports that a directory could not be removed
+// because it is not empty.
+func IsDirNotEmptyErrno(errno error) bool {
+	// POSIX allows rmdir to fail with either error.
+	return errno == syscall.ENOTEMPTY || errno == syscall.EEXIST
+}
+
+// IsLinkUnsupportedErrno checks whether the given error object (as extracted
+// from an *os.LinkError) reports that a hard link could not be created
+// because the filesystem does not support it between the given paths.
+func IsLinkUnsupportedErrno(errno error) bool {
+	// Linux reports EPERM for filesystems which do not support hard links.
+	return errno == syscall.EPERM || errno == syscall.ENOTSUP ||
+		errno == syscall.EOPNOTSUPP || errno == syscall.EXDEV
+}
@@ -37,3 +37,23 @@ func IsCrossDeviceLinkErrno(errno error) bool {
 	// See: https://msdn.microsoft.com/en-us/library/cc231199.aspx
 	return errno == syscall.Errno(0x11)
 }
+
+// IsDirNotEmptyErrno checks whether the given error object (as extracted
+// from an *os.PathError) reports that a directory could not be removed
+// because it is not empty.
+func IsDirNotEmptyErrno(errno error) bool {
+	// 0x91 is Win32 Error Code ERROR_DIR_NOT_EMPTY
+	// See: https://msdn.microsoft.com/en-us/library/cc231199.aspx
+	return errno == syscall.Errno(0x91)
+}
+
+// IsLinkUnsupportedErrno checks whether the given error object (as extracted
+// from an *os.LinkError) reports that a hard link could not be created
+// because the filesystem does not support it between the given paths.
+func IsLinkUnsupportedErrno(errno error) bool {
+	// 0x1 is Win32 Error Code ERROR_INVALID_FUNCTION, 0x5 ERROR_ACCESS_DENIED,
+	// 0x11 ERROR_NOT_SAME_DEVICE and 0x32 ERROR_NOT_SUPPORTED.
+	// See: https://msdn.microsoft.com/en-us/library/cc231199.aspx
+	return errno == syscall.Errno(0x1) || errno == syscall.Errno(0x5) ||
+		errno == syscall.Errno(0x11) || errno == syscall.Errno(0x32)
+}